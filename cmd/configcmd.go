@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"testovoe/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `usage: testovoe [flags] config print [-redacted]`
+
+// runConfig prints the config that every layer adds up to, as YAML that
+// can be used as a config file.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "replace secrets")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	out := *cfg
+	if *redacted {
+		out = cfg.Redacted()
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	err = enc.Encode(out)
+	if err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testovoe/internal/auth"
+	"testovoe/internal/config"
+	"testovoe/internal/domain"
+	"testovoe/internal/storage"
+	"text/tabwriter"
+	"time"
+)
+
+const keysUsage = `usage: testovoe keys create -name NAME -scopes SCOPE[,SCOPE] [-collections NAME[,NAME]]
+       testovoe keys list
+       testovoe keys revoke ID`
+
+// runKeys manages API keys. The token of a new key is printed once and
+// cannot be recovered later.
+func runKeys(ctx context.Context, cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+
+	err := prepareSchema(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+
+	connString, err := cfg.Postgres.ConnString()
+	if err != nil {
+		return err
+	}
+
+	mode, err := domain.ParseNumberMode(cfg.Numbers.Mode)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.New(ctx, connString, storage.Pool{MaxConns: 1}, mode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "create":
+		return createKey(ctx, db, args[1:])
+	case "list":
+		return listKeys(ctx, db)
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(keysUsage)
+		}
+		err = db.RevokeAPIKey(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		log.Info("Revoked API key", "id", args[1], "takes_effect_within", cfg.Auth.KeyCacheTTL)
+		return nil
+	default:
+		return errors.New(keysUsage)
+	}
+}
+
+func createKey(ctx context.Context, db *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := fs.String("name", "", "who or what the key is for")
+	scopes := fs.String("scopes", "", "comma-separated scopes: nums:read, nums:write, admin")
+	collections := fs.String("collections", "", "comma-separated collections the key is limited to; all when empty")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" || *scopes == "" {
+		return errors.New(keysUsage)
+	}
+	key := domain.APIKey{Name: *name, Scopes: splitList(*scopes), Collections: splitList(*collections)}
+	for _, scope := range key.Scopes {
+		if !domain.ValidScope(scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	token, id, hash := auth.NewToken()
+	key.ID, key.Hash = id, hash
+	_, err = db.CreateAPIKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	return nil
+}
+
+func listKeys(ctx context.Context, db *storage.Storage) error {
+	keys, err := db.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCOLLECTIONS\tCREATED AT\tREVOKED AT")
+	for _, key := range keys {
+		collections, revokedAt := "*", "-"
+		if len(key.Collections) > 0 {
+			collections = strings.Join(key.Collections, ",")
+		}
+		if key.RevokedAt != nil {
+			revokedAt = key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, strings.Join(key.Scopes, ","),
+			collections, key.CreatedAt.Format(time.RFC3339), revokedAt)
+	}
+	return w.Flush()
+}
+
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
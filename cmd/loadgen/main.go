@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"testovoe/internal/client"
+	"testovoe/internal/http/handlers"
+	"testovoe/internal/http/router"
+	"testovoe/internal/loadgen"
+	"testovoe/internal/storage/memory"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	url := flag.String("url", "", "base URL of a running server; empty runs against an in-process handler")
+	concurrency := flag.Int("c", 8, "number of concurrent workers")
+	rate := flag.Float64("rate", 0, "requests per second across all workers, 0 for unlimited")
+	duration := flag.Duration("d", 10*time.Second, "test duration, 0 for no limit")
+	requests := flag.Int("n", 0, "total number of requests, 0 for no limit")
+	dist := flag.String("dist", loadgen.DistUniform, "number distribution: uniform, sequential or duplicates")
+	lo := flag.Int64("min", 0, "smallest generated number")
+	hi := flag.Int64("max", 1_000_000, "largest generated number")
+	distinct := flag.Int("distinct", 10, "distinct values for the duplicates distribution")
+	apiKey := flag.String("api-key", "", "API key sent with every request to -url")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, *url, loadgen.Config{
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Duration:    *duration,
+		Requests:    *requests,
+	}, *apiKey, *dist, *lo, *hi, *distinct)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, url string, cfg loadgen.Config, apiKey, dist string, lo, hi int64, distinct int) error {
+	gen, err := loadgen.NewGenerator(dist, lo, hi, distinct)
+	if err != nil {
+		return err
+	}
+
+	var target loadgen.Target
+	if url != "" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = cfg.Concurrency
+		var rt http.RoundTripper = transport
+		if apiKey != "" {
+			rt = client.WithAPIKey(transport, apiKey)
+		}
+		target = loadgen.NewHTTPTarget(url, &http.Client{Transport: rt})
+	} else {
+		target = loadgen.NewHandlerTarget(inProcessHandler(ctx))
+	}
+
+	report, err := loadgen.Run(ctx, cfg, target, gen)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	_, err = report.WriteTo(os.Stdout)
+	return err
+}
+
+// inProcessHandler wires the real router and use case on top of in-memory
+// storage, so runs measure the service code without Postgres.
+func inProcessHandler(ctx context.Context) http.Handler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	useCase := usecase.NewUseCase(log, memory.New())
+	httpRouter := chi.NewRouter()
+	router.Router(ctx, httpRouter, handlers.NewHTTPHandler(log, useCase), router.Options{})
+
+	return httpRouter
+}
@@ -2,13 +2,31 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
 	"testovoe/internal/application"
+	"testovoe/internal/audit"
+	"testovoe/internal/auth"
+	"testovoe/internal/batch"
+	"testovoe/internal/cache"
+	"testovoe/internal/certs"
 	"testovoe/internal/config"
+	"testovoe/internal/domain"
+	"testovoe/internal/features"
 	"testovoe/internal/http/handlers"
 	"testovoe/internal/http/router"
+	"testovoe/internal/index"
+	"testovoe/internal/logging"
+	"testovoe/internal/ratelimit"
+	"testovoe/internal/resilience"
+	"testovoe/internal/retention"
+	"testovoe/internal/spool"
 	"testovoe/internal/storage"
 	"testovoe/internal/usecase"
 
@@ -25,52 +43,412 @@ const (
 )
 
 func main() {
-	cfg := config.MustLoadConfig()
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run serves until SIGINT or SIGTERM, or runs the subcommand in args. On
+// the way out it stops the servers, then cancels the background workers and
+// waits for them, and only then closes what they use.
+func run(args []string) error {
+	cfg, loader, args := config.MustLoad(args)
+	if len(args) > 0 && args[0] == "config" {
+		return runConfig(cfg, args[1:])
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	var (
+		workers sync.WaitGroup
+		closers []func()
+	)
+	defer func() {
+		cancel()
+		workers.Wait()
+		for _, c := range slices.Backward(closers) {
+			c()
+		}
+	}()
+
+	logLevel := new(slog.LevelVar)
+	log := setupLogger(cfg.Env, cfg.Log, logLevel)
+	if level, ok := cfg.Level(); ok {
+		logLevel.Set(level)
+	}
+	expvar.Publish("log_dropped", expvar.Func(func() any { return logging.Dropped() }))
+	log.Info("Loaded config", "config", cfg.Redacted())
+
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrate(ctx, cfg, log, args[1:])
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		return nil
+	}
+
+	if len(args) > 0 && args[0] == "keys" {
+		err := runKeys(ctx, cfg, log, args[1:])
+		if err != nil {
+			return fmt.Errorf("key command failed: %w", err)
+		}
+		return nil
+	}
+
+	if len(args) > 0 && args[0] == "partition" {
+		err := runPartition(ctx, cfg, log)
+		if err != nil {
+			return fmt.Errorf("partitioning failed: %w", err)
+		}
+		return nil
+	}
+
+	// Validated with the rest of the config.
+	mode, _ := domain.ParseNumberMode(cfg.Numbers.Mode)
+
+	connString, err := cfg.Postgres.ConnString()
+	if err != nil {
+		return fmt.Errorf("invalid database config: %w", err)
+	}
+
+	db, err := storage.New(ctx, connString, storage.Pool{
+		MaxConns:          cfg.Postgres.MaxConns,
+		MinConns:          cfg.Postgres.MinConns,
+		MaxConnLifetime:   cfg.Postgres.MaxConnLifetime,
+		MaxConnIdleTime:   cfg.Postgres.MaxConnIdleTime,
+		HealthCheckPeriod: cfg.Postgres.HealthCheckPeriod,
+	}, mode)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	closers = append(closers, db.Close)
 
-	log := setupLogger(cfg.Env)
+	err = resilience.WaitFor(ctx, log, cfg.Resilience.StartupTimeout, db.Ping)
+	if err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
+	}
 
-	db, err := storage.New(ctx, cfg.Postgres.Addr)
+	err = prepareSchema(ctx, cfg, log)
 	if err != nil {
-		log.Error("Failed to connect to database", "error", err)
-		return
+		return fmt.Errorf("database schema is not ready: %w", err)
+	}
+
+	if len(cfg.Postgres.Replicas) > 0 {
+		replicas, err := cfg.Postgres.ReplicaConnStrings()
+		if err != nil {
+			return fmt.Errorf("invalid replica config: %w", err)
+		}
+		err = db.ConnectReplicas(ctx, replicas, cfg.Postgres.MaxReplicaLag)
+		if err != nil {
+			return fmt.Errorf("failed to connect to replicas: %w", err)
+		}
+		workers.Go(func() { db.RunReplicaCheck(ctx, cfg.Postgres.ReplicaCheckInterval) })
+		expvar.Publish("replicas", expvar.Func(func() any { return db.Replicas() }))
+	}
+
+	policy := domain.RetentionPolicy{
+		MaxAge:  cfg.Retention.MaxAge,
+		MaxRows: cfg.Retention.MaxRows,
+		Archive: cfg.Retention.Archive,
+	}
+	if cfg.Retention.Enabled {
+		db.SetRetention(policy)
 	}
-	defer db.Close()
 
 	httpRouter := chi.NewRouter()
+	admin := handlers.NewAdminHandler(log)
+	admin.SetLogLevel(logLevel)
+	admin.AddStats("pool", func() any { return db.PoolStats() })
+	admin.AddStats("log_dropped", func() any { return logging.Dropped() })
+	if len(cfg.Postgres.Replicas) > 0 {
+		admin.AddStats("replicas", func() any { return db.Replicas() })
+	}
+
+	var (
+		store   usecase.Storage = db
+		cached  *cache.Storage
+		indexed *index.Storage
+	)
+	if cfg.Writer.Enabled {
+		writer := batch.NewWriter(db, batch.Config{MaxBatch: cfg.Writer.MaxBatch, MaxDelay: cfg.Writer.MaxDelay})
+		closers = append(closers, writer.Close)
+		expvar.Publish("writer", expvar.Func(func() any { return writer.Metrics() }))
+		admin.AddStats("writer", func() any { return writer.Metrics() })
+		store = writer
+	}
+	resilient := resilience.NewStorage(log, store, resilience.Config{
+		Retries:          cfg.Resilience.Retries,
+		BaseDelay:        cfg.Resilience.BaseDelay,
+		MaxDelay:         cfg.Resilience.MaxDelay,
+		BreakerThreshold: cfg.Resilience.BreakerThreshold,
+		BreakerCooldown:  cfg.Resilience.BreakerCooldown,
+	})
+	expvar.Publish("breaker", expvar.Func(func() any { return resilient.Breaker().State() }))
+	admin.AddStats("breaker", func() any { return resilient.Breaker().State() })
+	store = resilient
+	if cfg.Cache.Enabled {
+		cached = cache.NewStorage(store, cfg.Cache.TTL)
+		expvar.Publish("cache", expvar.Func(func() any { return cached.Metrics() }))
+		admin.AddStats("cache", func() any { return cached.Metrics() })
+		admin.SetCache(cached)
+		store = cached
+	}
+	if cfg.Index.Enabled {
+		indexed = index.NewStorage(log, store)
+		if cfg.Retention.Enabled {
+			indexed.SetRetention(policy)
+		}
+		err = indexed.Resync(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to warm index: %w", err)
+		}
+		workers.Go(func() { indexed.RunResync(ctx, cfg.Index.ResyncInterval) })
+		admin.AddJob("resync", func(ctx context.Context) (any, error) {
+			return nil, indexed.Resync(ctx)
+		})
+		store = indexed
+	}
+	if cfg.Retention.Enabled {
+		job := retention.New(log, db, policy, cfg.Retention.PartitionsAhead)
+		if cached != nil {
+			job.OnRemoved(func(context.Context) { cached.Flush() })
+		}
+		if indexed != nil {
+			job.OnRemoved(func(ctx context.Context) {
+				err := indexed.Resync(ctx)
+				if err != nil {
+					log.Error("Failed to resync index after retention", "error", err)
+				}
+			})
+		}
+		expvar.Publish("retention", expvar.Func(func() any { return job.Metrics() }))
+		admin.AddStats("retention", func() any { return job.Metrics() })
+		admin.AddJob("retention", func(ctx context.Context) (any, error) {
+			return job.Enforce(ctx)
+		})
+		workers.Go(func() { job.Run(ctx, cfg.Retention.Interval) })
+	}
+	var spooled *spool.Storage
+	if cfg.Spool.Enabled {
+		spoolLog, err := spool.Open(cfg.Spool.Path)
+		if err != nil {
+			return fmt.Errorf("failed to open write spool: %w", err)
+		}
+		closers = append(closers, func() { _ = spoolLog.Close() })
+		spooled = spool.NewStorage(log, store, spoolLog)
+		expvar.Publish("spool", expvar.Func(func() any { return spooled.Metrics() }))
+		admin.AddStats("spool", func() any { return spooled.Metrics() })
+		admin.AddJob("spool-drain", func(ctx context.Context) (any, error) {
+			return nil, spooled.Drain(ctx)
+		})
+		workers.Go(func() { spooled.Run(ctx, cfg.Spool.ReplayInterval) })
+		store = spooled
+	}
+
+	useCase := usecase.NewUseCase(log, store)
+	useCase.SetValidationPolicy(validationPolicy(cfg, mode))
+	admin.SetReadOnlySwitch(useCase)
 
-	useCase := usecase.NewUseCase(log, db)
+	if cfg.Audit.Enabled {
+		var (
+			dbSink   audit.DBSink
+			fileSink *audit.FileSink
+		)
+		if slices.Contains(cfg.Audit.Sinks, "db") {
+			dbSink = db
+		}
+		if slices.Contains(cfg.Audit.Sinks, "file") {
+			fileSink, err = audit.OpenFile(cfg.Audit.File)
+			if err != nil {
+				return fmt.Errorf("failed to open audit file: %w", err)
+			}
+			closers = append(closers, func() { _ = fileSink.Close() })
+		}
+		useCase.SetAuditor(audit.New(dbSink, fileSink))
+	}
+
+	err = useCase.Orders().Register("evens_first", usecase.EvensFirst)
+	if err != nil {
+		return fmt.Errorf("failed to register sort order: %w", err)
+	}
 
-	httpHandlers := handlers.NewHTTPHandler(useCase)
+	httpHandlers := handlers.NewHTTPHandler(log, useCase)
+	httpHandlers.AddHealthCheck("database", func(ctx context.Context) (any, error) {
+		return resilient.Breaker().State(), db.Ping(ctx)
+	})
+	if cached != nil {
+		httpHandlers.AddHealthCheck("cache", func(ctx context.Context) (any, error) {
+			return cached.Metrics(), nil
+		})
+	}
+	if spooled != nil {
+		httpHandlers.AddHealthCheck("spool", func(ctx context.Context) (any, error) {
+			return spooled.Metrics(), nil
+		})
+	}
 
 	httpRouter.Use(middleware.RequestID)
+	httpRouter.Use(logging.RequestFields)
+	if cfg.Log.Access {
+		httpRouter.Use(logging.AccessLog(log))
+	}
 	httpRouter.Use(middleware.Recoverer)
 
-	router.Router(ctx, httpRouter, httpHandlers)
+	var authn *auth.Authenticator
+	if cfg.Auth.Enabled {
+		var keys auth.KeyStore = db
+		if cfg.Auth.KeyCacheTTL > 0 {
+			keys = auth.NewKeyCache(db, cfg.Auth.KeyCacheTTL)
+		}
+		authn = auth.New(log, keys)
+	}
+	if authn != nil {
+		authn.SetCertRules(cfg.Auth.ClientCerts)
+	}
+	if authn != nil && cfg.Auth.JWT.Enabled {
+		jwks := auth.NewJWKSFile(log, cfg.Auth.JWT.JWKSFile)
+		if cfg.Auth.JWT.JWKSURL != "" {
+			jwks = auth.NewJWKSURL(log, cfg.Auth.JWT.JWKSURL, nil)
+		}
+		err = jwks.Refresh(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load JWKS: %w", err)
+		}
+		workers.Go(func() { jwks.Run(ctx, cfg.Auth.JWT.RefreshInterval) })
+
+		authn.SetVerifier(auth.NewVerifier(jwks, auth.JWTConfig{
+			Issuer:           cfg.Auth.JWT.Issuer,
+			Audience:         cfg.Auth.JWT.Audience,
+			ClockSkew:        cfg.Auth.JWT.ClockSkew,
+			ScopesClaim:      cfg.Auth.JWT.ScopesClaim,
+			CollectionsClaim: cfg.Auth.JWT.CollectionsClaim,
+		}))
+	}
+
+	flags := features.New(cfg.Features)
+	routerOpts := router.Options{Log: log, Auth: authn, Features: flags}
+	if cfg.Audit.Enabled && slices.Contains(cfg.Audit.Sinks, "db") {
+		admin.SetAuditLog(db)
+	}
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.New(cfg.RateLimit.Limits())
+		admin.SetRateLimits(limiter)
+		expvar.Publish("rate_limit", expvar.Func(func() any { return limiter.Metrics() }))
+		admin.AddStats("rate_limit", func() any { return limiter.Metrics() })
+		routerOpts.Limiter, routerOpts.Quotas = limiter, db
+	}
+
+	router.Router(ctx, httpRouter, httpHandlers, routerOpts)
+
+	reloader := config.NewReloader(log, loader, cfg, func(next *config.Config, changed []string) {
+		if config.Touches(changed, "log.level") {
+			if level, ok := next.Level(); ok {
+				logLevel.Set(level)
+			} else {
+				logLevel.Set(defaultLogLevel(next.Env))
+			}
+		}
+		if config.Touches(changed, "validation") {
+			useCase.SetValidationPolicy(validationPolicy(next, mode))
+		}
+		if config.Touches(changed, "rate_limit") && routerOpts.Limiter != nil {
+			// Valid, since the whole config was validated.
+			_ = routerOpts.Limiter.SetLimits(next.RateLimit.Limits())
+		}
+		if config.Touches(changed, "cache.ttl") && cached != nil {
+			cached.SetTTL(next.Cache.TTL)
+		}
+		if config.Touches(changed, "features") {
+			flags.Set(next.Features)
+		}
+	})
+	admin.SetConfig(reloader)
+	workers.Go(func() {
+		err := reloader.Run(ctx)
+		if err != nil {
+			log.Error("Config reload is off", "error", err)
+		}
+	})
 
 	app := application.NewApplication(ctx, cfg, log, httpRouter)
+	if cfg.HttpServer.TLS.Enabled {
+		// Validated with the rest of the config.
+		opts, _ := cfg.HttpServer.TLS.Options()
+		reloader, err := certs.New(log, opts)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+		workers.Go(func() { reloader.Run(ctx, cfg.HttpServer.TLS.ReloadInterval) })
+		app.SetTLSConfig(reloader.TLSConfig())
+	}
+
+	if cfg.Admin.Enabled {
+		adminRouter := chi.NewRouter()
+		adminRouter.Use(middleware.RequestID, logging.RequestFields)
+		if cfg.Log.Access {
+			adminRouter.Use(logging.AccessLog(log))
+		}
+		adminRouter.Use(middleware.Recoverer)
+		router.Admin(ctx, adminRouter, admin, cfg.Admin.Token)
+		app.SetAdmin(adminRouter)
+	}
+
+	app.OnShutdown(httpHandlers.CloseStreams)
 
-	app.MustRun()
+	err = app.Run()
+	if err != nil {
+		return fmt.Errorf("failed to start servers: %w", err)
+	}
 
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
 	app.Shutdown()
+	return nil
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+// setupLogger logs at level, which starts at the default for env and may
+// be changed later. An unknown env is logged like prod.
+func setupLogger(env string, cfg config.Log, level *slog.LevelVar) *slog.Logger {
+	level.Set(defaultLogLevel(env))
+	log := logging.New(os.Stdout, logging.Options{
+		Format: cfg.Format,
+		Level:  level,
+		Redact: cfg.Redact,
+		Sampling: logging.Sampling{
+			First:      cfg.Sampling.First,
+			Thereafter: cfg.Sampling.Thereafter,
+			Tick:       cfg.Sampling.Tick,
+		},
+	})
 
 	switch env {
-	case envLocal:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDev:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	case envLocal, envDev, envProd:
+	default:
+		log.Warn("unknown env, logging as in prod", "env", env)
 	}
 
 	return log
 }
+
+func defaultLogLevel(env string) slog.Level {
+	if env == envLocal || env == envDev {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+func validationPolicy(cfg *config.Config, mode domain.NumberMode) usecase.ValidationPolicy {
+	return usecase.ValidationPolicy{
+		Mode:             mode,
+		AllowNaN:         cfg.Numbers.AllowNaN,
+		Min:              cfg.Validation.Min,
+		Max:              cfg.Validation.Max,
+		Allow:            cfg.Validation.Allow,
+		Deny:             cfg.Validation.Deny,
+		MaxIntegerDigits: cfg.Validation.MaxIntegerDigits,
+		MaxScale:         cfg.Validation.MaxScale,
+	}
+}
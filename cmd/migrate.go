@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testovoe/internal/config"
+	"testovoe/internal/migrator"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: testovoe migrate up|down|status|redo"
+
+func runMigrate(ctx context.Context, cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	connString, err := cfg.Postgres.ConnString()
+	if err != nil {
+		return err
+	}
+
+	m, err := migrator.New(log, connString)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "redo":
+		return m.Redo(ctx)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tSOURCE")
+		for _, st := range statuses {
+			appliedAt := "-"
+			if !st.AppliedAt.IsZero() {
+				appliedAt = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Source.Version, st.State, appliedAt, st.Source.Path)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// prepareSchema applies pending migrations when auto_migrate is enabled and
+// refuses to start if the schema is still older than the embedded one.
+func prepareSchema(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
+	connString, err := cfg.Postgres.ConnString()
+	if err != nil {
+		return err
+	}
+
+	m, err := migrator.New(log, connString)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if cfg.Postgres.AutoMigrate {
+		err = m.Up(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.CheckVersion(ctx)
+}
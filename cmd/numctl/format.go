@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testovoe/internal/domain"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, expected table, json or csv", format)
+	}
+}
+
+// formatFromPath picks json or csv by file extension and falls back to
+// table, which for files means one number per line.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".csv":
+		return formatCSV
+	default:
+		return formatTable
+	}
+}
+
+func writeNumbers(w io.Writer, format string, numbers []domain.Number) error {
+	switch format {
+	case formatJSON:
+		if numbers == nil {
+			numbers = []domain.Number{}
+		}
+		return json.NewEncoder(w).Encode(numbers)
+	case formatCSV:
+		err := writeCSVHeader(w)
+		if err != nil {
+			return err
+		}
+		fallthrough
+	default:
+		for _, num := range numbers {
+			err := writeNumber(w, format, num)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeNumber writes num on a line of its own, as writeNumbers writes the
+// rows of a table or csv. In json it is written as an object, so a stream of
+// numbers is one JSON value per line.
+func writeNumber(w io.Writer, format string, num domain.Number) error {
+	switch format {
+	case formatJSON:
+		return json.NewEncoder(w).Encode(domain.UserNum{Num: num})
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{num.String()})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		_, err := fmt.Fprintln(w, num)
+		return err
+	}
+}
+
+func writeCSVHeader(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"num"})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeStats(w io.Writer, format string, stats domain.Stats) error {
+	rows := [][2]string{
+		{"count", strconv.FormatInt(stats.Count, 10)},
+		{"min", stats.Min.String()},
+		{"max", stats.Max.String()},
+		{"sum", stats.Sum.String()},
+		{"mean", stats.Mean.String()},
+	}
+
+	switch format {
+	case formatJSON:
+		return json.NewEncoder(w).Encode(stats)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		for _, row := range rows {
+			err := cw.Write(row[:])
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, row := range rows {
+			fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+		}
+		return tw.Flush()
+	}
+}
+
+// readNumbers parses a JSON array, a CSV file with an optional "num" header
+// or plain text with numbers separated by whitespace or commas.
+func readNumbers(r io.Reader, format string) ([]domain.Number, error) {
+	switch format {
+	case formatJSON:
+		var numbers []domain.Number
+		err := json.NewDecoder(r).Decode(&numbers)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse json: %w", err)
+		}
+		return numbers, nil
+	case formatCSV:
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse csv: %w", err)
+		}
+
+		var numbers []domain.Number
+		for i, record := range records {
+			if len(record) == 0 {
+				continue
+			}
+			if i == 0 && record[0] == "num" {
+				continue
+			}
+			num, err := domain.ParseNumber(strings.TrimSpace(record[0]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			numbers = append(numbers, num)
+		}
+		return numbers, nil
+	default:
+		var numbers []domain.Number
+		scanner := bufio.NewScanner(r)
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			for _, field := range strings.Split(scanner.Text(), ",") {
+				if field == "" {
+					continue
+				}
+				num, err := domain.ParseNumber(field)
+				if err != nil {
+					return nil, err
+				}
+				numbers = append(numbers, num)
+			}
+		}
+		return numbers, scanner.Err()
+	}
+}
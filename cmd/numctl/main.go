@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"testovoe/internal/client"
+	"testovoe/internal/domain"
+	"time"
+)
+
+const (
+	envAddr     = "NUMCTL_ADDR"
+	envAPIKey   = "NUMCTL_API_KEY"
+	defaultAddr = "http://localhost:8081"
+)
+
+const usage = `usage: numctl [-addr URL] [-api-key KEY] [-timeout D] <command> [flags] [args]
+
+commands:
+  put [N ...]      store numbers given as arguments, or read them from stdin
+  list             print stored numbers (-order NAME, -limit N, -format)
+  stats            print count, min, max, sum and mean (-format)
+  watch            follow numbers as they are stored (-format)
+  import FILE      store every number from a .json, .csv or plain text file
+  export FILE      write stored numbers to a .json, .csv or plain text file
+
+The server address defaults to $NUMCTL_ADDR or ` + defaultAddr + `,
+the API key to $NUMCTL_API_KEY.
+`
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "numctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	addr := os.Getenv(envAddr)
+	if addr == "" {
+		addr = defaultAddr
+	}
+
+	fs := flag.NewFlagSet("numctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	fs.StringVar(&addr, "addr", addr, "server address")
+	apiKey := fs.String("api-key", os.Getenv(envAPIKey), "API key sent with every request")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout, not applied to watch")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing command")
+	}
+
+	var httpClient *http.Client
+	if *apiKey != "" {
+		httpClient = &http.Client{Transport: client.WithAPIKey(nil, *apiKey)}
+	}
+
+	cmd := &command{
+		client:  client.New(addr, httpClient),
+		timeout: *timeout,
+		stdin:   stdin,
+		stdout:  stdout,
+	}
+
+	name, rest := fs.Arg(0), fs.Args()[1:]
+	switch name {
+	case "put":
+		return cmd.put(ctx, rest)
+	case "list":
+		return cmd.list(ctx, rest)
+	case "stats":
+		return cmd.stats(ctx, rest)
+	case "watch":
+		return cmd.watch(ctx, rest)
+	case "import":
+		return cmd.importFile(ctx, rest)
+	case "export":
+		return cmd.exportFile(ctx, rest)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+type command struct {
+	client  *client.Client
+	timeout time.Duration
+	stdin   io.Reader
+	stdout  io.Writer
+}
+
+func (c *command) put(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("put", flag.ContinueOnError)
+	format := fs.String("format", formatTable, "output format: table, json or csv")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	var numbers []domain.Number
+	if fs.NArg() == 0 || (fs.NArg() == 1 && fs.Arg(0) == "-") {
+		numbers, err = readNumbers(c.stdin, formatTable)
+		if err != nil {
+			return fmt.Errorf("could not read stdin: %w", err)
+		}
+	} else {
+		for _, arg := range fs.Args() {
+			num, err := domain.ParseNumber(arg)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", arg)
+			}
+			numbers = append(numbers, num)
+		}
+	}
+
+	var sorted []domain.Number
+	for _, num := range numbers {
+		sorted, err = c.putOne(ctx, num)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeNumbers(c.stdout, *format, sorted)
+}
+
+func (c *command) putOne(ctx context.Context, num domain.Number) ([]domain.Number, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	return c.client.Put(ctx, num)
+}
+
+func (c *command) list(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	order := fs.String("order", "asc", "sort order, e.g. asc, desc, abs, inserted")
+	limit := fs.Int("limit", 0, "maximum number of values, 0 for all")
+	format := fs.String("format", formatTable, "output format: table, json or csv")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	numbers, err := c.client.List(ctx, client.ListOptions{Order: *order, Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	return writeNumbers(c.stdout, *format, numbers)
+}
+
+func (c *command) stats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	format := fs.String("format", formatTable, "output format: table, json or csv")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	stats, err := c.client.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeStats(c.stdout, *format, stats)
+}
+
+func (c *command) watch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	format := fs.String("format", formatTable, "output format: table, json or csv")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	if *format == formatCSV {
+		err = writeCSVHeader(c.stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.client.Watch(ctx, func(num domain.Number) error {
+		return writeNumber(c.stdout, *format, num)
+	})
+}
+
+func (c *command) importFile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: table, json or csv; detected from the extension by default")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("import expects exactly one file")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	numbers, err := readNumbers(f, *format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, num := range numbers {
+		_, err = c.putOne(ctx, num)
+		if err != nil {
+			return fmt.Errorf("imported %d of %d: %w", i, len(numbers), err)
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "imported %d numbers\n", len(numbers))
+
+	return nil
+}
+
+func (c *command) exportFile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	order := fs.String("order", "asc", "sort order, e.g. asc, desc, abs, inserted")
+	format := fs.String("format", "", "output format: table, json or csv; detected from the extension by default")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("export expects exactly one file")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	numbers, err := c.client.List(ctx, client.ListOptions{Order: *order})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = writeNumbers(f, *format, numbers)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "exported %d numbers\n", len(numbers))
+
+	return nil
+}
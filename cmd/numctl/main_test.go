@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+)
+
+// fakeServer stores numbers like the API does and remembers what it was
+// asked.
+type fakeServer struct {
+	mu      sync.Mutex
+	nums    []domain.Number
+	queries []string
+	auth    []string
+}
+
+func newFakeServer(t *testing.T, nums ...domain.Number) (*fakeServer, *httptest.Server) {
+	f := &fakeServer{nums: nums}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /put-num", func(w http.ResponseWriter, r *http.Request) {
+		var userNum domain.UserNum
+		if err := json.NewDecoder(r.Body).Decode(&userNum); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.nums = append(f.nums, userNum.Num)
+		sorted := slices.SortedFunc(slices.Values(f.nums), domain.Number.Cmp)
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(sorted)
+	})
+	mux.HandleFunc("GET /nums", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.queries = append(f.queries, r.URL.RawQuery)
+		nums := slices.Clone(f.nums)
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(nums)
+	})
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(domain.Stats{Count: 2})
+	})
+	mux.HandleFunc("GET /nums/watch", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"num\":1}\n\ndata: {\"num\":\"NaN\"}\n\n")
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.auth = append(f.auth, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv(envAPIKey, "")
+
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		stored  []domain.Number
+		want    string
+		wantErr string
+		// check inspects the server after the command ran.
+		check func(t *testing.T, f *fakeServer)
+	}{
+		{
+			name: "put arguments",
+			args: []string{"put", "3", "1", "2"},
+			want: "1\n2\n3\n",
+		},
+		{
+			name:  "put from stdin",
+			args:  []string{"put", "-format", "json"},
+			stdin: "5, 4\n6",
+			want:  "[4,5,6]\n",
+		},
+		{
+			name:    "put rejects a non-number",
+			args:    []string{"put", "x"},
+			wantErr: `invalid number "x"`,
+		},
+		{
+			name:   "list passes order and limit",
+			args:   []string{"list", "-order", "desc", "-limit", "2", "-format", "csv"},
+			stored: []domain.Number{domain.NewInt(7), domain.NewInt(8)},
+			want:   "num\n7\n8\n",
+			check: func(t *testing.T, f *fakeServer) {
+				assert.Equal(t, []string{"limit=2&order=desc"}, f.queries)
+			},
+		},
+		{
+			name:    "list rejects an unknown format",
+			args:    []string{"list", "-format", "xml"},
+			wantErr: `unknown format "xml"`,
+		},
+		{
+			name: "stats",
+			args: []string{"stats", "-format", "csv"},
+			want: "count,2\nmin,0\nmax,0\nsum,0\nmean,0\n",
+		},
+		{
+			name: "watch as json",
+			args: []string{"watch", "-format", "json"},
+			want: "{\"num\":1}\n{\"num\":\"NaN\"}\n",
+		},
+		{
+			name: "api key flag",
+			args: []string{"-api-key", "tt_a_b", "list"},
+			check: func(t *testing.T, f *fakeServer) {
+				assert.Equal(t, []string{"Bearer tt_a_b"}, f.auth)
+			},
+		},
+		{
+			name:    "missing command",
+			wantErr: "missing command",
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"frobnicate"},
+			wantErr: `unknown command "frobnicate"`,
+		},
+		{
+			name:    "unknown global flag",
+			args:    []string{"-verbose", "list"},
+			wantErr: "flag provided but not defined: -verbose",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeServer(t, tt.stored...)
+
+			var stdout bytes.Buffer
+			args := append([]string{"-addr", srv.URL}, tt.args...)
+			err := run(context.Background(), args, strings.NewReader(tt.stdin), &stdout)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stdout.String())
+			if tt.check != nil {
+				tt.check(t, f)
+			}
+		})
+	}
+}
+
+func TestRun_ImportDetectsFormat(t *testing.T) {
+	t.Setenv(envAPIKey, "")
+
+	tests := []struct {
+		file    string
+		content string
+		flags   []string
+		want    []domain.Number
+	}{
+		{file: "nums.json", content: `[1, 2.5]`, want: []domain.Number{domain.NewInt(1), domain.MustParseNumber("2.5")}},
+		{file: "nums.csv", content: "num\n3\n4\n", want: []domain.Number{domain.NewInt(3), domain.NewInt(4)}},
+		{file: "nums.txt", content: "5 6,7\n", want: []domain.Number{domain.NewInt(5), domain.NewInt(6), domain.NewInt(7)}},
+		{file: "nums.dat", content: "num\n8\n", flags: []string{"-format", "csv"}, want: []domain.Number{domain.NewInt(8)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, srv := newFakeServer(t)
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			var stdout bytes.Buffer
+			args := append(append([]string{"-addr", srv.URL, "import"}, tt.flags...), path)
+			require.NoError(t, run(context.Background(), args, nil, &stdout))
+
+			assert.Equal(t, fmt.Sprintf("imported %d numbers\n", len(tt.want)), stdout.String())
+			assert.Equal(t, tt.want, f.nums)
+		})
+	}
+}
+
+func TestRun_ExportDetectsFormat(t *testing.T) {
+	t.Setenv(envAPIKey, "")
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "nums.json", want: "[1,2]\n"},
+		{file: "nums.csv", want: "num\n1\n2\n"},
+		{file: "nums.txt", want: "1\n2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, srv := newFakeServer(t, domain.NewInt(1), domain.NewInt(2))
+			path := filepath.Join(t.TempDir(), tt.file)
+
+			var stdout bytes.Buffer
+			require.NoError(t, run(context.Background(), []string{"-addr", srv.URL, "export", path}, nil, &stdout))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+			assert.Equal(t, "exported 2 numbers\n", stdout.String())
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testovoe/internal/config"
+	"testovoe/internal/domain"
+	"testovoe/internal/storage"
+)
+
+// runPartition converts nums into a table partitioned by day, after which
+// retention drops whole partitions instead of deleting rows. Writes block
+// while the existing rows are copied.
+func runPartition(ctx context.Context, cfg *config.Config, log *slog.Logger) error {
+	err := prepareSchema(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+
+	connString, err := cfg.Postgres.ConnString()
+	if err != nil {
+		return err
+	}
+
+	mode, err := domain.ParseNumberMode(cfg.Numbers.Mode)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.New(ctx, connString, storage.Pool{MaxConns: 1}, mode)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	created, err := db.Partition(ctx, cfg.Retention.PartitionsAhead)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Partitioned nums", "partitions", created)
+	return nil
+}
@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
 	"net/http"
 	"sync"
@@ -15,6 +16,7 @@ type Application struct {
 	cfg    *config.Config
 	log    *slog.Logger
 	server *http.Server
+	admin  *http.Server
 }
 
 func NewApplication(ctx context.Context, cfg *config.Config, log *slog.Logger, router *chi.Mux) *Application {
@@ -34,6 +36,30 @@ func NewApplication(ctx context.Context, cfg *config.Config, log *slog.Logger, r
 	}
 }
 
+// SetTLSConfig makes the server accept only TLS connections, using cfg. It
+// must be called before Run.
+func (a *Application) SetTLSConfig(cfg *tls.Config) {
+	a.server.TLSConfig = cfg
+}
+
+// SetAdmin serves handler on the admin address from the config, over plain
+// HTTP, next to the API. It must be called before Run.
+func (a *Application) SetAdmin(handler http.Handler) {
+	a.admin = &http.Server{
+		Addr:         a.cfg.Admin.Address,
+		Handler:      handler,
+		ReadTimeout:  a.cfg.Admin.Timeout,
+		WriteTimeout: a.cfg.Admin.Timeout,
+	}
+}
+
+// OnShutdown registers fn to be called when Shutdown starts, before it
+// waits for requests in flight. Long-lived requests such as event streams
+// use it to end.
+func (a *Application) OnShutdown(fn func()) {
+	a.server.RegisterOnShutdown(fn)
+}
+
 func (a *Application) MustRun() {
 	err := a.Run()
 	if err != nil {
@@ -49,12 +75,31 @@ func (a *Application) Run() error {
 		defer wg.Done()
 		a.log.Info("Run: server started")
 
-		err := a.server.ListenAndServe()
+		var err error
+		if a.server.TLSConfig != nil {
+			// The certificate comes from the TLS config.
+			err = a.server.ListenAndServeTLS("", "")
+		} else {
+			err = a.server.ListenAndServe()
+		}
 		if err != nil {
 			a.log.Error("ListenAndServe: ", "error", err)
 		}
 	}()
 
+	if a.admin != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.log.Info("Run: admin server started", "address", a.admin.Addr)
+
+			err := a.admin.ListenAndServe()
+			if err != nil {
+				a.log.Error("ListenAndServe: admin server", "error", err)
+			}
+		}()
+	}
+
 	go func() {
 		wg.Wait()
 		a.log.Info("Run: server stopped")
@@ -66,8 +111,17 @@ func (a *Application) Run() error {
 func (a *Application) Shutdown() {
 	a.log.Info("Shutdown")
 
-	err := a.server.Shutdown(a.ctx)
+	ctx, cancel := context.WithTimeout(a.ctx, a.cfg.HttpServer.ShutdownTimeout)
+	defer cancel()
+
+	err := a.server.Shutdown(ctx)
 	if err != nil {
 		a.log.Error("Shutdown: failed to shutdown server", "error", err)
 	}
+	if a.admin != nil {
+		err = a.admin.Shutdown(ctx)
+		if err != nil {
+			a.log.Error("Shutdown: failed to shutdown admin server", "error", err)
+		}
+	}
 }
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testovoe/internal/domain"
+)
+
+// DBSink records entries in the database outside of any write, for the
+// attempts that changed nothing.
+type DBSink interface {
+	RecordAudit(ctx context.Context, entry domain.AuditEntry) error
+}
+
+// Auditor fans entries out to the configured sinks. With a database sink,
+// successful writes are recorded by storage in their own transaction, so
+// Record only adds the other outcomes there. The file sink is written after
+// the fact and receives every entry.
+type Auditor struct {
+	db   DBSink
+	file *FileSink
+}
+
+// New returns an Auditor for the given sinks; either may be nil.
+func New(db DBSink, file *FileSink) *Auditor {
+	return &Auditor{db: db, file: file}
+}
+
+func (a *Auditor) InTx() bool {
+	return a.db != nil
+}
+
+func (a *Auditor) Record(ctx context.Context, entry domain.AuditEntry, storedInTx bool) error {
+	var errs []error
+	if a.db != nil && !storedInTx {
+		errs = append(errs, a.db.RecordAudit(ctx, entry))
+	}
+	if a.file != nil {
+		errs = append(errs, a.file.Record(ctx, entry))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+)
+
+func testEntry(outcome string) domain.AuditEntry {
+	return domain.AuditEntry{
+		At:        time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Principal: "k1",
+		Operation: domain.AuditPut,
+		Payload:   []byte(`{"num":1}`),
+		Outcome:   outcome,
+	}
+}
+
+func TestFileSink_ChainSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+
+	s, err := OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(context.Background(), testEntry(domain.AuditSuccess)))
+	require.NoError(t, s.Record(context.Background(), testEntry(domain.AuditRejected)))
+	require.NoError(t, s.Close())
+
+	s, err = OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(context.Background(), testEntry(domain.AuditFailure)))
+	require.NoError(t, s.Close())
+
+	n, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+func TestVerifyFile_DetectsTampering(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+	}{
+		{"edited entry", func(lines [][]byte) [][]byte {
+			lines[1] = bytes.Replace(lines[1], []byte(`rejected`), []byte(`success`), 1)
+			return lines
+		}},
+		{"deleted entry", func(lines [][]byte) [][]byte {
+			return append(lines[:1], lines[2:]...)
+		}},
+		{"reordered entries", func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			s, err := OpenFile(path)
+			require.NoError(t, err)
+			for _, outcome := range []string{domain.AuditSuccess, domain.AuditRejected, domain.AuditFailure} {
+				require.NoError(t, s.Record(context.Background(), testEntry(outcome)))
+			}
+			require.NoError(t, s.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := tc.tamper(bytes.SplitAfter(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")))
+			require.NoError(t, os.WriteFile(path, append(bytes.Join(lines, nil), '\n'), 0o644))
+
+			_, err = VerifyFile(path)
+			assert.ErrorIs(t, err, ErrChainBroken)
+
+			_, err = OpenFile(path)
+			assert.ErrorIs(t, err, ErrChainBroken)
+		})
+	}
+}
+
+func TestFileSink_TornLastLineIsDropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	s, err := OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(context.Background(), testEntry(domain.AuditSuccess)))
+	require.NoError(t, s.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":2,"ha`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(context.Background(), testEntry(domain.AuditSuccess)))
+	require.NoError(t, s.Close())
+
+	n, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+type recordingDB struct {
+	entries []domain.AuditEntry
+}
+
+func (r *recordingDB) RecordAudit(_ context.Context, entry domain.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestAuditor_SkipsDatabaseForEntriesStoredInTx(t *testing.T) {
+	db := &recordingDB{}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	file, err := OpenFile(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	a := New(db, file)
+	assert.True(t, a.InTx())
+
+	require.NoError(t, a.Record(context.Background(), testEntry(domain.AuditSuccess), true))
+	require.NoError(t, a.Record(context.Background(), testEntry(domain.AuditRejected), false))
+
+	require.Len(t, db.entries, 1)
+	assert.Equal(t, domain.AuditRejected, db.entries[0].Outcome)
+
+	n, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
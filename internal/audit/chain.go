@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Genesis is the hash the first entry of a chain is linked to.
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// ErrChainBroken means an entry was changed, removed or inserted after it
+// was recorded.
+var ErrChainBroken = errors.New("audit chain broken")
+
+// Hash links an entry to the hash of the one before it:
+// sha256(prev || entry), hex encoded. Postgres computes the same in SQL.
+func Hash(prev string, entry []byte) string {
+	prevBytes, _ := hex.DecodeString(prev)
+	h := sha256.New()
+	h.Write(prevBytes)
+	h.Write(entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
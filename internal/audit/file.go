@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+// line is how an entry is written to a JSONL file: the exact bytes that were
+// hashed, and the hash linking them to the line before.
+type line struct {
+	Seq   int64           `json:"seq"`
+	Hash  string          `json:"hash"`
+	Entry json.RawMessage `json:"entry"`
+}
+
+// FileSink appends audit entries to a JSON Lines file, each fsynced and
+// hash-chained to the one before, so edits to the file are detected by
+// VerifyFile.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+	prev string
+}
+
+func OpenFile(path string) (*FileSink, error) {
+	const op = "audit.OpenFile"
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &FileSink{file: file, prev: Genesis}
+	err = s.load()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
+}
+
+// load verifies the chain so far and continues it. A torn last line, left by
+// a crash during an append, is cut off.
+func (s *FileSink) load() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	pos, err := walk(io.NewSectionReader(s.file, 0, info.Size()), func(l line) error {
+		s.seq, s.prev = l.Seq, l.Hash
+		return nil
+	})
+	if errors.Is(err, errTorn) {
+		return s.file.Truncate(pos)
+	}
+	return err
+}
+
+func (s *FileSink) Record(_ context.Context, entry domain.AuditEntry) error {
+	const op = "audit.FileSink.Record"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canonical := entry.Canonical()
+	l := line{Seq: s.seq + 1, Hash: Hash(s.prev, canonical), Entry: canonical}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		// Drop any partial line so the chain can continue.
+		_ = s.file.Truncate(info.Size())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.seq, s.prev = l.Seq, l.Hash
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// VerifyFile checks the chain of an audit file and returns how many entries
+// it holds. Broken links wrap ErrChainBroken.
+func VerifyFile(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var n int64
+	_, err = walk(file, func(line) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+var errTorn = errors.New("torn last line")
+
+// walk checks every line's hash and sequence against the line before and
+// calls fn with it. It returns the offset just past the last complete line.
+func walk(r io.Reader, fn func(line) error) (int64, error) {
+	br := bufio.NewReader(r)
+	prev, seq := Genesis, int64(0)
+
+	var pos int64
+	for {
+		raw, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(raw) > 0 {
+				return pos, errTorn
+			}
+			return pos, nil
+		}
+		if err != nil {
+			return pos, err
+		}
+
+		var l line
+		err = json.Unmarshal(bytes.TrimSpace(raw), &l)
+		if err != nil {
+			return pos, fmt.Errorf("entry %d: %w: %w", seq+1, ErrChainBroken, err)
+		}
+		if l.Seq != seq+1 {
+			return pos, fmt.Errorf("entry %d: %w: sequence jumps to %d", seq+1, ErrChainBroken, l.Seq)
+		}
+		if Hash(prev, l.Entry) != l.Hash {
+			return pos, fmt.Errorf("entry %d: %w: hash mismatch", l.Seq, ErrChainBroken)
+		}
+
+		err = fn(l)
+		if err != nil {
+			return pos, err
+		}
+		pos += int64(len(raw))
+		prev, seq = l.Hash, l.Seq
+	}
+}
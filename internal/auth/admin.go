@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testovoe/internal/domain"
+	"testovoe/internal/logging"
+	"testovoe/internal/reqctx"
+)
+
+// AdminPrincipal is the principal of requests to the admin server.
+var AdminPrincipal = domain.Principal{ID: "admin-token", Scopes: []string{domain.ScopeAdmin}}
+
+// RequireToken answers 401 unless a request sends "Authorization: Bearer
+// <token>". It guards the admin server independently of the key store, so
+// the admin API stays usable while the database is down.
+func RequireToken(token string) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(token))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			sum := sha256.Sum256([]byte(got))
+			if !ok || subtle.ConstantTimeCompare(sum[:], want[:]) != 1 {
+				unauthorized(w, "admin token required")
+				return
+			}
+
+			logging.AddFields(r.Context(), slog.String("principal", AdminPrincipal.ID))
+			next.ServeHTTP(w, r.WithContext(reqctx.WithPrincipal(r.Context(), AdminPrincipal)))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// API keys are handed out as "tt_<id>_<secret>". The id locates the stored
+// key; only a SHA-256 hash of the secret is stored. Secrets are 256 random
+// bits, so a fast hash is enough to keep a leaked table from yielding keys.
+const tokenPrefix = "tt_"
+
+// NewToken generates a key and returns the token to give to its owner, its
+// id and the hash to store.
+func NewToken() (token, id, hash string) {
+	id = randomHex(8)
+	secret := randomHex(32)
+	return tokenPrefix + id + "_" + secret, id, HashSecret(secret)
+}
+
+// ParseToken splits a token into its id and secret.
+func ParseToken(token string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// secretMatches compares in constant time, so response timing does not
+// reveal how much of a guessed secret was right.
+func secretMatches(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(hash)) == 1
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+	"testovoe/internal/domain"
+)
+
+// CertRule grants a verified client certificate the listed scopes. Subject
+// matches either the certificate's full subject, as in
+// "CN=ingest,O=Example", or just its common name.
+type CertRule struct {
+	Subject     string   `yaml:"subject"`
+	Scopes      []string `yaml:"scopes"`
+	Collections []string `yaml:"collections"`
+}
+
+// SetCertRules makes the Authenticator accept verified TLS client
+// certificates that match a rule in place of a token. It must be called
+// before serving requests.
+func (a *Authenticator) SetCertRules(rules []CertRule) {
+	a.certRules = rules
+}
+
+// certPrincipal returns the principal of the client certificate the TLS
+// handshake verified, if a rule matches it.
+func (a *Authenticator) certPrincipal(r *http.Request) (domain.Principal, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return domain.Principal{}, false
+	}
+	return CertPrincipal(r.TLS.VerifiedChains[0][0], a.certRules)
+}
+
+// CertPrincipal maps cert to a principal by the first rule matching its
+// subject. The principal's id is "cert:" and the common name.
+func CertPrincipal(cert *x509.Certificate, rules []CertRule) (domain.Principal, bool) {
+	subject := cert.Subject.String()
+	for _, rule := range rules {
+		if rule.Subject != subject && rule.Subject != cert.Subject.CommonName {
+			continue
+		}
+		return domain.Principal{
+			ID:          "cert:" + cert.Subject.CommonName,
+			Name:        subject,
+			Scopes:      rule.Scopes,
+			Collections: rule.Collections,
+		}, true
+	}
+	return domain.Principal{}, false
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JWKS is a JSON Web Key Set loaded from a file or URL. It is reloaded
+// every refresh interval by Run and, so that rotated keys are picked up
+// before then, whenever a token names a key it does not know, at most once
+// per minRefresh.
+type JWKS struct {
+	log  *slog.Logger
+	load func(ctx context.Context) ([]byte, error)
+
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	loadedAt   time.Time
+	minRefresh time.Duration
+}
+
+func NewJWKSFile(log *slog.Logger, path string) *JWKS {
+	return newJWKS(log, func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+func NewJWKSURL(log *slog.Logger, url string, client *http.Client) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return newJWKS(log, func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	})
+}
+
+func newJWKS(log *slog.Logger, load func(ctx context.Context) ([]byte, error)) *JWKS {
+	return &JWKS{log: log, load: load, minRefresh: 30 * time.Second}
+}
+
+// Refresh reloads the key set. On failure the previous keys stay in use.
+func (s *JWKS) Refresh(ctx context.Context) error {
+	const op = "auth.JWKS.Refresh"
+
+	data, err := s.load(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.Lock()
+	s.keys, s.loadedAt = keys, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the key set every interval until ctx is done.
+func (s *JWKS) Run(ctx context.Context, interval time.Duration) {
+	const op = "auth.JWKS.Run"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Refresh(ctx)
+			if err != nil {
+				s.log.Warn("could not refresh jwks", "op", op, "error", err)
+			}
+		}
+	}
+}
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// key returns the key with id kid, or the only key when kid is empty.
+func (s *JWKS) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, stale := s.lookup(kid)
+	if key != nil {
+		return key, nil
+	}
+	if !stale {
+		return nil, errUnknownKey
+	}
+
+	err := s.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, _ = s.lookup(kid)
+	if key == nil {
+		return nil, errUnknownKey
+	}
+	return key, nil
+}
+
+// lookup also reports whether the set may be reloaded to look for a key it
+// does not have.
+func (s *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stale := time.Since(s.loadedAt) >= s.minRefresh
+	if kid == "" {
+		if len(s.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range s.keys {
+			return key, false
+		}
+	}
+	return s.keys[kid], stale
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns the signing keys of a JWK Set by key id. RSA, P-256 and
+// Ed25519 keys are supported; other keys and encryption keys are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if x.BitLen() > 256 || y.BitLen() > 256 {
+			return nil, errors.New("invalid p-256 key")
+		}
+		// Parsing checks that the point is on the curve.
+		point := make([]byte, 65)
+		point[0] = 4
+		x.FillBytes(point[1:33])
+		y.FillBytes(point[33:])
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"testovoe/internal/domain"
+	"time"
+)
+
+// JWTConfig says which tokens a Verifier accepts and how their claims map to
+// a principal.
+type JWTConfig struct {
+	Issuer string
+	// Audience lists accepted audiences; a token must name at least one.
+	Audience []string
+	// ClockSkew is the leeway allowed when checking exp, nbf and iat.
+	ClockSkew time.Duration
+	// ScopesClaim holds the principal's scopes, as a space-separated string
+	// or an array. CollectionsClaim holds its collections likewise.
+	ScopesClaim      string
+	CollectionsClaim string
+}
+
+// Verifier checks JWTs signed with RS256, ES256 or EdDSA by a key in a JWKS.
+type Verifier struct {
+	keys *JWKS
+	cfg  JWTConfig
+	now  func() time.Time
+}
+
+func NewVerifier(keys *JWKS, cfg JWTConfig) *Verifier {
+	if cfg.ScopesClaim == "" {
+		cfg.ScopesClaim = "scope"
+	}
+	if cfg.CollectionsClaim == "" {
+		cfg.CollectionsClaim = "collections"
+	}
+	return &Verifier{keys: keys, cfg: cfg, now: time.Now}
+}
+
+// ErrInvalidToken is wrapped by every reason a token is refused.
+var ErrInvalidToken = errors.New("invalid token")
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks the signature and claims of token and returns the principal
+// it names. Failures that are not the token's fault, such as a JWKS that
+// cannot be fetched, do not wrap ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (domain.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return domain.Principal{}, fmt.Errorf("%w: not a compact jws", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: header: %w", ErrInvalidToken, err)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if errors.Is(err, errUnknownKey) {
+		return domain.Principal{}, fmt.Errorf("%w: %w %q", ErrInvalidToken, err, header.Kid)
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	err = verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var claims map[string]any
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: claims: %w", ErrInvalidToken, err)
+	}
+	err = v.checkClaims(claims)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	name, _ := claims["name"].(string)
+	return domain.Principal{
+		ID:          sub,
+		Name:        name,
+		Scopes:      stringList(claims[v.cfg.ScopesClaim]),
+		Collections: stringList(claims[v.cfg.CollectionsClaim]),
+	}, nil
+}
+
+// verifySignature only accepts the algorithm that matches the key's type, so
+// a token cannot pick a weaker check than the key was published for.
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return errors.New("bad signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			break
+		}
+		if len(sig) != 64 {
+			return errors.New("bad signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return errors.New("bad signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			break
+		}
+		if !ed25519.Verify(k, signed, sig) {
+			return errors.New("bad signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %q does not match the signing key", alg)
+}
+
+func (v *Verifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+	skew := v.cfg.ClockSkew
+
+	if iss, _ := claims["iss"].(string); v.cfg.Issuer != "" && iss != v.cfg.Issuer {
+		return fmt.Errorf("issuer %q not accepted", iss)
+	}
+
+	if len(v.cfg.Audience) > 0 {
+		accepted := slices.ContainsFunc(stringList(claims["aud"]), func(aud string) bool {
+			return slices.Contains(v.cfg.Audience, aud)
+		})
+		if !accepted {
+			return errors.New("audience not accepted")
+		}
+	}
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("exp is required")
+	}
+	if !now.Before(exp.Add(skew)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(skew).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(skew).Before(iat) {
+		return errors.New("token issued in the future")
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return errors.New("sub is required")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+// stringList reads a claim that is either a space-separated string or an
+// array of strings.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
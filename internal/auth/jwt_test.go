@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+)
+
+var b64 = base64.RawURLEncoding
+
+type testSigner struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func newSigners(t *testing.T) []testSigner {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return []testSigner{
+		{"rsa", "RS256", rsaKey},
+		{"ec", "ES256", ecKey},
+		{"ed", "EdDSA", edKey},
+	}
+}
+
+func (s testSigner) jwk() map[string]string {
+	switch pub := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "n": b64.EncodeToString(pub.N.Bytes()),
+			"e": b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		point, _ := pub.Bytes()
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": "P-256",
+			"x": b64.EncodeToString(point[1:33]), "y": b64.EncodeToString(point[33:])}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": s.kid, "crv": "Ed25519", "x": b64.EncodeToString(pub)}
+	}
+	panic("unexpected key type")
+}
+
+func (s testSigner) sign(t *testing.T, alg string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+
+	var sig []byte
+	var err error
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, []byte(signed))
+	}
+	require.NoError(t, err)
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func jwksJSON(signers ...testSigner) []byte {
+	keys := make([]map[string]string, len(signers))
+	for i, s := range signers {
+		keys[i] = s.jwk()
+	}
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	return data
+}
+
+var testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func newTestVerifier(t *testing.T, load func(context.Context) ([]byte, error)) *Verifier {
+	jwks := newJWKS(slog.New(slog.NewTextHandler(io.Discard, nil)), load)
+	require.NoError(t, jwks.Refresh(context.Background()))
+
+	v := NewVerifier(jwks, JWTConfig{
+		Issuer:    "https://idp.test",
+		Audience:  []string{"testovoe"},
+		ClockSkew: time.Minute,
+	})
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss":         "https://idp.test",
+		"aud":         []string{"other", "testovoe"},
+		"sub":         "svc-ingest",
+		"exp":         testNow.Add(time.Hour).Unix(),
+		"iat":         testNow.Unix(),
+		"scope":       "nums:read nums:write",
+		"collections": []string{"nums"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestVerifier_Algorithms(t *testing.T) {
+	signers := newSigners(t)
+	data := jwksJSON(signers...)
+	v := newTestVerifier(t, func(context.Context) ([]byte, error) { return data, nil })
+
+	for _, s := range signers {
+		t.Run(s.alg, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), s.sign(t, s.alg, claims(nil)))
+
+			require.NoError(t, err)
+			assert.Equal(t, domain.Principal{
+				ID:          "svc-ingest",
+				Scopes:      []string{domain.ScopeRead, domain.ScopeWrite},
+				Collections: []string{"nums"},
+			}, p)
+		})
+	}
+}
+
+func TestVerifier_RejectsTokens(t *testing.T) {
+	signers := newSigners(t)
+	rsaSigner, edSigner := signers[0], signers[2]
+	data := jwksJSON(signers...)
+	v := newTestVerifier(t, func(context.Context) ([]byte, error) { return data, nil })
+
+	// The claims of one token under the signature of another.
+	original := strings.Split(edSigner.sign(t, "EdDSA", claims(nil)), ".")
+	escalated := strings.Split(edSigner.sign(t, "EdDSA", claims(map[string]any{"scope": "admin"})), ".")
+	tampered := original[0] + "." + escalated[1] + "." + original[2]
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"expired beyond skew", rsaSigner.sign(t, "RS256", claims(map[string]any{"exp": testNow.Add(-2 * time.Minute).Unix()}))},
+		{"not yet valid", rsaSigner.sign(t, "RS256", claims(map[string]any{"nbf": testNow.Add(5 * time.Minute).Unix()}))},
+		{"missing exp", rsaSigner.sign(t, "RS256", claims(map[string]any{"exp": nil}))},
+		{"wrong issuer", rsaSigner.sign(t, "RS256", claims(map[string]any{"iss": "https://evil.test"}))},
+		{"wrong audience", rsaSigner.sign(t, "RS256", claims(map[string]any{"aud": "other"}))},
+		{"missing sub", rsaSigner.sign(t, "RS256", claims(map[string]any{"sub": nil}))},
+		{"alg does not match key", rsaSigner.sign(t, "EdDSA", claims(nil))},
+		{"alg none", rsaSigner.sign(t, "none", claims(nil))},
+		{"tampered", tampered},
+		{"not a jwt", "abc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tc.token)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err := v.Verify(context.Background(), rsaSigner.sign(t, "RS256",
+		claims(map[string]any{"exp": testNow.Add(-30 * time.Second).Unix()})))
+	assert.NoError(t, err, "expired within clock skew")
+}
+
+func TestVerifier_PicksUpRotatedKey(t *testing.T) {
+	signers := newSigners(t)
+	current := jwksJSON(signers[0])
+	loads := 0
+	v := newTestVerifier(t, func(context.Context) ([]byte, error) {
+		loads++
+		return current, nil
+	})
+	v.keys.minRefresh = 0
+
+	current = jwksJSON(signers[0], signers[1])
+	_, err := v.Verify(context.Background(), signers[1].sign(t, "ES256", claims(nil)))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	_, err = v.Verify(context.Background(), signers[2].sign(t, "EdDSA", claims(nil)))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_UnknownKeyRefreshIsThrottled(t *testing.T) {
+	signers := newSigners(t)
+	data := jwksJSON(signers[0])
+	loads := 0
+	v := newTestVerifier(t, func(context.Context) ([]byte, error) {
+		loads++
+		return data, nil
+	})
+
+	for range 3 {
+		_, err := v.Verify(context.Background(), signers[1].sign(t, "ES256", claims(nil)))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+	assert.Equal(t, 1, loads, "only the initial load")
+}
+
+func TestMiddleware_AcceptsJWT(t *testing.T) {
+	signers := newSigners(t)
+	data := jwksJSON(signers...)
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), keyMap{})
+	a.SetVerifier(newTestVerifier(t, func(context.Context) ([]byte, error) { return data, nil }))
+
+	token := signers[2].sign(t, "EdDSA", claims(map[string]any{"scope": []string{"nums:read"}}))
+
+	var seen domain.Principal
+	h := a.Middleware(Require(domain.ScopeRead, domain.Collection)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = reqctx.Principal(r.Context())
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/nums", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "svc-ingest", seen.ID)
+
+	req.Header.Set("Authorization", "Bearer "+signers[2].sign(t, "EdDSA", claims(map[string]any{"exp": testNow.Add(-time.Hour).Unix()})))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token expired")
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testovoe/internal/domain"
+	"time"
+)
+
+// maxCachedKeys bounds the cache against lookups of made-up ids.
+const maxCachedKeys = 10000
+
+// KeyCache remembers the keys, and the ids that match none, that a KeyStore
+// returned for ttl. A revoked key is therefore refused at most ttl after the
+// revocation. Lookups that fail for other reasons are not cached.
+type KeyCache struct {
+	keys KeyStore
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedKey
+}
+
+type cachedKey struct {
+	key     domain.APIKey
+	found   bool
+	expires time.Time
+}
+
+func NewKeyCache(keys KeyStore, ttl time.Duration) *KeyCache {
+	return &KeyCache{keys: keys, ttl: ttl, now: time.Now, entries: make(map[string]cachedKey)}
+}
+
+func (c *KeyCache) APIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	c.mu.Lock()
+	e, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok && c.now().Before(e.expires) {
+		if !e.found {
+			return domain.APIKey{}, domain.ErrKeyNotFound
+		}
+		return e.key, nil
+	}
+
+	key, err := c.keys.APIKey(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrKeyNotFound) {
+		return domain.APIKey{}, err
+	}
+	c.store(id, cachedKey{key: key, found: err == nil, expires: c.now().Add(c.ttl)})
+	return key, err
+}
+
+func (c *KeyCache) store(id string, e cachedKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedKeys {
+		now := c.now()
+		for cached, old := range c.entries {
+			if !now.Before(old.expires) {
+				delete(c.entries, cached)
+			}
+		}
+		if len(c.entries) >= maxCachedKeys {
+			clear(c.entries)
+		}
+	}
+	c.entries[id] = e
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testovoe/internal/domain"
+	"testovoe/internal/logging"
+	"testovoe/internal/reqctx"
+)
+
+// KeyStore looks up stored API keys. Unknown ids yield domain.ErrKeyNotFound.
+type KeyStore interface {
+	APIKey(ctx context.Context, id string) (domain.APIKey, error)
+}
+
+// Authenticator resolves the API key or, with a verifier set, the JWT of a
+// request to its principal.
+type Authenticator struct {
+	log       *slog.Logger
+	keys      KeyStore
+	verifier  *Verifier
+	certRules []CertRule
+}
+
+// New returns an Authenticator for the keys in keys, which may be nil to
+// accept JWTs only.
+func New(log *slog.Logger, keys KeyStore) *Authenticator {
+	return &Authenticator{log: log, keys: keys}
+}
+
+// SetVerifier makes the Authenticator accept JWTs checked by v as well as
+// API keys. It must be called before serving requests.
+func (a *Authenticator) SetVerifier(v *Verifier) {
+	a.verifier = v
+}
+
+// Middleware authenticates the key or JWT sent as "Authorization: Bearer
+// <token>", or a key in the X-API-Key header, and puts its principal into the
+// request context. Without a token, a client certificate matching a cert
+// rule is accepted. Requests without valid credentials are answered with 401.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.Middleware"
+
+		token := requestToken(r)
+		if token == "" {
+			if principal, ok := a.certPrincipal(r); ok {
+				a.serve(next, w, r, principal)
+				return
+			}
+			unauthorized(w, "credentials required")
+			return
+		}
+
+		principal, err := a.Authenticate(r.Context(), token)
+		if errors.Is(err, errInvalidKey) || errors.Is(err, ErrInvalidToken) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			a.log.ErrorContext(r.Context(), "could not authenticate", "op", op, "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		a.serve(next, w, r, principal)
+	})
+}
+
+func (a *Authenticator) serve(next http.Handler, w http.ResponseWriter, r *http.Request, principal domain.Principal) {
+	logging.AddFields(r.Context(), slog.String("principal", principal.ID))
+	next.ServeHTTP(w, r.WithContext(reqctx.WithPrincipal(r.Context(), principal)))
+}
+
+var errInvalidKey = errors.New("invalid or revoked api key")
+
+// Authenticate returns the principal of a valid, unrevoked key, or of a
+// valid JWT when a verifier is set.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	id, secret, ok := ParseToken(token)
+	if !ok && a.verifier != nil {
+		return a.verifier.Verify(ctx, token)
+	}
+	if !ok || a.keys == nil {
+		return domain.Principal{}, errInvalidKey
+	}
+
+	key, err := a.keys.APIKey(ctx, id)
+	if errors.Is(err, domain.ErrKeyNotFound) {
+		return domain.Principal{}, errInvalidKey
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if key.RevokedAt != nil || !secretMatches(secret, key.Hash) {
+		return domain.Principal{}, errInvalidKey
+	}
+
+	return key.Principal(), nil
+}
+
+// Require refuses requests whose principal may not use scope on
+// collection: 401 without a principal, 403 with one.
+func Require(scope, collection string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := reqctx.Principal(r.Context())
+			if !ok {
+				unauthorized(w, "authentication required")
+				return
+			}
+			if !principal.Can(scope, collection) {
+				writeError(w, http.StatusForbidden, "missing scope "+scope+" on "+collection)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="testovoe"`)
+	writeError(w, http.StatusUnauthorized, msg)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
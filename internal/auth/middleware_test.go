@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+)
+
+type keyMap map[string]domain.APIKey
+
+func (m keyMap) APIKey(_ context.Context, id string) (domain.APIKey, error) {
+	key, ok := m[id]
+	if !ok {
+		return domain.APIKey{}, domain.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+type failingKeys struct{}
+
+func (failingKeys) APIKey(context.Context, string) (domain.APIKey, error) {
+	return domain.APIKey{}, errors.New("connection refused")
+}
+
+func newKey(keys keyMap, scopes []string, collections ...string) string {
+	token, id, hash := NewToken()
+	keys[id] = domain.APIKey{ID: id, Name: "test", Hash: hash, Scopes: scopes, Collections: collections}
+	return token
+}
+
+func serve(a *Authenticator, scope string, setup func(r *http.Request)) (*httptest.ResponseRecorder, *domain.Principal) {
+	var seen *domain.Principal
+	h := a.Middleware(Require(scope, domain.Collection)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := reqctx.Principal(r.Context())
+		seen = &p
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/nums", nil)
+	setup(req)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestMiddleware(t *testing.T) {
+	keys := keyMap{}
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), keys)
+
+	reader := newKey(keys, []string{domain.ScopeRead})
+	admin := newKey(keys, []string{domain.ScopeAdmin})
+	elsewhere := newKey(keys, []string{domain.ScopeRead}, "other")
+	revoked := newKey(keys, []string{domain.ScopeRead})
+	id, _, _ := ParseToken(revoked)
+	now := time.Now()
+	k := keys[id]
+	k.RevokedAt = &now
+	keys[id] = k
+	wrongSecret := reader[:len(reader)-1] + "x"
+
+	testCases := []struct {
+		name   string
+		scope  string
+		header string
+		token  string
+		status int
+	}{
+		{"bearer", domain.ScopeRead, "Authorization", "Bearer " + reader, http.StatusOK},
+		{"x-api-key", domain.ScopeRead, "X-API-Key", reader, http.StatusOK},
+		{"admin implies read", domain.ScopeRead, "X-API-Key", admin, http.StatusOK},
+		{"missing", domain.ScopeRead, "", "", http.StatusUnauthorized},
+		{"malformed", domain.ScopeRead, "X-API-Key", "nope", http.StatusUnauthorized},
+		{"wrong secret", domain.ScopeRead, "X-API-Key", wrongSecret, http.StatusUnauthorized},
+		{"revoked", domain.ScopeRead, "X-API-Key", revoked, http.StatusUnauthorized},
+		{"missing scope", domain.ScopeWrite, "X-API-Key", reader, http.StatusForbidden},
+		{"other collection", domain.ScopeRead, "X-API-Key", elsewhere, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, seen := serve(a, tc.scope, func(r *http.Request) {
+				if tc.header != "" {
+					r.Header.Set(tc.header, tc.token)
+				}
+			})
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				assert.NotNil(t, seen)
+			} else {
+				assert.Nil(t, seen)
+			}
+			if tc.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestMiddleware_StoreFailure(t *testing.T) {
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), failingKeys{})
+	token, _, _ := NewToken()
+
+	w, seen := serve(a, domain.ScopeRead, func(r *http.Request) { r.Header.Set("X-API-Key", token) })
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Nil(t, seen)
+}
+
+func TestMiddleware_ClientCertificate(t *testing.T) {
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), keyMap{})
+	a.SetCertRules([]CertRule{
+		{Subject: "CN=ingest,O=Example", Scopes: []string{domain.ScopeWrite}},
+		{Subject: "dashboard", Scopes: []string{domain.ScopeRead}},
+	})
+	withCert := func(cn string) func(r *http.Request) {
+		return func(r *http.Request) {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn, Organization: []string{"Example"}}}
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+	}
+
+	w, seen := serve(a, domain.ScopeWrite, withCert("ingest"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, seen) {
+		assert.Equal(t, "cert:ingest", seen.ID)
+		assert.Equal(t, "CN=ingest,O=Example", seen.Name)
+	}
+
+	w, _ = serve(a, domain.ScopeRead, withCert("dashboard"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = serve(a, domain.ScopeWrite, withCert("dashboard"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = serve(a, domain.ScopeRead, withCert("unknown"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// An unverified certificate grants nothing.
+	w, _ = serve(a, domain.ScopeRead, func(r *http.Request) {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "dashboard"}}
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequireToken(t *testing.T) {
+	var got domain.Principal
+	h := RequireToken("s3cret-admin-token")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = reqctx.Principal(r.Context())
+	}))
+
+	for name, header := range map[string]string{
+		"missing": "",
+		"wrong":   "Bearer nope",
+		"scheme":  "Basic s3cret-admin-token",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer s3cret-admin-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, AdminPrincipal, got)
+}
+
+// countingKeys counts the lookups that reach keys.
+type countingKeys struct {
+	keyMap
+	calls int
+}
+
+func (c *countingKeys) APIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	c.calls++
+	return c.keyMap.APIKey(ctx, id)
+}
+
+func TestKeyCache(t *testing.T) {
+	keys := &countingKeys{keyMap: keyMap{}}
+	token := newKey(keys.keyMap, []string{domain.ScopeRead})
+	id, _, _ := ParseToken(token)
+
+	cache := NewKeyCache(keys, time.Minute)
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), cache)
+
+	for range 3 {
+		_, err := a.Authenticate(context.Background(), token)
+		assert.NoError(t, err)
+		_, err = a.Authenticate(context.Background(), "tt_unknown_secret")
+		assert.ErrorIs(t, err, errInvalidKey)
+	}
+	assert.Equal(t, 2, keys.calls, "positive and negative lookups are cached")
+
+	// A revocation takes effect once the entry expires.
+	revoked := keys.keyMap[id]
+	revoked.RevokedAt = &now
+	keys.keyMap[id] = revoked
+	_, err := a.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = a.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, errInvalidKey)
+}
@@ -0,0 +1,200 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+var ErrClosed = errors.New("batch writer is closed")
+
+// Backend is storage that can insert many numbers in one statement. The ids
+// it returns must be in the same order as nums.
+type Backend interface {
+	usecase.Storage
+	PutNumbers(ctx context.Context, nums []domain.Number, metas []domain.Meta) ([]int64, error)
+}
+
+type Config struct {
+	// MaxBatch is the most numbers written by one statement.
+	MaxBatch int
+	// MaxDelay is how long the first number of a batch waits for company.
+	MaxDelay time.Duration
+}
+
+// Writer group-commits concurrent PutNumber calls: numbers arriving within
+// MaxDelay of each other are written by one multi-row insert, and every
+// caller still gets its own id or error.
+type Writer struct {
+	Backend
+
+	cfg  Config
+	reqs chan request
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	batches atomic.Int64
+	rows    atomic.Int64
+}
+
+type request struct {
+	num    domain.Number
+	meta   domain.Meta
+	result chan result
+}
+
+type result struct {
+	id  int64
+	lsn string
+	err error
+}
+
+type Metrics struct {
+	Batches int64 `json:"batches"`
+	Rows    int64 `json:"rows"`
+}
+
+func NewWriter(backend Backend, cfg Config) *Writer {
+	cfg.MaxBatch = max(cfg.MaxBatch, 1)
+
+	w := &Writer{
+		Backend: backend,
+		cfg:     cfg,
+		reqs:    make(chan request, cfg.MaxBatch),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// PutNumber queues num for the next batch and waits for it to be written.
+// If ctx ends first the number may still be written.
+func (w *Writer) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	// Keyed and audited writes need their context to reach storage, so they
+	// skip batching.
+	_, keyed := reqctx.IdempotencyKey(ctx)
+	_, audited := reqctx.Audit(ctx)
+	if keyed || audited {
+		return w.Backend.PutNumber(ctx, num)
+	}
+
+	meta, _ := reqctx.Meta(ctx)
+	req := request{num: num, meta: meta, result: make(chan result, 1)}
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return 0, ErrClosed
+	}
+	select {
+	case w.reqs <- req:
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return 0, ctx.Err()
+	}
+	w.mu.RUnlock()
+
+	select {
+	case res := <-req.result:
+		if res.lsn != "" {
+			reqctx.RecordWrite(ctx, res.lsn)
+		}
+		return res.id, res.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Close stops accepting numbers and returns once every queued one is written.
+func (w *Writer) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.reqs)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *Writer) Metrics() Metrics {
+	return Metrics{Batches: w.batches.Load(), Rows: w.rows.Load()}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	for req := range w.reqs {
+		batch := []request{req}
+		timer := time.NewTimer(w.cfg.MaxDelay)
+
+	collect:
+		for len(batch) < w.cfg.MaxBatch {
+			select {
+			case req, ok := <-w.reqs:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		w.flush(batch)
+	}
+}
+
+// flush writes batch in one statement. If that fails the numbers are retried
+// one by one, so a single bad number does not fail its neighbours.
+// Each write's position is collected and handed to the callers it covers.
+func (w *Writer) flush(batch []request) {
+	ctx, token := reqctx.WithWriteToken(context.Background())
+
+	nums := make([]domain.Number, len(batch))
+	metas := make([]domain.Meta, len(batch))
+	for i, req := range batch {
+		nums[i] = req.num
+		metas[i] = req.meta
+	}
+
+	w.batches.Add(1)
+	ids, err := w.Backend.PutNumbers(ctx, nums, metas)
+	if err == nil && len(ids) != len(batch) {
+		// The rows may be committed, so retrying could duplicate them.
+		err = fmt.Errorf("batch insert returned %d ids for %d numbers", len(ids), len(batch))
+		for _, req := range batch {
+			req.result <- result{err: err}
+		}
+		return
+	}
+	if err == nil {
+		w.rows.Add(int64(len(batch)))
+		for i, req := range batch {
+			req.result <- result{id: ids[i], lsn: token.LSN()}
+		}
+		return
+	}
+
+	if len(batch) == 1 {
+		batch[0].result <- result{err: err}
+		return
+	}
+	for _, req := range batch {
+		ctx, token := reqctx.WithWriteToken(reqctx.WithMeta(context.Background(), req.meta))
+		id, err := w.Backend.PutNumber(ctx, req.num)
+		if err == nil {
+			w.rows.Add(1)
+		}
+		req.result <- result{id: id, lsn: token.LSN(), err: err}
+	}
+}
@@ -0,0 +1,169 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/storage/memory"
+)
+
+type recordingBackend struct {
+	*memory.Storage
+
+	mu      sync.Mutex
+	batches []int
+	fail    func(domain.Number) bool
+}
+
+func (b *recordingBackend) PutNumbers(ctx context.Context, nums []domain.Number, metas []domain.Meta) ([]int64, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, len(nums))
+	b.mu.Unlock()
+
+	for _, num := range nums {
+		if b.fail != nil && b.fail(num) {
+			return nil, errors.New("batch rejected")
+		}
+	}
+	ids, err := b.Storage.PutNumbers(ctx, nums, metas)
+	if err == nil {
+		reqctx.RecordWrite(ctx, fmt.Sprintf("0/%X", ids[len(ids)-1]))
+	}
+	return ids, err
+}
+
+func (b *recordingBackend) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	if b.fail != nil && b.fail(num) {
+		return 0, errors.New("number rejected")
+	}
+	return b.Storage.PutNumber(ctx, num)
+}
+
+func TestWriter_GroupsConcurrentPuts(t *testing.T) {
+	backend := &recordingBackend{Storage: memory.New()}
+	w := NewWriter(backend, Config{MaxBatch: 10, MaxDelay: 50 * time.Millisecond})
+	defer w.Close()
+
+	var (
+		mu  sync.Mutex
+		ids = map[int64]domain.Number{}
+		wg  sync.WaitGroup
+	)
+	for i := range int64(50) {
+		wg.Go(func() {
+			id, err := w.PutNumber(context.Background(), domain.NewInt(i))
+			assert.NoError(t, err)
+
+			mu.Lock()
+			ids[id] = domain.NewInt(i)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	assert.Len(t, ids, 50)
+	stored, err := backend.GetRecords(context.Background(), "")
+	require.NoError(t, err)
+	for _, rec := range stored {
+		assert.Equal(t, ids[rec.ID], rec.Num, "id %d", rec.ID)
+	}
+
+	assert.Less(t, len(backend.batches), 50)
+	for _, size := range backend.batches {
+		assert.LessOrEqual(t, size, 10)
+	}
+	assert.Equal(t, int64(50), w.Metrics().Rows)
+}
+
+func TestWriter_PerCallerErrors(t *testing.T) {
+	backend := &recordingBackend{
+		Storage: memory.New(),
+		fail:    func(num domain.Number) bool { return num.Cmp(domain.NewInt(13)) == 0 },
+	}
+	w := NewWriter(backend, Config{MaxBatch: 3, MaxDelay: time.Second})
+	defer w.Close()
+
+	errs := make([]error, 3)
+	var wg sync.WaitGroup
+	for i, v := range []int64{1, 13, 2} {
+		wg.Go(func() {
+			_, errs[i] = w.PutNumber(context.Background(), domain.NewInt(v))
+		})
+	}
+	wg.Wait()
+
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "number rejected")
+	assert.NoError(t, errs[2])
+}
+
+func TestWriter_CloseFlushesQueued(t *testing.T) {
+	backend := &recordingBackend{Storage: memory.New()}
+	w := NewWriter(backend, Config{MaxBatch: 100, MaxDelay: time.Hour})
+
+	queued := make([]request, 5)
+	for i := range queued {
+		queued[i] = request{num: domain.NewInt(int64(i)), result: make(chan result, 1)}
+		w.reqs <- queued[i]
+	}
+
+	w.Close()
+
+	for _, req := range queued {
+		res := <-req.result
+		assert.NoError(t, res.err)
+	}
+	stored, err := backend.GetSlice(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stored, 5)
+
+	_, err = w.PutNumber(context.Background(), domain.NewInt(6))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestWriter_HandsBatchPositionToCallers(t *testing.T) {
+	backend := &recordingBackend{Storage: memory.New()}
+	w := NewWriter(backend, Config{MaxBatch: 10, MaxDelay: time.Millisecond})
+	defer w.Close()
+
+	ctx, token := reqctx.WithWriteToken(context.Background())
+	id, err := w.PutNumber(ctx, domain.NewInt(1))
+
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("0/%X", id), token.LSN())
+}
+
+func TestWriter_KeepsMetadataPerNumber(t *testing.T) {
+	backend := &recordingBackend{Storage: memory.New()}
+	w := NewWriter(backend, Config{MaxBatch: 10, MaxDelay: 20 * time.Millisecond})
+	defer w.Close()
+
+	var wg sync.WaitGroup
+	for _, source := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := reqctx.WithMeta(context.Background(), domain.Meta{Source: source})
+			_, err := w.PutNumber(ctx, domain.NewInt(int64(source[0])))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	records, err := backend.FindRecords(context.Background(), domain.RecordQuery{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	for _, rec := range records {
+		n, _ := rec.Num.Int64()
+		assert.Equal(t, string(rune(n)), rec.Meta.Source)
+	}
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Storage is a read-through cache in front of another storage. Every write
+// bumps a version and entries read under an older version are ignored, so a
+// replica always sees its own writes; writes from other replicas show up
+// once the TTL expires. Concurrent misses for the same key share one query.
+type Storage struct {
+	usecase.Storage
+
+	ttl atomic.Int64
+	now func() time.Time
+
+	version atomic.Uint64
+	group   singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]entry
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	shared atomic.Int64
+}
+
+type entry struct {
+	version uint64
+	expires time.Time
+	value   any
+}
+
+type Metrics struct {
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	Shared  int64  `json:"shared"` // misses answered by another caller's query
+	Version uint64 `json:"version"`
+	Entries int    `json:"entries"`
+}
+
+func NewStorage(storage usecase.Storage, ttl time.Duration) *Storage {
+	s := &Storage{
+		Storage: storage,
+		now:     time.Now,
+		entries: make(map[string]entry),
+	}
+	s.SetTTL(ttl)
+	return s
+}
+
+// SetTTL changes how long entries stored from now on are kept. It is safe
+// to call while requests are being served.
+func (s *Storage) SetTTL(ttl time.Duration) {
+	s.ttl.Store(int64(ttl))
+}
+
+// PutNumber invalidates the cache even when the write fails, since the
+// failure may have happened after the row was committed.
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	id, err := s.Storage.PutNumber(ctx, num)
+	s.version.Add(1)
+	return id, err
+}
+
+func (s *Storage) GetSlice(ctx context.Context) ([]domain.Number, error) {
+	return load(ctx, s, "slice", s.Storage.GetSlice, slices.Clone)
+}
+
+func (s *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	return load(ctx, s, "records:"+order, func(ctx context.Context) ([]domain.Record, error) {
+		return s.Storage.GetRecords(ctx, order)
+	}, slices.Clone)
+}
+
+func (s *Storage) GetStats(ctx context.Context) (domain.Stats, error) {
+	return load(ctx, s, "stats", s.Storage.GetStats, func(stats domain.Stats) domain.Stats {
+		return stats
+	})
+}
+
+// Flush drops every cached entry. Reads already in flight do not store
+// theirs either.
+func (s *Storage) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version.Add(1)
+	clear(s.entries)
+}
+
+func (s *Storage) Metrics() Metrics {
+	s.mu.Lock()
+	entries := len(s.entries)
+	s.mu.Unlock()
+
+	return Metrics{
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Shared:  s.shared.Load(),
+		Version: s.version.Load(),
+		Entries: entries,
+	}
+}
+
+// load returns a copy of the cached value for key, fetching it on a miss.
+// Callers get their own copy because they sort the results in place.
+func load[T any](ctx context.Context, s *Storage, key string, fetch func(context.Context) (T, error), clone func(T) T) (T, error) {
+	version := s.version.Load()
+	fresh := reqctx.FreshRead(ctx)
+	// A cached value may predate the write the caller needs to see.
+	after, pinned := reqctx.ReadAfter(ctx)
+
+	if !fresh && !pinned {
+		if value, ok := s.lookup(key, version); ok {
+			s.hits.Add(1)
+			return clone(value.(T)), nil
+		}
+	}
+	s.misses.Add(1)
+
+	flightKey := key + "@" + strconv.FormatUint(version, 10)
+	if fresh {
+		flightKey += "!fresh"
+	} else if pinned {
+		flightKey += "!after=" + after
+	}
+
+	// The query outlives any single caller, so it must not be cancelled
+	// with the first caller's request.
+	leader := false
+	value, err, shared := s.group.Do(flightKey, func() (any, error) {
+		leader = true
+		value, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		s.store(key, version, value)
+		return value, nil
+	})
+	if shared && !leader {
+		s.shared.Add(1)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return clone(value.(T)), nil
+}
+
+func (s *Storage) lookup(key string, version uint64) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.version != version || !s.now().Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (s *Storage) store(key string, version uint64, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, ok := s.entries[key]; ok && cur.version > version {
+		return
+	}
+	s.entries[key] = entry{version: version, expires: s.now().Add(time.Duration(s.ttl.Load())), value: value}
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase/mocks"
+)
+
+func TestStorage_HitsUntilWrite(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(mockStorage, time.Minute)
+
+	mockStorage.EXPECT().GetSlice(mock.Anything).Return([]domain.Number{domain.NewInt(2), domain.NewInt(1)}, nil).Once()
+
+	first, err := s.GetSlice(context.Background())
+	require.NoError(t, err)
+	first[0] = domain.NewInt(100)
+
+	second, err := s.GetSlice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Number{domain.NewInt(2), domain.NewInt(1)}, second, "callers must not share the cached slice")
+
+	mockStorage.EXPECT().PutNumber(mock.Anything, domain.NewInt(3)).Return(int64(3), nil).Once()
+	mockStorage.EXPECT().GetSlice(mock.Anything).Return([]domain.Number{domain.NewInt(2), domain.NewInt(1), domain.NewInt(3)}, nil).Once()
+
+	_, err = s.PutNumber(context.Background(), domain.NewInt(3))
+	require.NoError(t, err)
+
+	third, err := s.GetSlice(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, third, 3)
+
+	assert.Equal(t, Metrics{Hits: 1, Misses: 2, Version: 1, Entries: 1}, s.Metrics())
+}
+
+func TestStorage_TTLAndFreshRead(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(mockStorage, time.Second)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 1}, nil).Once()
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 2}, nil).Once()
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 3}, nil).Once()
+
+	stats, _ := s.GetStats(context.Background())
+	assert.Equal(t, int64(1), stats.Count)
+
+	stats, _ = s.GetStats(context.Background())
+	assert.Equal(t, int64(1), stats.Count)
+
+	stats, _ = s.GetStats(reqctx.WithFreshRead(context.Background()))
+	assert.Equal(t, int64(2), stats.Count)
+
+	now = now.Add(2 * time.Second)
+	stats, _ = s.GetStats(context.Background())
+	assert.Equal(t, int64(3), stats.Count)
+}
+
+func TestStorage_ReadAfterBypassesCache(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(mockStorage, time.Minute)
+
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 1}, nil).Once()
+	mockStorage.EXPECT().
+		GetStats(mock.MatchedBy(func(ctx context.Context) bool {
+			lsn, ok := reqctx.ReadAfter(ctx)
+			return ok && lsn == "0/16B3748"
+		})).
+		Return(domain.Stats{Count: 2}, nil).
+		Once()
+
+	stats, _ := s.GetStats(context.Background())
+	assert.Equal(t, int64(1), stats.Count)
+
+	stats, _ = s.GetStats(reqctx.WithReadAfter(context.Background(), "0/16B3748"))
+	assert.Equal(t, int64(2), stats.Count)
+
+	stats, _ = s.GetStats(context.Background())
+	assert.Equal(t, int64(2), stats.Count, "the newer value replaces the cached one")
+}
+
+func TestStorage_ConcurrentMissesShareQuery(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(mockStorage, time.Minute)
+
+	release := make(chan struct{})
+	mockStorage.EXPECT().
+		GetRecords(mock.Anything, "asc").
+		RunAndReturn(func(context.Context, string) ([]domain.Record, error) {
+			<-release
+			return []domain.Record{{ID: 1, Num: domain.NewInt(1)}}, nil
+		}).
+		Once()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			records, err := s.GetRecords(context.Background(), "asc")
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+		})
+	}
+
+	assert.Eventually(t, func() bool { return s.Metrics().Misses == 10 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(9), s.Metrics().Shared)
+}
+
+func TestStorage_FlushDiscardsReadsInFlight(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(mockStorage, time.Minute)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	mockStorage.EXPECT().
+		GetStats(mock.Anything).
+		RunAndReturn(func(context.Context) (domain.Stats, error) {
+			close(started)
+			<-release
+			return domain.Stats{Count: 1}, nil
+		}).
+		Once()
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 0}, nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = s.GetStats(context.Background())
+	}()
+	<-started
+	s.Flush()
+	close(release)
+	<-done
+
+	stats, err := s.GetStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Count, "a read started before Flush must not be served after it")
+}
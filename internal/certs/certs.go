@@ -0,0 +1,214 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options describes the server side of TLS. With ClientCA set, client
+// certificates are verified against the bundle in that file.
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCA     string
+	ClientAuth   tls.ClientAuthType
+	MinVersion   uint16
+	CipherSuites []uint16
+}
+
+// Reloader serves the certificate and client CA bundle named in its
+// options and swaps them in when the files change, without dropping
+// connections.
+type Reloader struct {
+	log  *slog.Logger
+	opts Options
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	stamps map[string]time.Time
+}
+
+// New loads the files in opts, failing if any of them is unusable.
+func New(log *slog.Logger, opts Options) (*Reloader, error) {
+	const op = "certs.New"
+
+	r := &Reloader{log: log, opts: opts}
+	err := r.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return r, nil
+}
+
+// Reload reads the certificate, key and CA bundle again. On failure the
+// previous ones stay in use.
+func (r *Reloader) Reload() error {
+	const op = "certs.Reload"
+
+	stamps, err := r.stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var pool *x509.CertPool
+	if r.opts.ClientCA != "" {
+		pem, err := os.ReadFile(r.opts.ClientCA)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s: no certificates in %s", op, r.opts.ClientCA)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.stamps = &cert, pool, stamps
+	r.mu.Unlock()
+	return nil
+}
+
+// Run reloads the files every interval when any of them has changed, until
+// ctx is done.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	const op = "certs.Run"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		err := r.Reload()
+		if err != nil {
+			r.log.Error("failed to reload certificates, keeping the previous ones", "op", op, "error", err)
+			continue
+		}
+		r.log.Info("certificates reloaded", "op", op)
+	}
+}
+
+func (r *Reloader) changed() bool {
+	stamps, err := r.stat()
+	if err != nil {
+		// Mid-rotation; Reload reports the problem if it persists.
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, mod := range stamps {
+		if !r.stamps[path].Equal(mod) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) stat() (map[string]time.Time, error) {
+	stamps := make(map[string]time.Time, 3)
+	for _, path := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCA} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = info.ModTime()
+	}
+	return stamps, nil
+}
+
+// TLSConfig returns a server configuration that picks up reloaded files on
+// the next handshake.
+func (r *Reloader) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion:   r.opts.MinVersion,
+		CipherSuites: r.opts.CipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.opts.ClientCA != "" {
+		base.ClientAuth = r.opts.ClientAuth
+	}
+
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		c := base.Clone()
+		c.Certificates = []tls.Certificate{*r.cert}
+		c.ClientCAs = r.pool
+		return c, nil
+	}
+	return cfg
+}
+
+// ParseVersion accepts "1.2" and "1.3".
+func ParseVersion(s string) (uint16, error) {
+	switch s {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q, want 1.2 or 1.3", s)
+	}
+}
+
+// ParseCiphers maps IANA cipher suite names to their ids. Only suites Go
+// considers secure are accepted. They govern TLS 1.2; TLS 1.3 suites are
+// not configurable.
+func ParseCiphers(names []string) ([]uint16, error) {
+	var (
+		ids  []uint16
+		errs []error
+	)
+	for _, name := range names {
+		i := slices.IndexFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool {
+			return strings.EqualFold(s.Name, name)
+		})
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("unknown or insecure cipher suite %q", name))
+			continue
+		}
+		ids = append(ids, tls.CipherSuites()[i].ID)
+	}
+	return ids, errors.Join(errs...)
+}
+
+// ParseClientAuth accepts "require", which refuses connections without a
+// valid client certificate, and "optional", which verifies one when sent.
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch s {
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return 0, fmt.Errorf("unsupported client auth %q, want require or optional", s)
+	}
+}
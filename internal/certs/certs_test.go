@@ -0,0 +1,228 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for cn, valid for localhost when
+// usage is a server.
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, mod time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, mod, mod))
+}
+
+type testServer struct {
+	*httptest.Server
+	ca       *testCA
+	reloader *Reloader
+	opts     Options
+}
+
+func newTestServer(t *testing.T, clientAuth tls.ClientAuthType) *testServer {
+	t.Helper()
+
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	opts := Options{
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		ClientCA:   filepath.Join(dir, "ca.crt"),
+		ClientAuth: clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}
+	now := time.Now()
+	writeFile(t, opts.CertFile, certPEM, now)
+	writeFile(t, opts.KeyFile, keyPEM, now)
+	writeFile(t, opts.ClientCA, ca.pem, now)
+
+	reloader, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), opts)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+		}
+	}))
+	srv.TLS = reloader.TLSConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return &testServer{Server: srv, ca: ca, reloader: reloader, opts: opts}
+}
+
+func (s *testServer) client(t *testing.T, cn string) *http.Client {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	cfg := &tls.Config{RootCAs: roots}
+	if cn != "" {
+		certPEM, keyPEM := s.ca.issue(t, cn, 20, x509.ExtKeyUsageClientAuth)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+}
+
+func (s *testServer) serial(t *testing.T) int64 {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	conn, err := tls.Dial("tcp", s.Listener.Addr().String(), &tls.Config{RootCAs: roots})
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloader_VerifiesClientCertificates(t *testing.T) {
+	srv := newTestServer(t, tls.RequireAndVerifyClientCert)
+
+	resp, err := srv.client(t, "ingest").Get(srv.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ingest", string(body))
+
+	_, err = srv.client(t, "").Get(srv.URL)
+	assert.Error(t, err, "no client certificate")
+
+	// A certificate from another CA is refused as well.
+	certPEM, keyPEM := newTestCA(t).issue(t, "intruder", 30, x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.ca.cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}}}}
+	_, err = client.Get(srv.URL)
+	assert.Error(t, err)
+}
+
+func TestReloader_OptionalClientCertificate(t *testing.T) {
+	srv := newTestServer(t, tls.VerifyClientCertIfGiven)
+
+	resp, err := srv.client(t, "").Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestReloader_RunPicksUpRotatedCertificate(t *testing.T) {
+	srv := newTestServer(t, tls.VerifyClientCertIfGiven)
+	assert.Equal(t, int64(10), srv.serial(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.reloader.Run(ctx, 10*time.Millisecond)
+
+	certPEM, keyPEM := srv.ca.issue(t, "server", 11, x509.ExtKeyUsageServerAuth)
+	later := time.Now().Add(time.Minute)
+	writeFile(t, srv.opts.KeyFile, keyPEM, later)
+	writeFile(t, srv.opts.CertFile, certPEM, later)
+
+	assert.Eventually(t, func() bool { return srv.serial(t) == 11 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestReloader_KeepsCertificateWhenReloadFails(t *testing.T) {
+	srv := newTestServer(t, tls.VerifyClientCertIfGiven)
+
+	writeFile(t, srv.opts.CertFile, []byte("not a certificate"), time.Now().Add(time.Minute))
+	assert.Error(t, srv.reloader.Reload())
+
+	assert.Equal(t, int64(10), srv.serial(t))
+}
+
+func TestParse(t *testing.T) {
+	v, err := ParseVersion("1.3")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), v)
+	_, err = ParseVersion("1.0")
+	assert.Error(t, err)
+
+	ids, err := ParseCiphers([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, ids)
+	_, err = ParseCiphers([]string{"TLS_RSA_WITH_RC4_128_SHA", "nope"})
+	assert.ErrorContains(t, err, "TLS_RSA_WITH_RC4_128_SHA")
+	assert.ErrorContains(t, err, "nope")
+
+	auth, err := ParseClientAuth("optional")
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, auth)
+	_, err = ParseClientAuth("maybe")
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+package client
+
+import "net/http"
+
+// WithAPIKey returns a transport that sends key as a bearer token on every
+// request made through base, or http.DefaultTransport when base is nil.
+func WithAPIKey(base http.RoundTripper, key string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &apiKeyTransport{base: base, key: key}
+}
+
+type apiKeyTransport struct {
+	base http.RoundTripper
+	key  string
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.key)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+const consistencyHeader = "X-Consistency-Token"
+
+// ErrPending is returned by Put when the server accepted the number into its
+// write spool but has not stored it yet.
+var ErrPending = errors.New("accepted, pending storage")
+
+// Client remembers the consistency token of its last write and sends it on
+// every request, so it reads its own writes even from a server backed by
+// replicas.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+type ListOptions struct {
+	Order string
+	Limit int
+}
+
+// Put stores a number and returns the sorted list the server replies with.
+// While the server's database is down it may return ErrPending instead.
+func (c *Client) Put(ctx context.Context, num domain.Number) ([]domain.Number, error) {
+	const op = "client.Put"
+
+	body, err := json.Marshal(domain.UserNum{Num: num})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var numbers []domain.Number
+	err = c.do(ctx, http.MethodPost, "/put-num", bytes.NewReader(body), &numbers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return numbers, nil
+}
+
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]domain.Number, error) {
+	const op = "client.List"
+
+	query := url.Values{}
+	if opts.Order != "" {
+		query.Set("order", opts.Order)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	path := "/nums"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var numbers []domain.Number
+	err := c.do(ctx, http.MethodGet, path, nil, &numbers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return numbers, nil
+}
+
+func (c *Client) Stats(ctx context.Context) (domain.Stats, error) {
+	const op = "client.Stats"
+
+	var stats domain.Stats
+	err := c.do(ctx, http.MethodGet, "/stats", nil, &stats)
+	if err != nil {
+		return domain.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
+
+// Watch follows the live stream and calls fn for every received number
+// until ctx is cancelled, the server closes the stream or fn fails.
+func (c *Client) Watch(ctx context.Context, fn func(num domain.Number) error) error {
+	const op = "client.Watch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/nums/watch", nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", op, resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var userNum domain.UserNum
+		err = json.Unmarshal([]byte(data), &userNum)
+		if err != nil {
+			return fmt.Errorf("%s: could not parse event: %w", op, err)
+		}
+
+		err = fn(userNum.Num)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.mu.Lock()
+	if c.token != "" {
+		req.Header.Set(consistencyHeader, c.token)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if token := resp.Header.Get(consistencyHeader); token != "" {
+		c.mu.Lock()
+		c.token = token
+		c.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		return ErrPending
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
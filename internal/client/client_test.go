@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testovoe/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Put(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/put-num", r.URL.Path)
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"num":3}`, string(body))
+
+		_, _ = w.Write([]byte(`[1,2,3]`))
+	}))
+	defer srv.Close()
+
+	numbers, err := New(srv.URL, nil).Put(context.Background(), domain.NewInt(3))
+
+	assert.NoError(t, err)
+	assert.Equal(t, ints(1, 2, 3), numbers)
+}
+
+func TestClient_List_Query(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/nums", r.URL.Path)
+		assert.Equal(t, "desc", r.URL.Query().Get("order"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		_, _ = w.Write([]byte(`[3,2]`))
+	}))
+	defer srv.Close()
+
+	numbers, err := New(srv.URL+"/", nil).List(context.Background(), ListOptions{Order: "desc", Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ints(3, 2), numbers)
+}
+
+func TestClient_Stats_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, nil).Stats(context.Background())
+
+	assert.Error(t, err)
+}
+
+func TestClient_Watch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, num := range ints(5, 1) {
+			data, _ := json.Marshal(domain.UserNum{Num: num})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	var received []domain.Number
+	err := New(srv.URL, nil).Watch(context.Background(), func(num domain.Number) error {
+		received = append(received, num)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ints(5, 1), received)
+}
+
+func ints(values ...int64) []domain.Number {
+	numbers := make([]domain.Number, len(values))
+	for i, v := range values {
+		numbers[i] = domain.NewInt(v)
+	}
+	return numbers
+}
+
+func TestClient_Put_Pending(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"accepted-pending"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, nil).Put(context.Background(), domain.NewInt(3))
+
+	assert.ErrorIs(t, err, ErrPending)
+}
+
+func TestClient_SendsConsistencyToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("X-Consistency-Token", "0/16B3748")
+			_, _ = w.Write([]byte(`[1]`))
+			return
+		}
+		assert.Equal(t, "0/16B3748", r.Header.Get("X-Consistency-Token"))
+		_, _ = w.Write([]byte(`[1]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+	_, err := c.Put(context.Background(), domain.NewInt(1))
+	assert.NoError(t, err)
+
+	_, err = c.List(context.Background(), ListOptions{})
+	assert.NoError(t, err)
+}
+
+func TestClient_APIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tt_id_secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"count":0,"min":0,"max":0,"sum":0,"mean":0}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, &http.Client{Transport: WithAPIKey(nil, "tt_id_secret")})
+	_, err := c.Stats(context.Background())
+
+	assert.NoError(t, err)
+}
@@ -1,40 +1,487 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"testovoe/internal/auth"
+	"testovoe/internal/certs"
+	"testovoe/internal/domain"
+	"testovoe/internal/features"
+	"testovoe/internal/ratelimit"
 	"time"
-
-	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
 	Env        string         `yaml:"env" env-default:"local"`
 	HttpServer HttpServer     `yaml:"http_server"`
 	Postgres   PostgresConfig `yaml:"postgres"`
+	Numbers    Numbers        `yaml:"numbers"`
+	Validation Validation     `yaml:"validation"`
+	Index      Index          `yaml:"index"`
+	Cache      Cache          `yaml:"cache"`
+	Writer     Writer         `yaml:"writer"`
+	Resilience Resilience     `yaml:"resilience"`
+	Spool      Spool          `yaml:"spool"`
+	Retention  Retention      `yaml:"retention"`
+	Auth       Auth           `yaml:"auth"`
+	RateLimit  RateLimit      `yaml:"rate_limit"`
+	Audit      Audit          `yaml:"audit"`
+	Admin      Admin          `yaml:"admin"`
+	Log        Log            `yaml:"log"`
+	// Features switches optional routes off; every flag is on unless set
+	// to false.
+	Features map[string]bool `yaml:"features"`
+}
+
+// Log configures the application logger. Redact names attribute keys whose
+// values are never written, on top of the built-in ones. With
+// Sampling.First set, only the first First records of each message per Tick
+// are kept below warn level, then every Thereafter-th.
+type Log struct {
+	// Level overrides the level implied by Env: debug, info, warn or error.
+	Level    string      `yaml:"level" env:"LOG_LEVEL"`
+	Format   string      `yaml:"format" env:"LOG_FORMAT" env-default:"json"`
+	Access   bool        `yaml:"access" env:"LOG_ACCESS" env-default:"true"`
+	Redact   []string    `yaml:"redact" env:"LOG_REDACT"`
+	Sampling LogSampling `yaml:"sampling"`
+}
+
+type LogSampling struct {
+	First      int           `yaml:"first" env:"LOG_SAMPLING_FIRST"`
+	Thereafter int           `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER" env-default:"100"`
+	Tick       time.Duration `yaml:"tick" env:"LOG_SAMPLING_TICK" env-default:"1s"`
+}
+
+func (l Log) validate() error {
+	var errs []error
+	if l.Level != "" {
+		var level slog.Level
+		if level.UnmarshalText([]byte(l.Level)) != nil {
+			errs = append(errs, fmt.Errorf("log.level: unknown level %q", l.Level))
+		}
+	}
+	if l.Format != "json" && l.Format != "text" {
+		errs = append(errs, fmt.Errorf("log.format: must be json or text, got %q", l.Format))
+	}
+	if l.Sampling.First < 0 || l.Sampling.Thereafter < 0 {
+		errs = append(errs, errors.New("log.sampling: first and thereafter must not be negative"))
+	}
+	if l.Sampling.First > 0 && l.Sampling.Tick <= 0 {
+		errs = append(errs, errors.New("log.sampling.tick: must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Level returns the configured log level, if Log.Level is set.
+func (c *Config) Level() (slog.Level, bool) {
+	var level slog.Level
+	if c.Log.Level == "" || level.UnmarshalText([]byte(c.Log.Level)) != nil {
+		return 0, false
+	}
+	return level, true
 }
 
-type PostgresConfig struct {
-	Addr string `yaml:"addr" env-required:"true"`
+func (c *Config) validateRuntime() error {
+	var errs []error
+	names := slices.Sorted(maps.Keys(c.Features))
+	for _, name := range names {
+		if !features.Known(name) {
+			errs = append(errs, fmt.Errorf("features: unknown flag %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Admin serves the operator API, pprof and expvar on a listener of its own,
+// which should not be reachable from outside. Every request must send
+// "Authorization: Bearer <Token>".
+type Admin struct {
+	Enabled bool   `yaml:"enabled" env:"ADMIN_ENABLED"`
+	Address string `yaml:"address" env:"ADMIN_ADDRESS" env-default:"localhost:8082"`
+	Token   string `yaml:"token" env:"ADMIN_TOKEN"`
+	// Timeout bounds each request; it must exceed the longest CPU profile
+	// or trace asked for.
+	Timeout time.Duration `yaml:"timeout" env:"ADMIN_TIMEOUT" env-default:"2m"`
+}
+
+// minAdminToken is the shortest admin token accepted.
+const minAdminToken = 16
+
+func (a Admin) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	var errs []error
+	if a.Address == "" {
+		errs = append(errs, errors.New("admin.address: required"))
+	}
+	if len(a.Token) < minAdminToken {
+		errs = append(errs, fmt.Errorf("admin.token: must be at least %d characters", minAdminToken))
+	}
+	if a.Timeout <= 0 {
+		errs = append(errs, errors.New("admin.timeout: must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Audit records every write attempt to the listed sinks: "db" appends to the
+// audit_log table in the same transaction as the write, "file" to a JSON
+// lines file at File. Both chain entries by hash.
+type Audit struct {
+	Enabled bool     `yaml:"enabled" env:"AUDIT_ENABLED"`
+	Sinks   []string `yaml:"sinks" env:"AUDIT_SINKS" env-default:"db"`
+	File    string   `yaml:"file" env:"AUDIT_FILE" env-default:"audit/audit.jsonl"`
+}
+
+func (a Audit) validate() error {
+	var errs []error
+	if a.Enabled && len(a.Sinks) == 0 {
+		errs = append(errs, errors.New("audit: enabled without sinks"))
+	}
+	for _, sink := range a.Sinks {
+		if sink != "db" && sink != "file" {
+			errs = append(errs, fmt.Errorf("audit.sinks: unknown sink %q", sink))
+		}
+	}
+	if slices.Contains(a.Sinks, "file") && a.File == "" {
+		errs = append(errs, errors.New("audit.file: required by the file sink"))
+	}
+	return errors.Join(errs...)
+}
+
+// RateLimit gives each client a token bucket per route, keyed by API key or
+// address, and optionally a daily write quota counted in Postgres. Rate is
+// in requests per second. The limits can be changed at runtime through
+// PUT /limits on the admin server.
+type RateLimit struct {
+	Enabled     bool                       `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Rate        float64                    `yaml:"rate" env:"RATE_LIMIT_RATE" env-default:"50"`
+	Burst       int                        `yaml:"burst" env:"RATE_LIMIT_BURST" env-default:"100"`
+	Routes      map[string]ratelimit.Limit `yaml:"routes"`
+	DailyWrites int64                      `yaml:"daily_writes" env:"RATE_LIMIT_DAILY_WRITES"`
+}
+
+// Limits returns the limits the service starts with.
+func (r RateLimit) Limits() ratelimit.Limits {
+	return ratelimit.Limits{
+		Default:     ratelimit.Limit{Rate: r.Rate, Burst: r.Burst},
+		Routes:      r.Routes,
+		DailyWrites: r.DailyWrites,
+	}
+}
+
+func (r RateLimit) validate() error {
+	err := r.Limits().Validate()
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return err
+	}
+
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, fmt.Errorf("rate_limit.%w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Auth requires an API key or, with JWT enabled, a JWT on every route but
+// /healthz. Keys are managed with the keys subcommand. Looked-up keys are
+// cached for KeyCacheTTL, so a revoked key may work that much longer; zero
+// turns the cache off.
+type Auth struct {
+	Enabled     bool          `yaml:"enabled" env:"AUTH_ENABLED"`
+	KeyCacheTTL time.Duration `yaml:"key_cache_ttl" env:"AUTH_KEY_CACHE_TTL" env-default:"30s"`
+	JWT         JWT           `yaml:"jwt"`
+	// ClientCerts grants scopes to verified TLS client certificates.
+	ClientCerts []auth.CertRule `yaml:"client_certs"`
+}
+
+func (a Auth) validate() error {
+	var errs []error
+	if a.KeyCacheTTL < 0 {
+		errs = append(errs, errors.New("auth.key_cache_ttl: must not be negative"))
+	}
+	for i, rule := range a.ClientCerts {
+		if rule.Subject == "" {
+			errs = append(errs, fmt.Errorf("auth.client_certs[%d].subject: required", i))
+		}
+		for _, scope := range rule.Scopes {
+			if !domain.ValidScope(scope) {
+				errs = append(errs, fmt.Errorf("auth.client_certs[%d].scopes: unknown scope %q", i, scope))
+			}
+		}
+	}
+	return errors.Join(append(errs, a.JWT.validate())...)
+}
+
+// JWT accepts tokens signed by a key in the JWKS at JWKSFile or JWKSURL.
+type JWT struct {
+	Enabled         bool          `yaml:"enabled" env:"AUTH_JWT_ENABLED"`
+	JWKSFile        string        `yaml:"jwks_file" env:"AUTH_JWT_JWKS_FILE"`
+	JWKSURL         string        `yaml:"jwks_url" env:"AUTH_JWT_JWKS_URL"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"AUTH_JWT_REFRESH_INTERVAL" env-default:"5m"`
+	Issuer          string        `yaml:"issuer" env:"AUTH_JWT_ISSUER"`
+	Audience        []string      `yaml:"audience" env:"AUTH_JWT_AUDIENCE"`
+	ClockSkew       time.Duration `yaml:"clock_skew" env:"AUTH_JWT_CLOCK_SKEW" env-default:"1m"`
+	// ScopesClaim and CollectionsClaim name the claims mapped to the
+	// principal's scopes and collections.
+	ScopesClaim      string `yaml:"scopes_claim" env:"AUTH_JWT_SCOPES_CLAIM" env-default:"scope"`
+	CollectionsClaim string `yaml:"collections_claim" env:"AUTH_JWT_COLLECTIONS_CLAIM" env-default:"collections"`
+}
+
+func (j JWT) validate() error {
+	if !j.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if (j.JWKSFile == "") == (j.JWKSURL == "") {
+		errs = append(errs, errors.New("auth.jwt: exactly one of jwks_file and jwks_url must be set"))
+	}
+	if j.Issuer == "" {
+		errs = append(errs, errors.New("auth.jwt.issuer: required"))
+	}
+	if len(j.Audience) == 0 {
+		errs = append(errs, errors.New("auth.jwt.audience: required"))
+	}
+	if j.RefreshInterval <= 0 {
+		errs = append(errs, errors.New("auth.jwt.refresh_interval: must be positive"))
+	}
+	if j.ClockSkew < 0 {
+		errs = append(errs, errors.New("auth.jwt.clock_skew: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+type Numbers struct {
+	Mode     string `yaml:"mode" env:"NUMBERS_MODE" env-default:"int64"`
+	AllowNaN bool   `yaml:"allow_nan" env:"NUMBERS_ALLOW_NAN"`
+}
+
+func (n Numbers) validate() error {
+	_, err := domain.ParseNumberMode(n.Mode)
+	if err != nil {
+		return fmt.Errorf("numbers.mode: %w", err)
+	}
+	return nil
+}
+
+type Index struct {
+	Enabled        bool          `yaml:"enabled" env:"INDEX_ENABLED" env-default:"true"`
+	ResyncInterval time.Duration `yaml:"resync_interval" env:"INDEX_RESYNC_INTERVAL" env-default:"30s"`
+}
+
+// Cache keeps reads for TTL. Its hit and miss counts are reported by
+// /healthz.
+type Cache struct {
+	Enabled bool          `yaml:"enabled" env:"CACHE_ENABLED" env-default:"true"`
+	TTL     time.Duration `yaml:"ttl" env:"CACHE_TTL" env-default:"1s"`
+}
+
+// Writer configures group commit of concurrent writes.
+type Writer struct {
+	Enabled  bool          `yaml:"enabled" env:"WRITER_ENABLED"`
+	MaxBatch int           `yaml:"max_batch" env:"WRITER_MAX_BATCH" env-default:"100"`
+	MaxDelay time.Duration `yaml:"max_delay" env:"WRITER_MAX_DELAY" env-default:"2ms"`
+}
+
+type Resilience struct {
+	StartupTimeout   time.Duration `yaml:"startup_timeout" env:"RESILIENCE_STARTUP_TIMEOUT" env-default:"30s"`
+	Retries          int           `yaml:"retries" env:"RESILIENCE_RETRIES" env-default:"3"`
+	BaseDelay        time.Duration `yaml:"base_delay" env:"RESILIENCE_BASE_DELAY" env-default:"50ms"`
+	MaxDelay         time.Duration `yaml:"max_delay" env:"RESILIENCE_MAX_DELAY" env-default:"1s"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"RESILIENCE_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"RESILIENCE_BREAKER_COOLDOWN" env-default:"5s"`
+}
+
+// Spool configures the local log that accepts writes while Postgres is down.
+type Spool struct {
+	Enabled        bool          `yaml:"enabled" env:"SPOOL_ENABLED"`
+	Path           string        `yaml:"path" env:"SPOOL_PATH" env-default:"spool/numbers.log"`
+	ReplayInterval time.Duration `yaml:"replay_interval" env:"SPOOL_REPLAY_INTERVAL" env-default:"5s"`
+}
+
+// Retention evicts numbers older than MaxAge or beyond the newest MaxRows.
+type Retention struct {
+	Enabled  bool          `yaml:"enabled" env:"RETENTION_ENABLED"`
+	MaxAge   time.Duration `yaml:"max_age" env:"RETENTION_MAX_AGE"`
+	MaxRows  int64         `yaml:"max_rows" env:"RETENTION_MAX_ROWS"`
+	Archive  bool          `yaml:"archive" env:"RETENTION_ARCHIVE"`
+	Interval time.Duration `yaml:"interval" env:"RETENTION_INTERVAL" env-default:"1m"`
+	// PartitionsAhead is how many days of partitions are created in advance
+	// once nums is partitioned.
+	PartitionsAhead int `yaml:"partitions_ahead" env:"RETENTION_PARTITIONS_AHEAD" env-default:"7"`
+}
+
+func (r Retention) validate() error {
+	var errs []error
+	if r.Enabled && r.MaxAge <= 0 && r.MaxRows <= 0 {
+		errs = append(errs, errors.New("retention: enabled without max_age or max_rows"))
+	}
+	if r.MaxAge < 0 {
+		errs = append(errs, errors.New("retention.max_age: must not be negative"))
+	}
+	if r.MaxRows < 0 {
+		errs = append(errs, errors.New("retention.max_rows: must not be negative"))
+	}
+	if r.Interval <= 0 {
+		errs = append(errs, errors.New("retention.interval: must be positive"))
+	}
+	if r.PartitionsAhead < 1 {
+		errs = append(errs, errors.New("retention.partitions_ahead: must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validation restricts the numbers clients may store. MaxIntegerDigits and
+// MaxScale bound the digits before and after the decimal point; zero leaves
+// only the limits of Postgres, which are far larger.
+type Validation struct {
+	Min              *domain.Number  `yaml:"min"`
+	Max              *domain.Number  `yaml:"max"`
+	Allow            []domain.Number `yaml:"allow"`
+	Deny             []domain.Number `yaml:"deny"`
+	MaxIntegerDigits int             `yaml:"max_integer_digits" env-default:"1000"`
+	MaxScale         int             `yaml:"max_scale" env-default:"100"`
+}
+
+func (v Validation) validate() error {
+	var errs []error
+	if v.Min != nil && v.Max != nil && v.Min.Cmp(*v.Max) > 0 {
+		errs = append(errs, fmt.Errorf("validation.min: %s is greater than validation.max %s", v.Min, v.Max))
+	}
+	if v.MaxIntegerDigits < 0 {
+		errs = append(errs, errors.New("validation.max_integer_digits: must not be negative"))
+	}
+	if v.MaxScale < 0 {
+		errs = append(errs, errors.New("validation.max_scale: must not be negative"))
+	}
+	return errors.Join(errs...)
 }
 
 type HttpServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8081"`
 	Timeout     time.Duration `yaml:"timeout"`
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long shutdown waits for requests in
+	// flight.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	TLS             TLS           `yaml:"tls"`
+}
+
+// TLS serves HTTPS with the certificate in CertFile and KeyFile. With
+// ClientCA set, client certificates are verified against that bundle and,
+// with auth enabled, mapped to principals by auth.client_certs. The files
+// are checked for changes every ReloadInterval.
+type TLS struct {
+	Enabled        bool          `yaml:"enabled" env:"HTTP_TLS_ENABLED"`
+	CertFile       string        `yaml:"cert_file" env:"HTTP_TLS_CERT_FILE"`
+	KeyFile        string        `yaml:"key_file" env:"HTTP_TLS_KEY_FILE"`
+	MinVersion     string        `yaml:"min_version" env:"HTTP_TLS_MIN_VERSION" env-default:"1.2"`
+	Ciphers        []string      `yaml:"ciphers" env:"HTTP_TLS_CIPHERS"`
+	ClientCA       string        `yaml:"client_ca" env:"HTTP_TLS_CLIENT_CA"`
+	ClientAuth     string        `yaml:"client_auth" env:"HTTP_TLS_CLIENT_AUTH" env-default:"require"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"HTTP_TLS_RELOAD_INTERVAL" env-default:"30s"`
+}
+
+func (t TLS) Options() (certs.Options, error) {
+	opts := certs.Options{CertFile: t.CertFile, KeyFile: t.KeyFile, ClientCA: t.ClientCA}
+
+	var errs []error
+	var err error
+	opts.MinVersion, err = certs.ParseVersion(t.MinVersion)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("http_server.tls.min_version: %w", err))
+	}
+	opts.CipherSuites, err = certs.ParseCiphers(t.Ciphers)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("http_server.tls.ciphers: %w", err))
+	}
+	opts.ClientAuth, err = certs.ParseClientAuth(t.ClientAuth)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("http_server.tls.client_auth: %w", err))
+	}
+	return opts, errors.Join(errs...)
+}
+
+func (t TLS) validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if t.CertFile == "" || t.KeyFile == "" {
+		errs = append(errs, errors.New("http_server.tls: cert_file and key_file are required"))
+	}
+	if t.ReloadInterval <= 0 {
+		errs = append(errs, errors.New("http_server.tls.reload_interval: must be positive"))
+	}
+	_, err := t.Options()
+	return errors.Join(append(errs, err)...)
 }
 
-func MustLoadConfig() *Config {
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		log.Fatal("CONFIG env variable not set")
+// MustLoad parses the flags in args and loads the config, exiting on
+// failure. It returns the loader, for reloads, and the arguments after the
+// flags.
+func MustLoad(args []string) (*Config, *Loader, []string) {
+	loader, rest, err := NewLoader(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
 	}
 
-	var cfg Config
-	err := cleanenv.ReadConfig(configPath, &cfg)
+	cfg, err := loader.Load()
 	if err != nil {
 		log.Fatal(err)
 	}
+	return cfg, loader, rest
+}
 
-	return &cfg
+// Validate reports every invalid setting, one per line.
+func (c *Config) Validate() error {
+	return errors.Join(c.HttpServer.TLS.validate(), c.Postgres.validate(), c.Numbers.validate(), c.Validation.validate(), c.Retention.validate(), c.Auth.validate(), c.RateLimit.validate(), c.Audit.validate(), c.Admin.validate(), c.Log.validate(), c.validateRuntime(), c.validateAcross())
+}
+
+// validateAcross checks settings that depend on each other.
+func (c *Config) validateAcross() error {
+	var errs []error
+	if len(c.Auth.ClientCerts) > 0 && (!c.HttpServer.TLS.Enabled || c.HttpServer.TLS.ClientCA == "") {
+		errs = append(errs, errors.New("auth.client_certs: needs http_server.tls enabled with a client_ca"))
+	}
+	if c.Auth.JWT.Enabled && !c.Auth.Enabled {
+		errs = append(errs, errors.New("auth.jwt.enabled: needs auth.enabled"))
+	}
+	if c.Admin.Enabled && c.Admin.Address == c.HttpServer.Address {
+		errs = append(errs, errors.New("admin.address: must differ from http_server.address"))
+	}
+	if c.RateLimit.DailyWrites > 0 && !c.RateLimit.Enabled {
+		errs = append(errs, errors.New("rate_limit.daily_writes: needs rate_limit.enabled"))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of c that is safe to log or serve, with secrets
+// replaced.
+func (c Config) Redacted() Config {
+	p := &c.Postgres
+	p.Addr = redactConn(p.Addr)
+	if p.Password != "" {
+		p.Password = redacted
+	}
+	p.Replicas = slices.Clone(p.Replicas)
+	for i, addr := range p.Replicas {
+		p.Replicas[i] = redactConn(addr)
+	}
+	if c.Admin.Token != "" {
+		c.Admin.Token = redacted
+	}
+	return c
 }
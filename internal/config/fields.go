@@ -0,0 +1,165 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting is one leaf of Config: a field that is not itself a struct of
+// this package.
+type setting struct {
+	// path is the YAML path, such as "http_server.tls.enabled".
+	path string
+	// env names the env var without the prefix: the env tag when the field
+	// has one, else path in upper case with dots turned into underscores.
+	env string
+	// legacy is set for env tag names, which are also read unprefixed.
+	legacy bool
+	def    string
+	hasDef bool
+	index  []int
+}
+
+var settings = sync.OnceValue(func() []setting {
+	var out []setting
+	walkSettings(reflect.TypeFor[Config](), "", nil, &out)
+	return out
+})
+
+func walkSettings(t reflect.Type, path string, index []int, out *[]setting) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name := path + yamlName(f)
+		idx := append(slices.Clone(index), i)
+
+		if f.Type.Kind() == reflect.Struct && f.Type.PkgPath() == t.PkgPath() {
+			walkSettings(f.Type, name+".", idx, out)
+			continue
+		}
+
+		s := setting{path: name, index: idx}
+		s.env, s.legacy = f.Tag.Lookup("env")
+		if !s.legacy {
+			s.env = strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+		}
+		s.def, s.hasDef = f.Tag.Lookup("env-default")
+		*out = append(*out, s)
+	}
+}
+
+// yamlName is the key of f in a config file, as yaml.v3 decodes it.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// setValue parses s into v. Scalars and durations are read as is, lists of
+// strings may be comma-separated, and anything else is read as YAML, such
+// as "{watch: false}" or "[1, 2]".
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.CanFloat():
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(s), "["):
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for item := range strings.SplitSeq(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(list)
+	default:
+		fresh := reflect.New(v.Type())
+		err := yaml.Unmarshal([]byte(s), fresh.Interface())
+		if err != nil {
+			return err
+		}
+		v.Set(fresh.Elem())
+	}
+	return nil
+}
+
+// unknownKeys lists the keys of raw, decoded from a file, that t has no
+// field for, looking into nested objects and lists of objects.
+func unknownKeys(raw any, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return nil
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := range t.NumField() {
+			fields[yamlName(t.Field(i))] = t.Field(i).Type
+		}
+		for _, key := range slices.Sorted(maps.Keys(m)) {
+			ft, ok := fields[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s%s: unknown setting", path, key))
+				continue
+			}
+			errs = append(errs, unknownKeys(m[key], ft, path+key+".")...)
+		}
+	case reflect.Slice:
+		list, ok := raw.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			errs = append(errs, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i))...)
+		}
+	case reflect.Map:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(m)) {
+			errs = append(errs, unknownKeys(m[key], t.Elem(), path+key+".")...)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the env var of every setting, as in
+// TESTOVOE_HTTP_SERVER_ADDRESS or TESTOVOE_POSTGRES_PASSWORD.
+const EnvPrefix = "TESTOVOE_"
+
+// Loader builds a Config from layers, each overriding the one before:
+//
+//   - the defaults in the env-default tags,
+//   - the config file, if any, in YAML, TOML or JSON by its extension,
+//   - env vars: EnvPrefix and the setting's name, or for settings with an
+//     env tag also the bare tag name at lower precedence,
+//   - command-line flags named by the setting's path, such as
+//     -rate_limit.burst=200.
+//
+// NAME_FILE reads the value of env var NAME from a file, for secrets.
+// Unknown keys in the file and unknown prefixed env vars are errors.
+type Loader struct {
+	prefix  string
+	path    string
+	flags   []flagValue
+	environ func() []string
+}
+
+type flagValue struct {
+	setting setting
+	value   string
+}
+
+// NewLoader parses the flags in args and returns what follows them.
+func NewLoader(args []string) (*Loader, []string, error) {
+	l := &Loader{prefix: EnvPrefix, environ: os.Environ}
+
+	fs := flag.NewFlagSet("testovoe", flag.ContinueOnError)
+	fs.StringVar(&l.path, "config", "", "config file, YAML, TOML or JSON (env "+EnvPrefix+"CONFIG or CONFIG_PATH)")
+	for _, s := range settings() {
+		usage := "env " + l.prefix + s.env
+		set := func(value string) error {
+			l.flags = append(l.flags, flagValue{s, value})
+			return nil
+		}
+		if reflect.TypeFor[Config]().FieldByIndex(s.index).Type.Kind() == reflect.Bool {
+			fs.BoolFunc(s.path, usage, set)
+		} else {
+			fs.Func(s.path, usage, set)
+		}
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, fs.Args(), nil
+}
+
+// Path returns the config file to read, or "" for none.
+func (l *Loader) Path() string {
+	if l.path != "" {
+		return l.path
+	}
+	env := environMap(l.environ())
+	if path := env[l.prefix+"CONFIG"]; path != "" {
+		return path
+	}
+	return env["CONFIG_PATH"]
+}
+
+// Load builds and validates a Config, reporting every problem in every
+// layer at once.
+func (l *Loader) Load() (*Config, error) {
+	var cfg Config
+	v := reflect.ValueOf(&cfg).Elem()
+
+	var errs []error
+	for _, s := range settings() {
+		if s.hasDef {
+			errs = append(errs, wrapSetting(s.path, setValue(v.FieldByIndex(s.index), s.def)))
+		}
+	}
+
+	if path := l.Path(); path != "" {
+		errs = append(errs, decodeFile(path, &cfg)...)
+	}
+
+	errs = append(errs, l.applyEnv(v)...)
+
+	for _, f := range l.flags {
+		errs = append(errs, wrapSetting("-"+f.setting.path, setValue(v.FieldByIndex(f.setting.index), f.value)))
+	}
+
+	errs = append(errs, cfg.Validate())
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return &cfg, nil
+}
+
+func decodeFile(path string, cfg *Config) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	var raw map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		_, err = toml.Decode(string(data), &raw)
+	case ".yaml", ".yml", ".json":
+		// JSON is YAML as well.
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		err = fmt.Errorf("unsupported format %q, want .yaml, .yml, .toml or .json", ext)
+	}
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	errs := unknownKeys(raw, reflect.TypeFor[Config](), "")
+
+	// Decoding over cfg keeps the defaults of keys the file leaves out.
+	data, err = yaml.Marshal(raw)
+	if err == nil {
+		err = yaml.Unmarshal(data, cfg)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+	return errs
+}
+
+func (l *Loader) applyEnv(v reflect.Value) []error {
+	env := environMap(l.environ())
+
+	var (
+		errs     []error
+		prefixed = make(map[string]setting)
+	)
+	for _, s := range settings() {
+		prefixed[l.prefix+s.env] = s
+	}
+
+	// lookup reads name, or the file named by name_FILE unless that is a
+	// setting of its own, like POSTGRES_PASSWORD_FILE.
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		file, fromFile := env[name+"_FILE"]
+		if _, isSetting := prefixed[name+"_FILE"]; isSetting {
+			fromFile = false
+		}
+		switch {
+		case ok && fromFile:
+			errs = append(errs, fmt.Errorf("%s: cannot be combined with %s_FILE", name, name))
+			return "", false
+		case fromFile:
+			data, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", name, err))
+				return "", false
+			}
+			return strings.TrimRight(string(data), "\r\n"), true
+		}
+		return value, ok
+	}
+
+	for _, s := range settings() {
+		field := v.FieldByIndex(s.index)
+		if s.legacy {
+			if value, ok := env[s.env]; ok {
+				errs = append(errs, wrapSetting(s.env, setValue(field, value)))
+			}
+		}
+		if value, ok := lookup(l.prefix + s.env); ok {
+			errs = append(errs, wrapSetting(l.prefix+s.env, setValue(field, value)))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if !strings.HasPrefix(name, l.prefix) || name == l.prefix+"CONFIG" {
+			continue
+		}
+		if _, ok := prefixed[name]; ok {
+			continue
+		}
+		if _, ok := prefixed[strings.TrimSuffix(name, "_FILE")]; ok {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: unknown setting", name))
+	}
+	return errs
+}
+
+func environMap(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	return env
+}
+
+func wrapSetting(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PostgresConfig struct {
+	// Addr is a complete connection string, used as is when Host is empty.
+	Addr string `yaml:"addr" env:"POSTGRES_ADDR"`
+
+	Host         string `yaml:"host" env:"POSTGRES_HOST"`
+	Port         int    `yaml:"port" env:"POSTGRES_PORT" env-default:"5432"`
+	Database     string `yaml:"database" env:"POSTGRES_DB"`
+	User         string `yaml:"user" env:"POSTGRES_USER"`
+	Password     string `yaml:"password" env:"POSTGRES_PASSWORD"`
+	PasswordFile string `yaml:"password_file" env:"POSTGRES_PASSWORD_FILE"`
+	SSLMode      string `yaml:"ssl_mode" env:"POSTGRES_SSL_MODE" env-default:"prefer"`
+	SSLRootCert  string `yaml:"ssl_root_cert" env:"POSTGRES_SSL_ROOT_CERT"`
+
+	MaxConns          int32         `yaml:"max_conns" env:"POSTGRES_MAX_CONNS" env-default:"10"`
+	MinConns          int32         `yaml:"min_conns" env:"POSTGRES_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"POSTGRES_MAX_CONN_LIFETIME" env-default:"1h"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"POSTGRES_MAX_CONN_IDLE_TIME" env-default:"30m"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"POSTGRES_HEALTH_CHECK_PERIOD" env-default:"1m"`
+
+	// Session settings applied to every connection, replicas included.
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT" env-default:"30s"`
+	ApplicationName  string        `yaml:"application_name" env:"POSTGRES_APPLICATION_NAME" env-default:"testovoe"`
+	SearchPath       string        `yaml:"search_path" env:"POSTGRES_SEARCH_PATH"`
+
+	AutoMigrate bool `yaml:"auto_migrate" env:"POSTGRES_AUTO_MIGRATE"`
+
+	// Replicas serve reads while they lag the primary by at most
+	// MaxReplicaLag.
+	Replicas             []string      `yaml:"replicas" env:"POSTGRES_REPLICAS" env-separator:","`
+	MaxReplicaLag        time.Duration `yaml:"max_replica_lag" env:"POSTGRES_MAX_REPLICA_LAG" env-default:"1s"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"POSTGRES_REPLICA_CHECK_INTERVAL" env-default:"1s"`
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func (p PostgresConfig) validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("postgres."+format, args...))
+	}
+
+	if p.Host == "" && p.Addr == "" {
+		add("host: required unless addr is set")
+	}
+	if p.Host != "" && (p.Port < 1 || p.Port > 65535) {
+		add("port: %d is not a valid port", p.Port)
+	}
+	if p.Password != "" && p.PasswordFile != "" {
+		add("password_file: cannot be combined with password")
+	}
+	if p.PasswordFile != "" {
+		if _, err := os.Stat(p.PasswordFile); err != nil {
+			add("password_file: %v", err)
+		}
+	}
+	if !slices.Contains(sslModes, p.SSLMode) {
+		add("ssl_mode: %q is not one of %s", p.SSLMode, strings.Join(sslModes, ", "))
+	}
+	if p.SSLRootCert != "" {
+		if _, err := os.Stat(p.SSLRootCert); err != nil {
+			add("ssl_root_cert: %v", err)
+		}
+	}
+
+	if p.MaxConns < 1 {
+		add("max_conns: must be at least 1")
+	}
+	if p.MinConns < 0 || p.MinConns > p.MaxConns {
+		add("min_conns: must be between 0 and max_conns (%d)", p.MaxConns)
+	}
+	for name, d := range map[string]time.Duration{
+		"max_conn_lifetime":      p.MaxConnLifetime,
+		"max_conn_idle_time":     p.MaxConnIdleTime,
+		"health_check_period":    p.HealthCheckPeriod,
+		"statement_timeout":      p.StatementTimeout,
+		"replica_check_interval": p.ReplicaCheckInterval,
+	} {
+		if d <= 0 {
+			add("%s: must be positive", name)
+		}
+	}
+	if p.StatementTimeout%time.Millisecond != 0 {
+		add("statement_timeout: must be a whole number of milliseconds")
+	}
+	if p.ApplicationName == "" {
+		add("application_name: required")
+	}
+	if p.MaxReplicaLag < 0 {
+		add("max_replica_lag: must not be negative")
+	}
+
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// ConnString returns the primary's connection string with the session
+// settings applied.
+func (p PostgresConfig) ConnString() (string, error) {
+	if p.Host == "" {
+		return withParams(p.Addr, p.sessionParams())
+	}
+
+	password := p.Password
+	if p.PasswordFile != "" {
+		data, err := os.ReadFile(p.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read password file: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	params := [][2]string{
+		{"host", p.Host},
+		{"port", strconv.Itoa(p.Port)},
+		{"dbname", p.Database},
+		{"user", p.User},
+		{"password", password},
+		{"sslmode", p.SSLMode},
+		{"sslrootcert", p.SSLRootCert},
+	}
+	return withParams("", append(params, p.sessionParams()...))
+}
+
+// ReplicaConnStrings returns the replica connection strings with the session
+// settings applied.
+func (p PostgresConfig) ReplicaConnStrings() ([]string, error) {
+	conns := make([]string, len(p.Replicas))
+	for i, addr := range p.Replicas {
+		conn, err := withParams(addr, p.sessionParams())
+		if err != nil {
+			return nil, err
+		}
+		conns[i] = conn
+	}
+	return conns, nil
+}
+
+func (p PostgresConfig) sessionParams() [][2]string {
+	return [][2]string{
+		{"application_name", p.ApplicationName},
+		{"statement_timeout", strconv.FormatInt(p.StatementTimeout.Milliseconds(), 10)},
+		{"search_path", p.SearchPath},
+	}
+}
+
+// withParams adds params with non-empty values to a connection string in
+// either URL or keyword/value form, replacing ones it already sets.
+func withParams(conn string, params [][2]string) (string, error) {
+	if strings.HasPrefix(conn, "postgres://") || strings.HasPrefix(conn, "postgresql://") {
+		u, err := url.Parse(conn)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection URL: %w", err)
+		}
+		q := u.Query()
+		for _, kv := range params {
+			if kv[1] != "" {
+				q.Set(kv[0], kv[1])
+			}
+		}
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(conn)
+	for _, kv := range params {
+		if kv[1] == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		// Later keywords override earlier ones.
+		fmt.Fprintf(&b, "%s='%s'", kv[0], strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(kv[1]))
+	}
+	return b.String(), nil
+}
+
+const redacted = "REDACTED"
+
+var keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactConn hides the password in a connection string.
+func redactConn(conn string) string {
+	u, err := url.Parse(conn)
+	if err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return keywordPassword.ReplaceAllString(conn, "${1}"+redacted)
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// hotReloadable are the settings a running service applies on reload.
+// Everything else takes effect after a restart.
+var hotReloadable = []string{
+	"log.level",
+	"validation",
+	"rate_limit.rate",
+	"rate_limit.burst",
+	"rate_limit.routes",
+	"rate_limit.daily_writes",
+	"cache.ttl",
+	"features",
+}
+
+// HotReloadable reports whether the setting at path is applied on reload.
+func HotReloadable(path string) bool {
+	return Touches(hotReloadable, path)
+}
+
+// Touches reports whether any path in paths is prefix or lies under it, or
+// prefix lies under one of them.
+func Touches(paths []string, prefix string) bool {
+	return slices.ContainsFunc(paths, func(p string) bool {
+		return p == prefix || strings.HasPrefix(p, prefix+".") || strings.HasPrefix(prefix, p+".")
+	})
+}
+
+// Diff lists the settings that differ between a and b by their YAML path,
+// such as "rate_limit.burst". Maps and slices are compared whole.
+func Diff(a, b *Config) []string {
+	var paths []string
+	diff(reflect.ValueOf(*a), reflect.ValueOf(*b), "", &paths)
+	return paths
+}
+
+func diff(a, b reflect.Value, path string, paths *[]string) {
+	if a.Kind() == reflect.Struct && a.Type().PkgPath() == reflect.TypeFor[Config]().PkgPath() {
+		for i := range a.NumField() {
+			name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("yaml"), ",")
+			if path != "" {
+				name = path + "." + name
+			}
+			diff(a.Field(i), b.Field(i), name, paths)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		*paths = append(*paths, path)
+	}
+}
+
+// Version identifies the config a running service is using.
+type Version struct {
+	// ID is a digest of the effective settings, env overrides included.
+	ID       string    `json:"id"`
+	Seq      int       `json:"seq"`
+	LoadedAt time.Time `json:"loaded_at"`
+	// RestartRequired lists the settings changed since startup that the
+	// running service ignores.
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+func digest(cfg *Config) string {
+	data, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Reloader reloads the config file when it changes or the process gets
+// SIGHUP. A new config is validated as a whole before apply sees it; an
+// invalid one is logged and ignored.
+type Reloader struct {
+	log    *slog.Logger
+	loader *Loader
+	apply  func(cfg *Config, changed []string)
+
+	mu      sync.Mutex
+	startup *Config
+	current *Config
+	version Version
+}
+
+// NewReloader reloads cfg through loader, which it came from. apply is
+// called with the new config and the hot-reloadable settings that changed.
+func NewReloader(log *slog.Logger, loader *Loader, cfg *Config, apply func(cfg *Config, changed []string)) *Reloader {
+	return &Reloader{
+		log:     log,
+		loader:  loader,
+		apply:   apply,
+		startup: cfg,
+		current: cfg,
+		version: Version{ID: digest(cfg), Seq: 1, LoadedAt: time.Now().UTC()},
+	}
+}
+
+func (r *Reloader) Version() Version {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.version
+}
+
+// Current returns the config last loaded, restart-only settings included.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload loads every layer again and applies what changed.
+func (r *Reloader) Reload() error {
+	const op = "config.Reload"
+
+	cfg, err := r.loader.Load()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := digest(cfg)
+	if id == r.version.ID {
+		return nil
+	}
+
+	var hot []string
+	for _, path := range Diff(r.current, cfg) {
+		if HotReloadable(path) {
+			hot = append(hot, path)
+		}
+	}
+	var restart []string
+	for _, path := range Diff(r.startup, cfg) {
+		if !HotReloadable(path) {
+			restart = append(restart, path)
+		}
+	}
+
+	if len(hot) > 0 {
+		r.apply(cfg, hot)
+	}
+	r.current = cfg
+	r.version = Version{ID: id, Seq: r.version.Seq + 1, LoadedAt: time.Now().UTC(), RestartRequired: restart}
+
+	r.log.Info("config reloaded", "op", op, "version", id, "applied", hot)
+	if len(restart) > 0 {
+		r.log.Warn("config changes need a restart", "op", op, "settings", restart)
+	}
+	return nil
+}
+
+// Run reloads on SIGHUP and on changes in the config file's directory,
+// which also catches editors and mounts that replace the file, until ctx
+// is done. Without a config file only SIGHUP reloads.
+func (r *Reloader) Run(ctx context.Context) error {
+	const op = "config.Run"
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer watcher.Close()
+
+	if path := r.loader.Path(); path != "" {
+		err = watcher.Add(filepath.Dir(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Writes come in bursts; reload once they settle.
+	const settle = 100 * time.Millisecond
+	debounce := time.NewTimer(settle)
+	debounce.Stop()
+
+	reload := func() {
+		err := r.Reload()
+		if err != nil {
+			r.log.Error("config not reloaded, keeping the current one", "op", op, "error", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			reload()
+		case <-watcher.Events:
+			debounce.Reset(settle)
+		case err := <-watcher.Errors:
+			r.log.Warn("config watch error", "op", op, "error", err)
+		case <-debounce.C:
+			reload()
+		}
+	}
+}
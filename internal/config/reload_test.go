@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+postgres:
+  addr: "postgres://user@localhost:5432/testovoe"
+cache:
+  ttl: 1s
+`
+
+func writeConfig(t *testing.T, path, extra string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(baseConfig+extra), 0o600))
+}
+
+type applied struct {
+	cfg     *Config
+	changed []string
+}
+
+func newTestReloader(t *testing.T, extra string) (*Reloader, string, chan applied) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, extra)
+	loader := testLoader(path)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	calls := make(chan applied, 10)
+	r := NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), loader, cfg, func(cfg *Config, changed []string) {
+		calls <- applied{cfg, changed}
+	})
+	return r, path, calls
+}
+
+func TestDiff(t *testing.T) {
+	a := &Config{Cache: Cache{TTL: time.Second}, Features: map[string]bool{"watch": true}}
+	b := &Config{Cache: Cache{TTL: time.Minute}, Features: map[string]bool{"watch": false}}
+	b.HttpServer.Address = ":9000"
+	b.HttpServer.TLS.MinVersion = "1.3"
+
+	assert.Equal(t, []string{"http_server.address", "http_server.tls.min_version", "cache.ttl", "features"}, Diff(a, b))
+	assert.Empty(t, Diff(a, a))
+
+	assert.True(t, HotReloadable("cache.ttl"))
+	assert.True(t, HotReloadable("validation.min"))
+	assert.False(t, HotReloadable("rate_limit.enabled"))
+	assert.False(t, HotReloadable("http_server.address"))
+}
+
+func TestReloader_AppliesHotSettingsAndReportsTheRest(t *testing.T) {
+	r, path, calls := newTestReloader(t, "")
+	first := r.Version()
+
+	writeConfig(t, path, `
+log:
+  level: warn
+features:
+  watch: false
+http_server:
+  address: "0.0.0.0:9000"
+`)
+	require.NoError(t, r.Reload())
+
+	call := <-calls
+	assert.Equal(t, []string{"log.level", "features"}, call.changed)
+	assert.Equal(t, map[string]bool{"watch": false}, call.cfg.Features)
+
+	v := r.Version()
+	assert.NotEqual(t, first.ID, v.ID)
+	assert.Equal(t, 2, v.Seq)
+	assert.Equal(t, []string{"http_server.address"}, v.RestartRequired)
+
+	// Unchanged content is not applied again.
+	require.NoError(t, r.Reload())
+	assert.Empty(t, calls)
+	assert.Equal(t, 2, r.Version().Seq)
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	r, path, calls := newTestReloader(t, "")
+	before := r.Version()
+
+	writeConfig(t, path, `
+log:
+  level: loud
+features:
+  teleport: true
+`)
+	err := r.Reload()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "log.level")
+	assert.ErrorContains(t, err, "teleport")
+
+	assert.Empty(t, calls)
+	assert.Equal(t, before, r.Version())
+}
+
+func TestReloader_RunWatchesFile(t *testing.T) {
+	r, path, calls := newTestReloader(t, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	// Replace the file the way editors and config mounts do.
+	require.Eventually(t, func() bool {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(baseConfig+"\nlog:\n  level: error\n"), 0o600))
+		require.NoError(t, os.Rename(tmp, path))
+		select {
+		case call := <-calls:
+			return assert.Equal(t, "error", call.cfg.Log.Level)
+		case <-time.After(500 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited operations.
+const AuditPut = "put"
+
+// Outcomes of an audited operation.
+const (
+	AuditSuccess  = "success"
+	AuditPending  = "pending"
+	AuditRejected = "rejected"
+	AuditFailure  = "failure"
+)
+
+// AuditEntry records one attempted mutation. Seq and Hash are assigned by
+// the sink that chains it; they are not part of what is hashed.
+type AuditEntry struct {
+	Seq       int64           `json:"seq,omitempty"`
+	At        time.Time       `json:"at"`
+	Principal string          `json:"principal,omitempty"`
+	Operation string          `json:"operation"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Outcome   string          `json:"outcome"`
+	Error     string          `json:"error,omitempty"`
+	NumID     int64           `json:"num_id,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+}
+
+// Canonical returns the bytes of e that are hashed into the chain.
+func (e AuditEntry) Canonical() []byte {
+	e.Seq, e.Hash = 0, ""
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// AuditQuery selects audit entries; zero fields match everything. Entries
+// come oldest first, starting after AfterSeq.
+type AuditQuery struct {
+	Principal string
+	Operation string
+	Outcome   string
+	RequestID string
+	NumID     int64
+	Since     time.Time
+	Until     time.Time
+	AfterSeq  int64
+	Limit     int
+}
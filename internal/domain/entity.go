@@ -1,5 +1,23 @@
 package domain
 
 type UserNum struct {
-	Num int `json:"num"`
+	Num    Number            `json:"num"`
+	Source string            `json:"source,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Record is a stored number together with its id, which increases with
+// insertion order. Meta is only filled in by queries that ask for it.
+type Record struct {
+	ID   int64  `json:"id"`
+	Num  Number `json:"num"`
+	Meta *Meta  `json:"meta,omitempty"`
+}
+
+type Stats struct {
+	Count int64  `json:"count"`
+	Min   Number `json:"min"`
+	Max   Number `json:"max"`
+	Sum   Number `json:"sum"`
+	Mean  Number `json:"mean"`
 }
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrIdempotencyMismatch is returned when a client reuses an idempotency key
+// for a different request.
+var ErrIdempotencyMismatch = errors.New("idempotency key was already used for a different number")
+
+// RequestHash identifies the number an idempotency key was first used with.
+func RequestHash(num Number) string {
+	sum := sha256.Sum256([]byte(num.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"maps"
+	"time"
+)
+
+// Meta is the provenance stored with a number.
+type Meta struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Client    string            `json:"client,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// RecordQuery selects stored records by their metadata. Zero fields match
+// everything; every label must be present with the given value.
+type RecordQuery struct {
+	Order  string
+	Since  time.Time
+	Until  time.Time
+	Client string
+	Source string
+	Labels map[string]string
+	// Limit caps the number of records returned; 0 means no limit.
+	Limit int
+}
+
+// Matches reports whether a number stored with meta is selected by q.
+// Since is inclusive and Until exclusive.
+func (q RecordQuery) Matches(meta Meta) bool {
+	if !q.Since.IsZero() && meta.CreatedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !meta.CreatedAt.Before(q.Until) {
+		return false
+	}
+	if q.Client != "" && meta.Client != q.Client {
+		return false
+	}
+	if q.Source != "" && meta.Source != q.Source {
+		return false
+	}
+	for k, v := range q.Labels {
+		if got, ok := meta.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Meta) Clone() Meta {
+	m.Labels = maps.Clone(m.Labels)
+	return m
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordQuery_Matches(t *testing.T) {
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	meta := Meta{CreatedAt: at, Client: "a", Source: "sensor", Labels: map[string]string{"env": "prod", "zone": "x"}}
+
+	testCases := []struct {
+		name     string
+		q        RecordQuery
+		expected bool
+	}{
+		{"empty", RecordQuery{}, true},
+		{"since inclusive", RecordQuery{Since: at}, true},
+		{"until exclusive", RecordQuery{Until: at}, false},
+		{"in window", RecordQuery{Since: at.Add(-time.Hour), Until: at.Add(time.Hour)}, true},
+		{"client", RecordQuery{Client: "b"}, false},
+		{"source", RecordQuery{Source: "sensor"}, true},
+		{"label subset", RecordQuery{Labels: map[string]string{"env": "prod"}}, true},
+		{"label value", RecordQuery{Labels: map[string]string{"env": "dev"}}, false},
+		{"missing label", RecordQuery{Labels: map[string]string{"team": "core"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.q.Matches(meta))
+		})
+	}
+}
@@ -0,0 +1,629 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Limits of the Postgres NUMERIC type, which stores every number mode.
+const (
+	maxScale         = 16383
+	maxIntegerDigits = 131072
+)
+
+var (
+	ErrInvalidNumber = errors.New("must be a number")
+	ErrNotInteger    = errors.New("must be an integer")
+	ErrOutOfRange    = errors.New("is out of range for a 64-bit integer")
+	ErrTooManyDigits = errors.New("has too many digits")
+	ErrFloatRange    = errors.New("is out of range for a 64-bit float")
+	ErrNotFinite     = errors.New("must be a finite number")
+)
+
+type NumberMode string
+
+const (
+	ModeInt64   NumberMode = "int64"
+	ModeBigInt  NumberMode = "bigint"
+	ModeDecimal NumberMode = "decimal"
+	ModeFloat64 NumberMode = "float64"
+)
+
+func ParseNumberMode(s string) (NumberMode, error) {
+	switch mode := NumberMode(s); mode {
+	case "":
+		return ModeInt64, nil
+	case ModeInt64, ModeBigInt, ModeDecimal, ModeFloat64:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown number mode %q", s)
+	}
+}
+
+// Normalize checks that n can be stored in mode and returns it in the form
+// the mode stores: integer modes drop a zero fractional part, so 5.0 becomes
+// 5, and float64 rounds decimals to the nearest float.
+func (m NumberMode) Normalize(n Number) (Number, error) {
+	if m == ModeFloat64 {
+		if n.isFloat {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(n.String(), 64)
+		if err != nil && math.IsInf(f, 0) {
+			return Number{}, ErrFloatRange
+		}
+		return NewFloat(f), nil
+	}
+
+	if n.isFloat {
+		if math.IsNaN(n.float()) || math.IsInf(n.float(), 0) {
+			return Number{}, ErrNotFinite
+		}
+		n, _ = parseDecimal(strconv.FormatFloat(n.float(), 'e', -1, 64))
+	}
+
+	switch m {
+	case ModeDecimal:
+		return n, nil
+	case ModeBigInt:
+		if !n.IsInt() {
+			return Number{}, ErrNotInteger
+		}
+		return n.integer(), nil
+	default:
+		if !n.IsInt() {
+			return Number{}, ErrNotInteger
+		}
+		n = n.integer()
+		if n.big != nil {
+			return Number{}, ErrOutOfRange
+		}
+		return n, nil
+	}
+}
+
+// Number is either an exact decimal value, unscaled * 10^-scale, or an IEEE
+// float64. Decimals whose unscaled part fits in int64 avoid allocating a
+// big.Int, which keeps the common int64 mode cheap to sort and compare; floats
+// keep their bits in small. The zero value is the decimal 0.
+type Number struct {
+	small   int64
+	big     *big.Int
+	scale   int32
+	isFloat bool
+}
+
+func NewInt(v int64) Number {
+	return Number{small: v}
+}
+
+func NewFloat(f float64) Number {
+	return Number{small: int64(math.Float64bits(f)), isFloat: true}
+}
+
+func NewBigInt(v *big.Int) Number {
+	return newNumber(new(big.Int).Set(v), 0)
+}
+
+// NewDecimal returns unscaled * 10^-scale. A negative scale multiplies the
+// value by a power of ten.
+func NewDecimal(unscaled *big.Int, scale int32) Number {
+	u := new(big.Int).Set(unscaled)
+	if scale < 0 {
+		u.Mul(u, pow10(-scale))
+		scale = 0
+	}
+	return newNumber(u, scale)
+}
+
+func newNumber(unscaled *big.Int, scale int32) Number {
+	if unscaled.IsInt64() {
+		return Number{small: unscaled.Int64(), scale: scale}
+	}
+	return Number{big: unscaled, scale: scale}
+}
+
+// ParseNumber parses a decimal literal such as "-12", "3.50" or "1e3" into
+// an exact decimal. Values a decimal cannot hold parse as floats: the IEEE
+// specials "NaN", "Infinity" and "-Infinity", and negative zero.
+func ParseNumber(s string) (Number, error) {
+	switch s {
+	case "NaN":
+		return NewFloat(math.NaN()), nil
+	case "Infinity", "+Infinity":
+		return NewFloat(math.Inf(1)), nil
+	case "-Infinity":
+		return NewFloat(math.Inf(-1)), nil
+	}
+
+	n, err := parseDecimal(s)
+	if err == nil && n.Sign() == 0 && strings.HasPrefix(s, "-") {
+		return NewFloat(math.Copysign(0, -1)), nil
+	}
+	return n, err
+}
+
+func parseDecimal(s string) (Number, error) {
+	if s == "" {
+		return Number{}, ErrInvalidNumber
+	}
+
+	if !strings.ContainsAny(s, ".eE") {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err == nil {
+			return NewInt(v), nil
+		}
+	}
+
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return Number{}, ErrTooManyDigits
+			}
+			return Number{}, ErrInvalidNumber
+		}
+		exponent = exp
+	}
+
+	negative := false
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		negative = true
+		mantissa = mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart == "" && fracPart == "" {
+		return Number{}, ErrInvalidNumber
+	}
+	digits := intPart + fracPart
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Number{}, ErrInvalidNumber
+		}
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+	}
+
+	scale := int64(len(fracPart)) - exponent
+	integerDigits := int64(len(digits)) - scale
+	if scale > maxScale || integerDigits > maxIntegerDigits {
+		return Number{}, ErrTooManyDigits
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		unscaled.Neg(unscaled)
+	}
+
+	return NewDecimal(unscaled, int32(scale)), nil
+}
+
+func MustParseNumber(s string) Number {
+	n, err := ParseNumber(s)
+	if err != nil {
+		panic(fmt.Sprintf("domain.MustParseNumber(%q): %v", s, err))
+	}
+	return n
+}
+
+// IsFloat reports whether n holds a float64 rather than an exact decimal.
+func (n Number) IsFloat() bool {
+	return n.isFloat
+}
+
+// Float64 returns the nearest float64 and whether the conversion was exact.
+func (n Number) Float64() (float64, bool) {
+	if n.isFloat {
+		return n.float(), true
+	}
+	if n.big == nil && n.scale == 0 {
+		f := float64(n.small)
+		return f, int64(f) == n.small && f != 1<<63
+	}
+	f, exact := n.rat().Float64()
+	return f, exact
+}
+
+func (n Number) IsNaN() bool {
+	return n.isFloat && math.IsNaN(n.float())
+}
+
+func (n Number) float() float64 {
+	return math.Float64frombits(uint64(n.small))
+}
+
+// rat returns the exact value of a decimal or finite float.
+func (n Number) rat() *big.Rat {
+	if n.isFloat {
+		return new(big.Rat).SetFloat64(n.float())
+	}
+	u, s := n.Unscaled()
+	return new(big.Rat).SetFrac(u, pow10(s))
+}
+
+// Unscaled returns the integer u and scale s such that the value is u * 10^-s.
+// Finite floats are converted exactly; NaN and infinities yield zero.
+func (n Number) Unscaled() (*big.Int, int32) {
+	if n.isFloat {
+		return floatUnscaled(n.float())
+	}
+	if n.big != nil {
+		return new(big.Int).Set(n.big), n.scale
+	}
+	return big.NewInt(n.small), n.scale
+}
+
+func (n Number) Scale() int32 {
+	return n.scale
+}
+
+// IntegerDigits returns how many digits n has before the decimal point,
+// leaving out leading zeros.
+func (n Number) IntegerDigits() int {
+	u, scale := n.Unscaled()
+	if u.Sign() == 0 {
+		return 0
+	}
+	return max(len(u.Abs(u).String())-int(scale), 0)
+}
+
+func (n Number) Sign() int {
+	if n.isFloat {
+		f := n.float()
+		switch {
+		case f < 0:
+			return -1
+		case f > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if n.big != nil {
+		return n.big.Sign()
+	}
+	switch {
+	case n.small < 0:
+		return -1
+	case n.small > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (n Number) Abs() Number {
+	if n.isFloat {
+		return NewFloat(math.Abs(n.float()))
+	}
+	if n.Sign() >= 0 {
+		return n
+	}
+	u, s := n.Unscaled()
+	return newNumber(u.Neg(u), s)
+}
+
+// IsEven reports whether n is an integer divisible by two.
+func (n Number) IsEven() bool {
+	if !n.IsInt() {
+		return false
+	}
+	if v, ok := n.Int64(); ok {
+		return v%2 == 0
+	}
+	u, s := n.Unscaled()
+	return u.Quo(u, pow10(s)).Bit(0) == 0
+}
+
+// IsInt reports whether the fractional part is zero.
+func (n Number) IsInt() bool {
+	if n.isFloat {
+		f := n.float()
+		return !math.IsInf(f, 0) && f == math.Trunc(f)
+	}
+	if n.scale == 0 {
+		return true
+	}
+	if n.big == nil && n.scale <= 18 {
+		return n.small%int64pow10[n.scale] == 0
+	}
+	u, _ := n.Unscaled()
+	return new(big.Int).Rem(u, pow10(n.scale)).Sign() == 0
+}
+
+// Int64 returns the value as int64 if it is an integer within range.
+func (n Number) Int64() (int64, bool) {
+	if !n.IsInt() {
+		return 0, false
+	}
+	if n.isFloat {
+		f := n.float()
+		if f < -(1<<63) || f >= 1<<63 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	i := n.integer()
+	if i.big != nil {
+		return 0, false
+	}
+	return i.small, true
+}
+
+// integer drops the fractional digits.
+func (n Number) integer() Number {
+	if n.scale == 0 {
+		return n
+	}
+	if n.big == nil && n.scale <= 18 {
+		return Number{small: n.small / int64pow10[n.scale]}
+	}
+	u, _ := n.Unscaled()
+	return newNumber(u.Quo(u, pow10(n.scale)), 0)
+}
+
+// Cmp defines a total order: -Infinity < finite values < +Infinity < NaN.
+// NaN equals NaN, and -0 equals +0 as in IEEE 754 and Postgres, so the two
+// zeros keep their insertion order under a stable sort.
+func (n Number) Cmp(m Number) int {
+	if n.isFloat || m.isFloat {
+		return cmpFloat(n, m)
+	}
+
+	if n.big == nil && m.big == nil && n.scale == m.scale {
+		switch {
+		case n.small < m.small:
+			return -1
+		case n.small > m.small:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	if ns, ms := n.Sign(), m.Sign(); ns != ms {
+		if ns < ms {
+			return -1
+		}
+		return 1
+	}
+
+	a, b := align(n, m)
+	return a.Cmp(b)
+}
+
+func (n Number) Add(m Number) Number {
+	if n.isFloat || m.isFloat {
+		a, _ := n.Float64()
+		b, _ := m.Float64()
+		return NewFloat(a + b)
+	}
+
+	if n.big == nil && m.big == nil && n.scale == m.scale {
+		sum := n.small + m.small
+		// Overflow happened if both operands have the same sign and the
+		// sum's sign differs.
+		if (n.small >= 0) != (m.small >= 0) || (sum >= 0) == (n.small >= 0) {
+			return Number{small: sum, scale: n.scale}
+		}
+	}
+
+	a, b := align(n, m)
+	return newNumber(a.Add(a, b), max(n.scale, m.scale))
+}
+
+// Quo divides by d and rounds half away from zero to the given scale.
+// Floats are divided in floating point and ignore scale.
+func (n Number) Quo(d int64, scale int32) Number {
+	if n.isFloat {
+		return NewFloat(n.float() / float64(d))
+	}
+
+	u, s := n.Unscaled()
+	if scale > s {
+		u.Mul(u, pow10(scale-s))
+	}
+	den := big.NewInt(d)
+	if scale < s {
+		den.Mul(den, pow10(s-scale))
+	}
+
+	q, r := new(big.Int).QuoRem(u, den, new(big.Int))
+	// Round: compare 2*|r| with |den|.
+	if r.Sign() != 0 && new(big.Int).Abs(r.Lsh(r, 1)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if u.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return newNumber(q, scale)
+}
+
+// String formats decimals with all their digits and floats in the shortest
+// form that parses back to the same float64.
+func (n Number) String() string {
+	if n.isFloat {
+		f := n.float()
+		switch {
+		case math.IsNaN(f):
+			return "NaN"
+		case math.IsInf(f, 1):
+			return "Infinity"
+		case math.IsInf(f, -1):
+			return "-Infinity"
+		default:
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+
+	if n.scale == 0 {
+		if n.big != nil {
+			return n.big.String()
+		}
+		return strconv.FormatInt(n.small, 10)
+	}
+
+	u, _ := n.Unscaled()
+	negative := u.Sign() < 0
+	digits := u.Abs(u).String()
+	if pad := int(n.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(n.scale)
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	b.WriteString(digits[:point])
+	b.WriteByte('.')
+	b.WriteString(digits[point:])
+	return b.String()
+}
+
+// MarshalJSON writes the value as a JSON number literal with every digit.
+// NaN and infinities have no JSON number form and are written as strings.
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n.isFloat {
+		f := n.float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return []byte(strconv.Quote(n.String())), nil
+		}
+	}
+	return []byte(n.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one, so clients
+// that cannot represent large values natively can send them quoted.
+func (n *Number) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return ErrInvalidNumber
+		}
+		data = []byte(s)
+	} else if len(data) == 0 || (data[0] != '-' && (data[0] < '0' || data[0] > '9')) {
+		return ErrInvalidNumber
+	}
+
+	parsed, err := ParseNumber(string(data))
+	if err != nil {
+		return err
+	}
+
+	*n = parsed
+	return nil
+}
+
+func (n Number) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+func (n *Number) UnmarshalText(text []byte) error {
+	parsed, err := ParseNumber(string(text))
+	if err != nil {
+		return err
+	}
+
+	*n = parsed
+	return nil
+}
+
+func cmpFloat(n, m Number) int {
+	nNaN := n.isFloat && math.IsNaN(n.float())
+	mNaN := m.isFloat && math.IsNaN(m.float())
+	switch {
+	case nNaN && mNaN:
+		return 0
+	case nNaN:
+		return 1
+	case mNaN:
+		return -1
+	}
+
+	if n.isFloat && m.isFloat {
+		a, b := n.float(), m.float()
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	for _, inf := range []struct {
+		num  Number
+		sign int
+	}{{n, 1}, {m, -1}} {
+		if inf.num.isFloat && math.IsInf(inf.num.float(), 0) {
+			if inf.num.float() > 0 {
+				return inf.sign
+			}
+			return -inf.sign
+		}
+	}
+
+	return n.rat().Cmp(m.rat())
+}
+
+// floatUnscaled converts a finite float exactly: f = mant / 2^k, and
+// mant / 2^k = mant * 5^k / 10^k.
+func floatUnscaled(f float64) (*big.Int, int32) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return new(big.Int), 0
+	}
+
+	r := new(big.Rat).SetFloat64(f)
+	if r.IsInt() {
+		return new(big.Int).Set(r.Num()), 0
+	}
+
+	k := int32(r.Denom().BitLen() - 1)
+	u := new(big.Int).Mul(r.Num(), new(big.Int).Exp(big.NewInt(5), big.NewInt(int64(k)), nil))
+	return u, k
+}
+
+// align returns the unscaled values of n and m brought to a common scale.
+func align(n, m Number) (*big.Int, *big.Int) {
+	a, as := n.Unscaled()
+	b, bs := m.Unscaled()
+	switch {
+	case as < bs:
+		a.Mul(a, pow10(bs-as))
+	case bs < as:
+		b.Mul(b, pow10(as-bs))
+	}
+	return a, b
+}
+
+var int64pow10 = func() [19]int64 {
+	var p [19]int64
+	p[0] = 1
+	for i := 1; i < len(p); i++ {
+		p[i] = p[i-1] * 10
+	}
+	return p
+}()
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
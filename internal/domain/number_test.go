@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumber_String(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"-0", "-0"},
+		{"42", "42"},
+		{"-42", "-42"},
+		{"007", "7"},
+		{"3.50", "3.50"},
+		{"-0.05", "-0.05"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"1e3", "1000"},
+		{"1.5E-3", "0.0015"},
+		{"-2.5e1", "-25"},
+		{"9223372036854775808", "9223372036854775808"},
+		{"-123456789012345678901234567890.123", "-123456789012345678901234567890.123"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			n, err := ParseNumber(tc.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, n.String())
+		})
+	}
+}
+
+func TestParseNumber_Invalid(t *testing.T) {
+	for _, input := range []string{"", "-", ".", "abc", "1.2.3", "1e", "0x10", "nan", "Inf", "1 000"} {
+		_, err := ParseNumber(input)
+		assert.ErrorIs(t, err, ErrInvalidNumber, input)
+	}
+
+	for _, input := range []string{"1e999999", "1e-20000", "1e99999999999"} {
+		_, err := ParseNumber(input)
+		assert.ErrorIs(t, err, ErrTooManyDigits, input)
+	}
+}
+
+func TestNumber_Cmp(t *testing.T) {
+	ordered := []string{
+		"-100000000000000000000",
+		"-9223372036854775808",
+		"-1.5",
+		"-1",
+		"0",
+		"0.001",
+		"1",
+		"1.000001",
+		"9.99",
+		"10",
+		"9223372036854775807",
+		"9223372036854775807.5",
+		"100000000000000000000",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, b := MustParseNumber(ordered[i]), MustParseNumber(ordered[j])
+			expected := 0
+			switch {
+			case i < j:
+				expected = -1
+			case i > j:
+				expected = 1
+			}
+			assert.Equal(t, expected, a.Cmp(b), "%s vs %s", ordered[i], ordered[j])
+		}
+	}
+
+	assert.Equal(t, 0, MustParseNumber("2.50").Cmp(MustParseNumber("2.5")))
+}
+
+func TestNumber_Add(t *testing.T) {
+	assert.Equal(t, "3.75", MustParseNumber("1.25").Add(MustParseNumber("2.5")).String())
+	assert.Equal(t, NewInt(-1), NewInt(2).Add(NewInt(-3)))
+
+	overflow := NewInt(math.MaxInt64).Add(NewInt(1))
+	assert.Equal(t, "9223372036854775808", overflow.String())
+
+	underflow := NewInt(math.MinInt64).Add(NewInt(-1))
+	assert.Equal(t, "-9223372036854775809", underflow.String())
+}
+
+func TestNumber_Quo(t *testing.T) {
+	assert.Equal(t, "0.33", NewInt(1).Quo(3, 2).String())
+	assert.Equal(t, "0.67", NewInt(2).Quo(3, 2).String())
+	assert.Equal(t, "-0.67", NewInt(-2).Quo(3, 2).String())
+	assert.Equal(t, "2.5", NewInt(5).Quo(2, 1).String())
+	assert.Equal(t, "3", NewInt(5).Quo(2, 0).String())
+	assert.Equal(t, "1.3", MustParseNumber("3.75").Quo(3, 1).String())
+}
+
+func TestNumber_IntConversions(t *testing.T) {
+	v, ok := MustParseNumber("5.000").Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), v)
+
+	_, ok = MustParseNumber("5.5").Int64()
+	assert.False(t, ok)
+
+	_, ok = MustParseNumber("9223372036854775808").Int64()
+	assert.False(t, ok)
+
+	huge, _ := new(big.Int).SetString("-99999999999999999999", 10)
+	assert.Equal(t, "-99999999999999999999", NewBigInt(huge).String())
+	assert.Equal(t, NewInt(1200), NewDecimal(big.NewInt(12), -2))
+}
+
+func TestNumber_JSON(t *testing.T) {
+	var nums []Number
+	err := json.Unmarshal([]byte(`[1, "2.50", -123456789012345678901234567890, "1e2"]`), &nums)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(nums)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,2.50,-123456789012345678901234567890,100]`, string(data))
+
+	var n Number
+	assert.Error(t, json.Unmarshal([]byte(`null`), &n))
+	assert.Error(t, json.Unmarshal([]byte(`true`), &n))
+	assert.Error(t, json.Unmarshal([]byte(`"abc"`), &n))
+}
+
+func TestNumberMode_Parse(t *testing.T) {
+	mode, err := ParseNumberMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ModeInt64, mode)
+
+	mode, err = ParseNumberMode("decimal")
+	require.NoError(t, err)
+	assert.Equal(t, ModeDecimal, mode)
+
+	_, err = ParseNumberMode("complex")
+	assert.Error(t, err)
+}
+
+func TestNumber_FloatCmp(t *testing.T) {
+	ordered := []Number{
+		NewFloat(math.Inf(-1)),
+		MustParseNumber("-1e400"),
+		NewFloat(-1.5),
+		MustParseNumber("-1.4999999999999999999"),
+		NewFloat(0.1),
+		MustParseNumber("0.1000000000000000055511151231257828"),
+		NewFloat(1e300),
+		MustParseNumber("1e400"),
+		NewFloat(math.Inf(1)),
+		NewFloat(math.NaN()),
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			expected := 0
+			switch {
+			case i < j:
+				expected = -1
+			case i > j:
+				expected = 1
+			}
+			assert.Equal(t, expected, ordered[i].Cmp(ordered[j]), "%s vs %s", ordered[i], ordered[j])
+		}
+	}
+
+	negZero := NewFloat(math.Copysign(0, -1))
+	assert.Equal(t, 0, negZero.Cmp(NewFloat(0)))
+	assert.Equal(t, 0, negZero.Cmp(NewInt(0)))
+	assert.Equal(t, 0, NewFloat(math.NaN()).Cmp(NewFloat(math.NaN())))
+}
+
+func TestNumber_FloatJSON(t *testing.T) {
+	values := []float64{0.1, 0, math.Copysign(0, -1), 1e21, 5e-324, math.MaxFloat64, 1.0 / 3, math.Inf(1), math.Inf(-1)}
+	for _, f := range values {
+		data, err := json.Marshal(NewFloat(f))
+		require.NoError(t, err)
+
+		var n Number
+		require.NoError(t, json.Unmarshal(data, &n))
+		n, err = ModeFloat64.Normalize(n)
+		require.NoError(t, err)
+
+		got, _ := n.Float64()
+		assert.Equal(t, math.Float64bits(f), math.Float64bits(got), string(data))
+	}
+
+	data, err := json.Marshal([]Number{NewFloat(math.NaN()), NewFloat(math.Inf(-1)), NewFloat(2)})
+	require.NoError(t, err)
+	assert.Equal(t, `["NaN","-Infinity",2]`, string(data))
+}
+
+func TestNumber_FloatUnscaled(t *testing.T) {
+	u, scale := NewFloat(0.1).Unscaled()
+	assert.Equal(t, "0.1000000000000000055511151231257827021181583404541015625", NewDecimal(u, scale).String())
+
+	u, scale = NewFloat(-3).Unscaled()
+	assert.Equal(t, "-3", NewDecimal(u, scale).String())
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "errors"
+
+// Built-in sort orders. Storage backends may push these down into their own
+// query language; every other order is applied in memory.
+const (
+	OrderAsc          = "asc"
+	OrderDesc         = "desc"
+	OrderAbs          = "abs"
+	OrderAbsDesc      = "abs_desc"
+	OrderInserted     = "inserted"
+	OrderInsertedDesc = "inserted_desc"
+)
+
+// ErrUnsupportedOrder is returned by storage that cannot apply an order itself.
+var ErrUnsupportedOrder = errors.New("order is not supported by storage")
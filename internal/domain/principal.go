@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Scopes a principal may be granted. Admin implies every other scope.
+const (
+	ScopeRead  = "nums:read"
+	ScopeWrite = "nums:write"
+	ScopeAdmin = "admin"
+)
+
+// Collection is the collection every number currently belongs to; keys
+// restricted to other collections are refused.
+const Collection = "nums"
+
+var ErrKeyNotFound = errors.New("api key not found")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Scopes []string `json:"scopes"`
+	// Collections restricts the principal to the named collections; empty
+	// means all of them.
+	Collections []string `json:"collections,omitempty"`
+}
+
+// Can reports whether p may use scope on collection.
+func (p Principal) Can(scope, collection string) bool {
+	if !slices.Contains(p.Scopes, scope) && !slices.Contains(p.Scopes, ScopeAdmin) {
+		return false
+	}
+	return len(p.Collections) == 0 || slices.Contains(p.Collections, collection)
+}
+
+// APIKey is a stored API key. Only a hash of its secret is kept.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"-"`
+	Scopes      []string   `json:"scopes"`
+	Collections []string   `json:"collections,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k APIKey) Principal() Principal {
+	return Principal{ID: k.ID, Name: k.Name, Scopes: k.Scopes, Collections: k.Collections}
+}
+
+// ValidScope reports whether scope is one of the known scopes.
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
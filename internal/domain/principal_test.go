@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	reader := Principal{Scopes: []string{ScopeRead}}
+	admin := Principal{Scopes: []string{ScopeAdmin}}
+	scoped := Principal{Scopes: []string{ScopeWrite}, Collections: []string{"other"}}
+
+	assert.True(t, reader.Can(ScopeRead, Collection))
+	assert.False(t, reader.Can(ScopeWrite, Collection))
+	assert.True(t, admin.Can(ScopeWrite, Collection))
+	assert.False(t, scoped.Can(ScopeWrite, Collection))
+	assert.True(t, scoped.Can(ScopeWrite, "other"))
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// RetentionPolicy bounds which numbers are kept. A zero MaxAge or MaxRows
+// leaves that bound off.
+type RetentionPolicy struct {
+	MaxAge  time.Duration
+	MaxRows int64
+	// Archive moves evicted numbers aside instead of deleting them.
+	Archive bool
+}
+
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxRows > 0
+}
+
+// RetentionReport says what one run of a retention policy removed.
+type RetentionReport struct {
+	Deleted            int64    `json:"deleted"`
+	Archived           int64    `json:"archived"`
+	DroppedPartitions  []string `json:"dropped_partitions,omitempty"`
+	ArchivedPartitions []string `json:"archived_partitions,omitempty"`
+	CreatedPartitions  []string `json:"created_partitions,omitempty"`
+}
+
+func (r RetentionReport) Removed() int64 {
+	return r.Deleted + r.Archived
+}
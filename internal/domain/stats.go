@@ -0,0 +1,81 @@
+package domain
+
+import "math"
+
+// meanScale matches the minimum precision Postgres uses for avg(numeric).
+const meanScale = 16
+
+// ComputeStats summarises nums in memory. Decimals are summed exactly; as
+// soon as a float is involved the sum switches to Neumaier's compensated
+// summation so that large and small values do not cancel out.
+func ComputeStats(nums []Number) Stats {
+	var (
+		stats    Stats
+		floats   bool
+		fsum     neumaier
+		exactSum Number
+	)
+	for i, num := range nums {
+		if i == 0 || num.Cmp(stats.Min) < 0 {
+			stats.Min = num
+		}
+		if i == 0 || num.Cmp(stats.Max) > 0 {
+			stats.Max = num
+		}
+
+		if num.isFloat {
+			floats = true
+			fsum.add(num.float())
+		} else {
+			exactSum = exactSum.Add(num)
+		}
+	}
+
+	stats.Count = int64(len(nums))
+	if floats {
+		f, _ := exactSum.Float64()
+		fsum.add(f)
+		stats.Sum = NewFloat(fsum.sum())
+	} else {
+		stats.Sum = exactSum
+	}
+	if stats.Count > 0 {
+		stats.Mean = stats.Sum.Quo(stats.Count, max(meanScale, stats.Sum.Scale()))
+	}
+
+	return stats
+}
+
+// neumaier is a compensated float64 accumulator. Infinities and NaN are kept
+// out of the compensation term, which they would otherwise poison.
+type neumaier struct {
+	s, c    float64
+	special float64
+	hasSpec bool
+}
+
+func (n *neumaier) add(f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		if n.hasSpec {
+			n.special += f
+		} else {
+			n.special, n.hasSpec = f, true
+		}
+		return
+	}
+
+	t := n.s + f
+	if math.Abs(n.s) >= math.Abs(f) {
+		n.c += (n.s - t) + f
+	} else {
+		n.c += (f - t) + n.s
+	}
+	n.s = t
+}
+
+func (n *neumaier) sum() float64 {
+	if n.hasSpec {
+		return n.special
+	}
+	return n.s + n.c
+}
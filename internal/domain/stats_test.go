@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeStats_Floats(t *testing.T) {
+	nums := []Number{NewFloat(1e16), NewFloat(1), NewFloat(-1e16), NewFloat(1)}
+	for range 10 {
+		nums = append(nums, NewFloat(0.1))
+	}
+
+	stats := ComputeStats(nums)
+
+	assert.Equal(t, int64(14), stats.Count)
+	assert.Equal(t, "-1e+16", stats.Min.String())
+	assert.Equal(t, "1e+16", stats.Max.String())
+	assert.Equal(t, "3", stats.Sum.String())
+
+	stats = ComputeStats([]Number{NewFloat(1), NewFloat(math.Inf(1)), NewFloat(math.NaN())})
+	assert.True(t, stats.Sum.IsNaN())
+	assert.True(t, stats.Max.IsNaN())
+
+	stats = ComputeStats([]Number{NewFloat(1), NewFloat(math.Inf(-1))})
+	assert.Equal(t, "-Infinity", stats.Sum.String())
+}
+
+func TestComputeStats_Decimals(t *testing.T) {
+	stats := ComputeStats([]Number{NewInt(1), MustParseNumber("2.5"), NewInt(-4)})
+
+	assert.Equal(t, int64(3), stats.Count)
+	assert.Equal(t, "-0.5", stats.Sum.String())
+	assert.Equal(t, "-0.1666666666666667", stats.Mean.String())
+	assert.Equal(t, Stats{}, ComputeStats(nil))
+}
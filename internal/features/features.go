@@ -0,0 +1,61 @@
+package features
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// Flags that switch routes off. Every flag is on unless set to false.
+const (
+	Watch = "watch"
+	Rank  = "rank"
+	Range = "range"
+	Stats = "stats"
+)
+
+var known = []string{Watch, Rank, Range, Stats}
+
+// Known reports whether name is a flag.
+func Known(name string) bool {
+	return slices.Contains(known, name)
+}
+
+// Flags holds the current flag values. It is safe to change them while
+// requests are being served.
+type Flags struct {
+	values atomic.Pointer[map[string]bool]
+}
+
+func New(values map[string]bool) *Flags {
+	f := &Flags{}
+	f.Set(values)
+	return f
+}
+
+func (f *Flags) Set(values map[string]bool) {
+	values = maps.Clone(values)
+	f.values.Store(&values)
+}
+
+func (f *Flags) Enabled(name string) bool {
+	on, ok := (*f.values.Load())[name]
+	return !ok || on
+}
+
+// Middleware answers 404 while flag name is off.
+func (f *Flags) Middleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.Enabled(name) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": name + " is disabled"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package features
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags_Middleware(t *testing.T) {
+	flags := New(nil)
+	h := flags.Middleware(Watch)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nums/watch", nil))
+		return w.Code
+	}
+
+	assert.True(t, flags.Enabled(Watch), "on unless set")
+	assert.Equal(t, http.StatusOK, serve())
+
+	flags.Set(map[string]bool{Watch: false})
+	assert.Equal(t, http.StatusNotFound, serve())
+	assert.True(t, flags.Enabled(Rank))
+
+	flags.Set(map[string]bool{Watch: true})
+	assert.Equal(t, http.StatusOK, serve())
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testovoe/internal/config"
+	"testovoe/internal/ratelimit"
+)
+
+// RateLimits is the rate limiter as adjusted through the admin API.
+type RateLimits interface {
+	Limits() ratelimit.Limits
+	SetLimits(limits ratelimit.Limits) error
+}
+
+// AdminHandler serves the operator API. Each part is optional; routes for a
+// part that is not set answer 404.
+type AdminHandler struct {
+	log    *slog.Logger
+	limits RateLimits
+	audit  AuditLog
+	config ConfigVersions
+
+	level    *slog.LevelVar
+	cache    Flusher
+	readOnly ReadOnlySwitch
+	stats    map[string]func() any
+	jobs     map[string]Job
+}
+
+// ConfigVersions reports the config the service runs with.
+type ConfigVersions interface {
+	Version() config.Version
+	Current() *config.Config
+}
+
+func NewAdminHandler(log *slog.Logger) *AdminHandler {
+	return &AdminHandler{log: log, stats: map[string]func() any{}, jobs: map[string]Job{}}
+}
+
+func (h *AdminHandler) SetRateLimits(limits RateLimits) {
+	h.limits = limits
+}
+
+func (h *AdminHandler) SetAuditLog(audit AuditLog) {
+	h.audit = audit
+}
+
+func (h *AdminHandler) SetConfig(config ConfigVersions) {
+	h.config = config
+}
+
+// ConfigVersion reports which config is active and which of its changes
+// wait for a restart.
+func (h *AdminHandler) ConfigVersion(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.ConfigVersion"
+
+		if h.config == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(h.config.Version())
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+func (h *AdminHandler) RateLimits(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.RateLimits"
+
+		if h.limits == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(h.limits.Limits())
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+// UpdateRateLimits replaces every limit with the ones in the body. They
+// apply to the next request; nothing restarts.
+func (h *AdminHandler) UpdateRateLimits(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.UpdateRateLimits"
+
+		if h.limits == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		defer r.Body.Close()
+
+		var limits ratelimit.Limits
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&limits)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		err = h.limits.SetLimits(limits)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		h.log.InfoContext(r.Context(), "rate limits updated", "op", op, "limits", limits)
+
+		err = json.NewEncoder(w).Encode(limits)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
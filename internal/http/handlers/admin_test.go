@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testovoe/internal/audit"
+	"testovoe/internal/config"
+	"testovoe/internal/domain"
+	"testovoe/internal/ratelimit"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_UpdateRateLimits(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limits{Default: ratelimit.Limit{Rate: 1, Burst: 1}})
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetRateLimits(limiter)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/limits",
+		bytes.NewBufferString(`{"default": {"rate": 10, "burst": 20}, "daily_writes": 1000}`))
+	w := httptest.NewRecorder()
+	admin.UpdateRateLimits(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ratelimit.Limits{Default: ratelimit.Limit{Rate: 10, Burst: 20}, DailyWrites: 1000}, limiter.Limits())
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/limits", nil)
+	w = httptest.NewRecorder()
+	admin.RateLimits(context.Background())(w, req)
+
+	var got ratelimit.Limits
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, limiter.Limits(), got)
+}
+
+func TestAdminHandler_UpdateRateLimits_Rejects(t *testing.T) {
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"unknown field", `{"defaults": {}}`, http.StatusBadRequest},
+		{"invalid", `{"default": {"rate": 5, "burst": 0}}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := ratelimit.New(ratelimit.Limits{})
+			admin := NewAdminHandler(newTestLogger())
+			admin.SetRateLimits(limiter)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/limits", bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+			admin.UpdateRateLimits(context.Background())(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, ratelimit.Limits{}, limiter.Limits())
+		})
+	}
+}
+
+type fakeAuditLog struct {
+	query   domain.AuditQuery
+	entries []domain.AuditEntry
+	verify  error
+}
+
+func (f *fakeAuditLog) FindAudit(_ context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	f.query = q
+	return f.entries, nil
+}
+
+func (f *fakeAuditLog) VerifyAudit(context.Context) (int64, error) {
+	return int64(len(f.entries)), f.verify
+}
+
+func TestAdminHandler_Audit(t *testing.T) {
+	log := &fakeAuditLog{entries: []domain.AuditEntry{{Seq: 4, Operation: domain.AuditPut, Outcome: domain.AuditSuccess}}}
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetAuditLog(log)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/admin/audit?principal=k1&outcome=rejected&num_id=9&since=2026-01-01T00:00:00Z&after=3&limit=1", nil)
+	w := httptest.NewRecorder()
+	admin.Audit(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.AuditQuery{
+		Principal: "k1",
+		Outcome:   domain.AuditRejected,
+		NumID:     9,
+		Since:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		AfterSeq:  3,
+		Limit:     1,
+	}, log.query)
+	assert.JSONEq(t, `{"entries":[{"seq":4,"at":"0001-01-01T00:00:00Z","operation":"put","outcome":"success"}],"next":4}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit?limit=0", nil)
+	w = httptest.NewRecorder()
+	admin.Audit(context.Background())(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminHandler_VerifyAudit(t *testing.T) {
+	log := &fakeAuditLog{}
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetAuditLog(log)
+
+	w := httptest.NewRecorder()
+	admin.VerifyAudit(context.Background())(w, httptest.NewRequest(http.MethodGet, "/admin/audit/verify", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"valid":true,"checked":0}`, w.Body.String())
+
+	log.verify = fmt.Errorf("entry 2: %w: hash mismatch", audit.ErrChainBroken)
+	w = httptest.NewRecorder()
+	admin.VerifyAudit(context.Background())(w, httptest.NewRequest(http.MethodGet, "/admin/audit/verify", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+type fakeReadOnly struct{ on bool }
+
+func (f *fakeReadOnly) ReadOnly() bool      { return f.on }
+func (f *fakeReadOnly) SetReadOnly(on bool) { f.on = on }
+
+type fakeConfig struct{ cfg *config.Config }
+
+func (f fakeConfig) Version() config.Version { return config.Version{Seq: 1} }
+func (f fakeConfig) Current() *config.Config { return f.cfg }
+
+type countingFlusher int
+
+func (f *countingFlusher) Flush() { *f++ }
+
+func adminRouter(h *AdminHandler) *chi.Mux {
+	r := chi.NewRouter()
+	ctx := context.Background()
+	r.Put("/log/level", h.UpdateLogLevel(ctx))
+	r.Get("/log/level", h.LogLevel(ctx))
+	r.Get("/config", h.ConfigDump(ctx))
+	r.Get("/stats", h.Stats(ctx))
+	r.Get("/stats/{name}", h.Stats(ctx))
+	r.Post("/cache/flush", h.FlushCache(ctx))
+	r.Post("/jobs/{name}", h.RunJob(ctx))
+	r.Put("/read-only", h.UpdateReadOnly(ctx))
+	r.Get("/read-only", h.ReadOnly(ctx))
+	return r
+}
+
+func serve(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetLogLevel(level)
+	r := adminRouter(admin)
+
+	w := serve(r, http.MethodPut, "/log/level", `{"level": "debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	w = serve(r, http.MethodPut, "/log/level", `{"level": "loud"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	w = serve(r, http.MethodGet, "/log/level", "")
+	assert.JSONEq(t, `{"level": "DEBUG"}`, w.Body.String())
+}
+
+func TestAdminHandler_ReadOnly(t *testing.T) {
+	sw := &fakeReadOnly{}
+	admin := NewAdminHandler(newTestLogger())
+	r := adminRouter(admin)
+
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/read-only", "").Code)
+
+	admin.SetReadOnlySwitch(sw)
+	w := serve(r, http.MethodPut, "/read-only", `{"enabled": true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, sw.on)
+
+	assert.Equal(t, http.StatusBadRequest, serve(r, http.MethodPut, "/read-only", `{}`).Code)
+	assert.True(t, sw.on)
+
+	w = serve(r, http.MethodGet, "/read-only", "")
+	assert.JSONEq(t, `{"enabled": true}`, w.Body.String())
+}
+
+func TestAdminHandler_StatsJobsAndCache(t *testing.T) {
+	var flushes countingFlusher
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetCache(&flushes)
+	admin.AddStats("pool", func() any { return map[string]int{"idle_conns": 3} })
+	admin.AddJob("resync", func(context.Context) (any, error) { return "done", nil })
+	admin.AddJob("broken", func(context.Context) (any, error) { return nil, fmt.Errorf("boom") })
+	r := adminRouter(admin)
+
+	w := serve(r, http.MethodGet, "/stats", "")
+	assert.JSONEq(t, `{"pool": {"idle_conns": 3}}`, w.Body.String())
+	w = serve(r, http.MethodGet, "/stats/pool", "")
+	assert.JSONEq(t, `{"idle_conns": 3}`, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/stats/nope", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, serve(r, http.MethodPost, "/cache/flush", "").Code)
+	assert.Equal(t, countingFlusher(1), flushes)
+
+	w = serve(r, http.MethodPost, "/jobs/resync", "")
+	assert.JSONEq(t, `{"job": "resync", "result": "done"}`, w.Body.String())
+	w = serve(r, http.MethodPost, "/jobs/broken", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "boom")
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodPost, "/jobs/nope", "").Code)
+}
+
+func TestAdminHandler_ConfigDumpIsRedacted(t *testing.T) {
+	cfg := &config.Config{Env: "prod"}
+	cfg.Postgres.Password = "hunter2"
+	cfg.Admin.Token = "s3cret-admin-token"
+	admin := NewAdminHandler(newTestLogger())
+	admin.SetConfig(fakeConfig{cfg: cfg})
+
+	w := serve(adminRouter(admin), http.MethodGet, "/config", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "env: prod")
+	assert.NotContains(t, w.Body.String(), "hunter2")
+	assert.NotContains(t, w.Body.String(), "s3cret")
+	assert.Equal(t, "hunter2", cfg.Postgres.Password)
+}
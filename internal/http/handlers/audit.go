@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testovoe/internal/audit"
+	"testovoe/internal/domain"
+	"time"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditLog is the audit trail as read through the admin API.
+type AuditLog interface {
+	FindAudit(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error)
+	VerifyAudit(ctx context.Context) (int64, error)
+}
+
+type auditResponse struct {
+	Entries []domain.AuditEntry `json:"entries"`
+	// Next is the after value for the following page; zero on the last.
+	Next int64 `json:"next,omitempty"`
+}
+
+// Audit lists audit entries oldest first. The principal, operation, outcome,
+// request_id, num_id, since and until parameters filter them; after and
+// limit page through them.
+func (h *AdminHandler) Audit(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Audit"
+
+		if h.audit == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		q, err := auditQuery(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		entries, err := h.audit.FindAudit(ctx, q)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not read audit log", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "failed to read audit log"})
+			return
+		}
+
+		resp := auditResponse{Entries: entries}
+		if entries == nil {
+			resp.Entries = []domain.AuditEntry{}
+		}
+		if len(entries) == q.Limit {
+			resp.Next = entries[len(entries)-1].Seq
+		}
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+// VerifyAudit recomputes the hash chain. A broken chain answers 409 with
+// the first bad link.
+func (h *AdminHandler) VerifyAudit(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.VerifyAudit"
+
+		if h.audit == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		checked, err := h.audit.VerifyAudit(ctx)
+		switch {
+		case errors.Is(err, audit.ErrChainBroken):
+			h.log.ErrorContext(r.Context(), "audit chain is broken", "op", op, "checked", checked, "error", err)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{"valid": false, "checked": checked, "error": err.Error()})
+			return
+		case err != nil:
+			h.log.ErrorContext(r.Context(), "could not verify audit log", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "failed to verify audit log"})
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]any{"valid": true, "checked": checked})
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+func auditQuery(r *http.Request) (domain.AuditQuery, error) {
+	query := r.URL.Query()
+	q := domain.AuditQuery{
+		Principal: query.Get("principal"),
+		Operation: query.Get("operation"),
+		Outcome:   query.Get("outcome"),
+		RequestID: query.Get("request_id"),
+		Limit:     defaultAuditLimit,
+	}
+
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		var err error
+		*dst, err = time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return q, fmt.Errorf("%s: must be an RFC 3339 time", name)
+		}
+	}
+
+	for name, dst := range map[string]*int64{"num_id": &q.NumID, "after": &q.AfterSeq} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("%s: must be a non-negative integer", name)
+		}
+		*dst = n
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			return q, fmt.Errorf("limit: must be between 1 and %d", maxAuditLimit)
+		}
+		q.Limit = n
+	}
+
+	return q, nil
+}
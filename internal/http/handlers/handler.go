@@ -3,25 +3,59 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"testovoe/internal/domain"
+	"testovoe/internal/logging"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 //go:generate mockery --name=UseCase --output=mocks/ --outpkg=mocks
 type UseCase interface {
-	GetSlices(ctx context.Context) ([]int, error)
-	PutNumber(ctx context.Context, number int) error
+	GetSlices(ctx context.Context, order string) ([]domain.Number, error)
+	PutNumber(ctx context.Context, number domain.Number) error
+	Stats(ctx context.Context) (domain.Stats, error)
+	Rank(ctx context.Context, num domain.Number) (rank, total int, err error)
+	Range(ctx context.Context, q usecase.RangeQuery) ([]domain.Number, error)
+	Subscribe() (<-chan domain.Number, func())
+	FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error)
 }
 
+// consistencyHeader carries the read-your-writes token: the put handler
+// returns it, and reads that send it back see that write.
+const consistencyHeader = "X-Consistency-Token"
+
+// maxPutBody bounds a /put-num body: room for a number within the default
+// digit limits and the largest labels allowed.
+const maxPutBody = 64 << 10
+
 type HTTPHandler struct {
 	useCase UseCase
 	log     *slog.Logger
+	health  []healthCheck
+
+	streams     chan struct{}
+	closeStream sync.Once
+}
+
+func NewHTTPHandler(log *slog.Logger, useCase UseCase) *HTTPHandler {
+	return &HTTPHandler{useCase: useCase, log: log, streams: make(chan struct{})}
 }
 
-func NewHTTPHandler(useCase UseCase) *HTTPHandler {
-	return &HTTPHandler{useCase: useCase}
+// CloseStreams ends every open event stream. http.Server.Shutdown does not
+// cancel requests in flight, so it must be called when shutdown starts.
+func (h *HTTPHandler) CloseStreams() {
+	h.closeStream.Do(func() { close(h.streams) })
 }
 
 func (h *HTTPHandler) HandleRequest(ctx context.Context) http.HandlerFunc {
@@ -30,49 +64,179 @@ func (h *HTTPHandler) HandleRequest(ctx context.Context) http.HandlerFunc {
 
 		w.Header().Set("Content-Type", "application/json")
 
-		var userNum domain.UserNum
-
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			h.log.Error("Can't read body", op, err)
+		quoted, ok := quoteNumbers(r)
+		if !ok {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxPutBody)
 		defer r.Body.Close()
 
-		err = json.Unmarshal(body, &userNum)
+		userNum, err := usecase.DecodeUserNum(r.Body)
 		if err != nil {
-			h.log.Error("Can't parse body", op, err)
-			w.WriteHeader(http.StatusBadRequest)
+			h.log.DebugContext(r.Context(), "Can't parse body", "op", op, "error", err)
+			writeRequestError(w, err)
 			return
 		}
 
-		err = h.useCase.PutNumber(ctx, userNum.Num)
+		writeCtx, token := reqctx.WithWriteToken(writeContext(ctx, r, userNum))
+		err = h.useCase.PutNumber(writeCtx, userNum.Num)
+		if errors.Is(err, usecase.ErrPending) {
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "accepted-pending"})
+			return
+		}
+		if errors.Is(err, usecase.ErrReadOnly) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		if err != nil {
-			h.log.Error("could not put num", op, err)
+			if writeRequestError(w, err) || writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not put num", "op", op, "error", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		numbers, err := h.useCase.GetSlices(ctx)
+		readCtx := readContext(ctx, r)
+		if lsn := token.LSN(); lsn != "" {
+			w.Header().Set(consistencyHeader, lsn)
+			readCtx = reqctx.WithReadAfter(readCtx, lsn)
+		}
+
+		numbers, err := h.useCase.GetSlices(readCtx, "")
 		if err != nil {
-			h.log.Error("could not get numbers", op, err)
+			if writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not get numbers", "op", op, "error", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		response, err := json.Marshal(numbers)
+		response, err := json.Marshal(numbersResponse(numbers, quoted))
 		if err != nil {
-			h.log.Error("could not marshal response", op, err)
+			h.log.ErrorContext(r.Context(), "could not marshal response", "op", op, "error", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
 		_, err = w.Write(response)
 		if err != nil {
-			h.log.Error("could not write response", op, err)
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 	}
 }
+
+// readContext asks storage to bypass its cache when the client sends
+// Cache-Control: no-cache, and to read at least up to the write a
+// consistency token names. It also carries the request's principal.
+func readContext(ctx context.Context, r *http.Request) context.Context {
+	ctx = withPrincipal(ctx, r)
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = reqctx.WithFreshRead(ctx)
+	}
+	if lsn := r.Header.Get(consistencyHeader); lsn != "" {
+		ctx = reqctx.WithReadAfter(ctx, lsn)
+	}
+	return ctx
+}
+
+// writeContext passes the client's Idempotency-Key header, its principal,
+// the request ID and the number's metadata on to storage.
+func writeContext(ctx context.Context, r *http.Request, userNum domain.UserNum) context.Context {
+	ctx = withPrincipal(ctx, r)
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		ctx = reqctx.WithRequestID(ctx, id)
+	}
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		ctx = reqctx.WithIdempotencyKey(ctx, key)
+	}
+	return reqctx.WithMeta(ctx, domain.Meta{
+		Client: clientIdentity(r),
+		Source: userNum.Source,
+		Labels: userNum.Labels,
+	})
+}
+
+// withPrincipal carries the principal the auth middleware put into the
+// request context over to ctx, which handlers derive from the server's,
+// along with the request's log fields.
+func withPrincipal(ctx context.Context, r *http.Request) context.Context {
+	ctx = logging.InheritFields(ctx, r.Context())
+	if p, ok := reqctx.Principal(r.Context()); ok {
+		return reqctx.WithPrincipal(ctx, p)
+	}
+	return ctx
+}
+
+// clientIdentity names the client that sent r: its authenticated principal,
+// else the X-Client-ID header when set, else its remote address without the
+// port.
+func clientIdentity(r *http.Request) string {
+	if p, ok := reqctx.Principal(r.Context()); ok {
+		return p.ID
+	}
+	if id := r.Header.Get("X-Client-ID"); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeUnavailable answers with 503 and Retry-After when storage cannot be
+// reached, and reports whether it did.
+func writeUnavailable(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, usecase.ErrUnavailable) {
+		return false
+	}
+
+	retryAfter := time.Second
+	var hint interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hint) {
+		retryAfter = max(hint.RetryAfter(), time.Second)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	return true
+}
+
+// writeRequestError answers validation failures with 422 and field errors,
+// a reused idempotency key with 422, oversized bodies with 413 and malformed
+// bodies with 400. It reports whether err was one of those.
+func writeRequestError(w http.ResponseWriter, err error) bool {
+	var (
+		verr    *usecase.ValidationError
+		tooLong *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &verr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(verr)
+		return true
+	case errors.Is(err, domain.ErrIdempotencyMismatch):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return true
+	case errors.As(err, &tooLong):
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "request body too large"})
+		return true
+	case errors.Is(err, usecase.ErrMalformedRequest):
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return true
+	default:
+		return false
+	}
+}
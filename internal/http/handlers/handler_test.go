@@ -5,14 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"testovoe/internal/domain"
 	"testovoe/internal/http/handlers/mocks"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/storage/memory"
+	"testovoe/internal/usecase"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,13 +30,13 @@ func TestHTTPHandler_HandleRequest_Success(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 	mockUseCase.EXPECT().
-		PutNumber(mock.Anything, 42).
+		PutNumber(mock.Anything, domain.NewInt(42)).
 		Return(nil).
 		Once()
 
 	mockUseCase.EXPECT().
-		GetSlices(mock.Anything).
-		Return([]int{1, 2, 42}, nil).
+		GetSlices(mock.Anything, "").
+		Return(ints(1, 2, 42), nil).
 		Once()
 
 	handler := &HTTPHandler{
@@ -38,7 +44,7 @@ func TestHTTPHandler_HandleRequest_Success(t *testing.T) {
 		log:     logger,
 	}
 
-	requestBody := domain.UserNum{Num: 42}
+	requestBody := domain.UserNum{Num: domain.NewInt(42)}
 	body, err := json.Marshal(requestBody)
 	assert.NoError(t, err)
 
@@ -51,10 +57,10 @@ func TestHTTPHandler_HandleRequest_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 
-	var response []int
+	var response []domain.Number
 	err = json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, []int{1, 2, 42}, response)
+	assert.Equal(t, ints(1, 2, 42), response)
 }
 
 func TestHTTPHandler_HandleRequest_PutNumberError(t *testing.T) {
@@ -62,7 +68,7 @@ func TestHTTPHandler_HandleRequest_PutNumberError(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 	mockUseCase.EXPECT().
-		PutNumber(mock.Anything, 42).
+		PutNumber(mock.Anything, domain.NewInt(42)).
 		Return(errors.New("database error")).
 		Once()
 
@@ -71,7 +77,7 @@ func TestHTTPHandler_HandleRequest_PutNumberError(t *testing.T) {
 		log:     logger,
 	}
 
-	requestBody := domain.UserNum{Num: 42}
+	requestBody := domain.UserNum{Num: domain.NewInt(42)}
 	body, _ := json.Marshal(requestBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/handle", bytes.NewBuffer(body))
 	w := httptest.NewRecorder()
@@ -82,17 +88,53 @@ func TestHTTPHandler_HandleRequest_PutNumberError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHTTPHandler_HandleRequest_BodyTooLarge(t *testing.T) {
+	handler := &HTTPHandler{
+		useCase: mocks.NewMockUseCase(t),
+		log:     newTestLogger(),
+	}
+
+	body := `{"num":1` + strings.Repeat(" ", maxPutBody) + `}`
+	req := httptest.NewRequest(http.MethodPost, "/put-num", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestHTTPHandler_HandleRequest_ReadOnly(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Return(usecase.ErrReadOnly).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num": 42}`))
+	w := httptest.NewRecorder()
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "read-only")
+}
+
 func TestHTTPHandler_HandleRequest_GetSlicesError(t *testing.T) {
 	mockUseCase := mocks.NewMockUseCase(t)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 	mockUseCase.EXPECT().
-		PutNumber(mock.Anything, 42).
+		PutNumber(mock.Anything, domain.NewInt(42)).
 		Return(nil).
 		Once()
 
 	mockUseCase.EXPECT().
-		GetSlices(mock.Anything).
+		GetSlices(mock.Anything, "").
 		Return(nil, errors.New("database error")).
 		Once()
 
@@ -101,7 +143,7 @@ func TestHTTPHandler_HandleRequest_GetSlicesError(t *testing.T) {
 		log:     logger,
 	}
 
-	requestBody := domain.UserNum{Num: 42}
+	requestBody := domain.UserNum{Num: domain.NewInt(42)}
 	body, _ := json.Marshal(requestBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/handle", bytes.NewBuffer(body))
 	w := httptest.NewRecorder()
@@ -147,23 +189,23 @@ func TestHTTPHandler_HandleRequest_EmptyBody(t *testing.T) {
 func TestHTTPHandler_HandleRequest_DifferentNumbers(t *testing.T) {
 	testCases := []struct {
 		name           string
-		inputNumber    int
-		expectedSlices []int
+		inputNumber    int64
+		expectedSlices []domain.Number
 	}{
 		{
 			name:           "positive number",
 			inputNumber:    100,
-			expectedSlices: []int{1, 2, 100},
+			expectedSlices: ints(1, 2, 100),
 		},
 		{
 			name:           "zero",
 			inputNumber:    0,
-			expectedSlices: []int{0},
+			expectedSlices: ints(0),
 		},
 		{
 			name:           "negative number",
 			inputNumber:    -5,
-			expectedSlices: []int{-5, -4, -3},
+			expectedSlices: ints(-5, -4, -3),
 		},
 	}
 
@@ -173,12 +215,12 @@ func TestHTTPHandler_HandleRequest_DifferentNumbers(t *testing.T) {
 			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 			mockUseCase.EXPECT().
-				PutNumber(mock.Anything, tc.inputNumber).
+				PutNumber(mock.Anything, domain.NewInt(tc.inputNumber)).
 				Return(nil).
 				Once()
 
 			mockUseCase.EXPECT().
-				GetSlices(mock.Anything).
+				GetSlices(mock.Anything, "").
 				Return(tc.expectedSlices, nil).
 				Once()
 
@@ -187,7 +229,7 @@ func TestHTTPHandler_HandleRequest_DifferentNumbers(t *testing.T) {
 				log:     logger,
 			}
 
-			requestBody := domain.UserNum{Num: tc.inputNumber}
+			requestBody := domain.UserNum{Num: domain.NewInt(tc.inputNumber)}
 			body, _ := json.Marshal(requestBody)
 			req := httptest.NewRequest(http.MethodPost, "/api/handle", bytes.NewBuffer(body))
 			w := httptest.NewRecorder()
@@ -197,7 +239,7 @@ func TestHTTPHandler_HandleRequest_DifferentNumbers(t *testing.T) {
 
 			assert.Equal(t, http.StatusOK, w.Code)
 
-			var response []int
+			var response []domain.Number
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedSlices, response)
@@ -212,16 +254,16 @@ func TestHTTPHandler_HandleRequest_ContextPassed(t *testing.T) {
 	var capturedCtx context.Context
 
 	mockUseCase.EXPECT().
-		PutNumber(mock.Anything, 42).
-		Run(func(ctx context.Context, num int) {
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Run(func(ctx context.Context, num domain.Number) {
 			capturedCtx = ctx
 		}).
 		Return(nil).
 		Once()
 
 	mockUseCase.EXPECT().
-		GetSlices(mock.Anything).
-		Return([]int{42}, nil).
+		GetSlices(mock.Anything, "").
+		Return(ints(42), nil).
 		Once()
 
 	handler := &HTTPHandler{
@@ -229,7 +271,7 @@ func TestHTTPHandler_HandleRequest_ContextPassed(t *testing.T) {
 		log:     logger,
 	}
 
-	requestBody := domain.UserNum{Num: 42}
+	requestBody := domain.UserNum{Num: domain.NewInt(42)}
 	body, _ := json.Marshal(requestBody)
 
 	ctx := context.WithValue(context.Background(), "test-key", "test-value")
@@ -246,3 +288,593 @@ func TestHTTPHandler_HandleRequest_ContextPassed(t *testing.T) {
 func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
+
+func ints(values ...int64) []domain.Number {
+	numbers := make([]domain.Number, len(values))
+	for i, v := range values {
+		numbers[i] = domain.NewInt(v)
+	}
+	return numbers
+}
+
+func TestHTTPHandler_ListNumbers_OrderAndLimit(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		GetSlices(mock.Anything, "desc").
+		Return(ints(4, 3, 2, 1), nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/nums?order=desc&limit=2", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListNumbers(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []domain.Number
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, ints(4, 3), response)
+}
+
+func TestHTTPHandler_ListNumbers_InvalidParams(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{"non-numeric limit", "limit=ten"},
+		{"negative limit", "limit=-1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &HTTPHandler{
+				useCase: mocks.NewMockUseCase(t),
+				log:     newTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/nums?"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListNumbers(context.Background())(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestHTTPHandler_ListNumbers_UnknownOrder(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		GetSlices(mock.Anything, "sideways").
+		Return(nil, fmt.Errorf("%w %q", usecase.ErrUnknownOrder, "sideways")).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/nums?order=sideways", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListNumbers(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown order")
+}
+
+func TestHTTPHandler_ListNumbers_MetadataFilters(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	meta := &domain.Meta{CreatedAt: since.Add(time.Hour), Client: "10.0.0.1", Labels: map[string]string{"env": "prod"}}
+
+	mockUseCase.EXPECT().
+		FindRecords(mock.Anything, domain.RecordQuery{
+			Order:  "desc",
+			Since:  since,
+			Labels: map[string]string{"env": "prod"},
+			Limit:  5,
+		}).
+		Return([]domain.Record{{ID: 7, Num: domain.NewInt(3), Meta: meta}}, nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/nums?order=desc&limit=5&meta=true&label.env=prod&since=2026-10-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListNumbers(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id": 7, "num": 3, "meta": {"created_at": "2026-10-01T01:00:00Z",
+		"client": "10.0.0.1", "labels": {"env": "prod"}}}]`, w.Body.String())
+}
+
+func TestHTTPHandler_ListNumbers_InvalidFilters(t *testing.T) {
+	for _, query := range []string{"since=yesterday", "meta=maybe", "label.bad%20key=x"} {
+		t.Run(query, func(t *testing.T) {
+			handler := &HTTPHandler{
+				useCase: mocks.NewMockUseCase(t),
+				log:     newTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/nums?"+query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListNumbers(context.Background())(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestHTTPHandler_HandleRequest_StoresMetadata(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	var meta domain.Meta
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(1)).
+		Run(func(ctx context.Context, _ domain.Number) { meta, _ = reqctx.Meta(ctx) }).
+		Return(nil).
+		Once()
+	mockUseCase.EXPECT().GetSlices(mock.Anything, "").Return(ints(1), nil).Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num",
+		bytes.NewBufferString(`{"num": 1, "source": "sensor", "labels": {"env": "prod"}}`))
+	req.RemoteAddr = "192.0.2.1:51234"
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.Meta{Client: "192.0.2.1", Source: "sensor", Labels: map[string]string{"env": "prod"}}, meta)
+}
+
+func TestHTTPHandler_HandleRequest_PassesPrincipal(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+	principal := domain.Principal{ID: "k1", Scopes: []string{domain.ScopeWrite}}
+
+	var put, read context.Context
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(1)).
+		Run(func(ctx context.Context, _ domain.Number) { put = ctx }).
+		Return(nil).
+		Once()
+	mockUseCase.EXPECT().
+		GetSlices(mock.Anything, "").
+		Run(func(ctx context.Context, _ string) { read = ctx }).
+		Return(ints(1), nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num": 1}`))
+	req = req.WithContext(reqctx.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	got, _ := reqctx.Principal(put)
+	assert.Equal(t, principal, got)
+	meta, _ := reqctx.Meta(put)
+	assert.Equal(t, "k1", meta.Client)
+	got, _ = reqctx.Principal(read)
+	assert.Equal(t, principal, got)
+}
+
+func TestHTTPHandler_Stats_FreshRead(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		Stats(mock.MatchedBy(reqctx.FreshRead)).
+		Return(domain.Stats{}, nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	w := httptest.NewRecorder()
+
+	handler.Stats(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+type retryAfterError struct{}
+
+func (retryAfterError) Error() string             { return "circuit breaker is open" }
+func (retryAfterError) Unwrap() error             { return usecase.ErrUnavailable }
+func (retryAfterError) RetryAfter() time.Duration { return 2500 * time.Millisecond }
+
+func TestHTTPHandler_StorageUnavailable(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(1)).
+		Return(retryAfterError{}).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num":1}`))
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+}
+
+func TestHTTPHandler_Range(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	lo := domain.NewInt(2)
+	mockUseCase.EXPECT().
+		Range(mock.Anything, usecase.RangeQuery{Min: &lo, Limit: 3}).
+		Return(ints(2, 3, 5), nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/nums/range?min=2&limit=3", nil)
+	w := httptest.NewRecorder()
+
+	handler.Range(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[2,3,5]`, w.Body.String())
+}
+
+func TestHTTPHandler_Rank(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		Rank(mock.Anything, domain.NewInt(10)).
+		Return(4, 9, nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/nums/rank?num=10", nil)
+	w := httptest.NewRecorder()
+
+	handler.Rank(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"num":10,"rank":4,"total":9}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.Rank(context.Background())(w, httptest.NewRequest(http.MethodGet, "/nums/rank?num=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHTTPHandler_Stats_Success(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	stats := domain.Stats{
+		Count: 3,
+		Min:   domain.NewInt(1),
+		Max:   domain.NewInt(5),
+		Sum:   domain.NewInt(9),
+		Mean:  domain.MustParseNumber("3.0000000000000000"),
+	}
+	mockUseCase.EXPECT().
+		Stats(mock.Anything).
+		Return(stats, nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+
+	handler.Stats(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response domain.Stats
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, stats, response)
+}
+
+func TestHTTPHandler_Stats_QuotedNumbers(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		Stats(mock.Anything).
+		Return(domain.Stats{
+			Count: 1,
+			Min:   domain.MustParseNumber("123456789012345678901234567890"),
+			Max:   domain.MustParseNumber("123456789012345678901234567890"),
+			Sum:   domain.MustParseNumber("123456789012345678901234567890"),
+			Mean:  domain.MustParseNumber("123456789012345678901234567890.5"),
+		}, nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats?num_format=string", nil)
+	w := httptest.NewRecorder()
+
+	handler.Stats(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"count": 1,
+		"min": "123456789012345678901234567890",
+		"max": "123456789012345678901234567890",
+		"sum": "123456789012345678901234567890",
+		"mean": "123456789012345678901234567890.5"
+	}`, w.Body.String())
+}
+
+func TestHTTPHandler_Stats_Error(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		Stats(mock.Anything).
+		Return(domain.Stats{}, errors.New("database error")).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+
+	handler.Stats(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func BenchmarkHTTPHandler_HandleRequest(b *testing.B) {
+	for _, size := range []int{0, 1_000, 10_000} {
+		storage := memory.New()
+		for i := range size {
+			_, _ = storage.PutNumber(context.Background(), domain.NewInt(int64(i)))
+		}
+
+		handler := &HTTPHandler{
+			useCase: usecase.NewUseCase(newTestLogger(), storage),
+			log:     newTestLogger(),
+		}
+		handlerFunc := handler.HandleRequest(context.Background())
+		body, _ := json.Marshal(domain.UserNum{Num: domain.NewInt(42)})
+
+		b.Run(fmt.Sprintf("stored=%d", size), func(b *testing.B) {
+			for b.Loop() {
+				req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewReader(body))
+				w := httptest.NewRecorder()
+
+				handlerFunc(w, req)
+
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPHandler_HandleRequest_ValidationErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"not a number", `{"num": "seven"}`},
+		{"unknown field", `{"num": 1, "extra": true}`},
+		{"missing", `{}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &HTTPHandler{
+				useCase: mocks.NewMockUseCase(t),
+				log:     newTestLogger(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+
+			handler.HandleRequest(context.Background())(w, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+			var response usecase.ValidationError
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, response.Errors)
+		})
+	}
+}
+
+func TestHTTPHandler_HandleRequest_PolicyViolation(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(13)).
+		Return(&usecase.ValidationError{Errors: []usecase.FieldError{{Field: "num", Message: "is not allowed"}}}).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num": 13}`))
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"errors":[{"field":"num","message":"is not allowed"}]}`, w.Body.String())
+}
+
+func TestHTTPHandler_HandleRequest_AcceptedPending(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.MatchedBy(func(ctx context.Context) bool {
+			key, ok := reqctx.IdempotencyKey(ctx)
+			return ok && key == "abc"
+		}), domain.NewInt(1)).
+		Return(fmt.Errorf("spool.PutNumber: %w", usecase.ErrPending)).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num":1}`))
+	req.Header.Set("Idempotency-Key", "abc")
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"status":"accepted-pending"}`, w.Body.String())
+}
+
+func TestHTTPHandler_HandleRequest_IdempotencyKeyReused(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(2)).
+		Return(fmt.Errorf("storage.PutNumber: %w", domain.ErrIdempotencyMismatch)).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num":2}`))
+	req.Header.Set("Idempotency-Key", "abc")
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency key was already used")
+}
+
+func TestHTTPHandler_Health(t *testing.T) {
+	handler := &HTTPHandler{log: newTestLogger()}
+	handler.AddHealthCheck("spool", func(context.Context) (any, error) {
+		return map[string]int{"depth": 3}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.Health(context.Background())(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{"spool":{"status":"ok","detail":{"depth":3}}}}`, w.Body.String())
+
+	handler.AddHealthCheck("database", func(context.Context) (any, error) {
+		return "open", errors.New("connection refused")
+	})
+
+	w = httptest.NewRecorder()
+	handler.Health(context.Background())(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"database":{"status":"fail","detail":"open","error":"connection refused"}`)
+}
+
+func TestHTTPHandler_HandleRequest_ReadsOwnWrite(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+
+	mockUseCase.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(5)).
+		Run(func(ctx context.Context, _ domain.Number) { reqctx.RecordWrite(ctx, "0/16B3748") }).
+		Return(nil).
+		Once()
+
+	mockUseCase.EXPECT().
+		GetSlices(mock.MatchedBy(func(ctx context.Context) bool {
+			lsn, ok := reqctx.ReadAfter(ctx)
+			return ok && lsn == "0/16B3748"
+		}), "").
+		Return(ints(5), nil).
+		Once()
+
+	handler := &HTTPHandler{
+		useCase: mockUseCase,
+		log:     newTestLogger(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/put-num", bytes.NewBufferString(`{"num":5}`))
+	w := httptest.NewRecorder()
+
+	handler.HandleRequest(context.Background())(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0/16B3748", w.Header().Get("X-Consistency-Token"))
+}
+
+func TestHTTPHandler_Watch_EndsWhenStreamsClose(t *testing.T) {
+	mockUseCase := mocks.NewMockUseCase(t)
+	numbers := make(chan domain.Number)
+	mockUseCase.EXPECT().Subscribe().Return(numbers, func() {}).Once()
+
+	handler := NewHTTPHandler(newTestLogger(), mockUseCase)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodGet, "/nums/watch", nil)
+		handler.Watch(context.Background())(httptest.NewRecorder(), req)
+	}()
+
+	handler.CloseStreams()
+	handler.CloseStreams()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream still open after CloseStreams")
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HealthCheck reports details about one component. An error marks the
+// service unhealthy.
+type HealthCheck func(ctx context.Context) (any, error)
+
+type healthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+type componentHealth struct {
+	Status string `json:"status"`
+	Detail any    `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                     `json:"status"`
+	Checks map[string]componentHealth `json:"checks"`
+}
+
+// AddHealthCheck registers a check reported by Health. Checks must be added
+// before the server starts.
+func (h *HTTPHandler) AddHealthCheck(name string, check HealthCheck) {
+	h.health = append(h.health, healthCheck{name: name, check: check})
+}
+
+// Health answers GET /healthz with the result of every registered check,
+// and 503 if any of them failed.
+func (h *HTTPHandler) Health(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		resp := healthResponse{Status: "ok", Checks: make(map[string]componentHealth, len(h.health))}
+		for _, c := range h.health {
+			detail, err := c.check(ctx)
+			component := componentHealth{Status: "ok", Detail: detail}
+			if err != nil {
+				component.Status, component.Error = "fail", err.Error()
+				resp.Status = "fail"
+			}
+			resp.Checks[c.name] = component
+		}
+
+		if resp.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
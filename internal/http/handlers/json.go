@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testovoe/internal/domain"
+)
+
+const (
+	numFormatNumber = "number"
+	numFormatString = "string"
+)
+
+// jsonNumber writes a number either as a JSON number literal or, for
+// clients whose JSON parsers lose precision on large values, as a string.
+// Both forms carry every digit.
+type jsonNumber struct {
+	num    domain.Number
+	quoted bool
+}
+
+func (n jsonNumber) MarshalJSON() ([]byte, error) {
+	if n.quoted {
+		return []byte(strconv.Quote(n.num.String())), nil
+	}
+	return n.num.MarshalJSON()
+}
+
+type statsResponse struct {
+	Count int64      `json:"count"`
+	Min   jsonNumber `json:"min"`
+	Max   jsonNumber `json:"max"`
+	Sum   jsonNumber `json:"sum"`
+	Mean  jsonNumber `json:"mean"`
+}
+
+type recordResponse struct {
+	ID   int64        `json:"id"`
+	Num  jsonNumber   `json:"num"`
+	Meta *domain.Meta `json:"meta"`
+}
+
+type userNumResponse struct {
+	Num jsonNumber `json:"num"`
+}
+
+// quoteNumbers reads the num_format query parameter.
+func quoteNumbers(r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("num_format") {
+	case "", numFormatNumber:
+		return false, true
+	case numFormatString:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+func numbersResponse(numbers []domain.Number, quoted bool) []jsonNumber {
+	resp := make([]jsonNumber, len(numbers))
+	for i, num := range numbers {
+		resp[i] = jsonNumber{num: num, quoted: quoted}
+	}
+	return resp
+}
+
+func newStatsResponse(stats domain.Stats, quoted bool) statsResponse {
+	return statsResponse{
+		Count: stats.Count,
+		Min:   jsonNumber{num: stats.Min, quoted: quoted},
+		Max:   jsonNumber{num: stats.Max, quoted: quoted},
+		Sum:   jsonNumber{num: stats.Sum, quoted: quoted},
+		Mean:  jsonNumber{num: stats.Mean, quoted: quoted},
+	}
+}
+
+func recordsResponse(records []domain.Record, quoted bool) []recordResponse {
+	resp := make([]recordResponse, len(records))
+	for i, rec := range records {
+		resp[i] = recordResponse{ID: rec.ID, Num: jsonNumber{num: rec.Num, quoted: quoted}, Meta: rec.Meta}
+	}
+	return resp
+}
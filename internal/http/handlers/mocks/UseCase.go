@@ -4,6 +4,9 @@ package mocks
 
 import (
 	context "context"
+	domain "testovoe/internal/domain"
+
+	usecase "testovoe/internal/usecase"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -21,29 +24,88 @@ func (_m *MockUseCase) EXPECT() *MockUseCase_Expecter {
 	return &MockUseCase_Expecter{mock: &_m.Mock}
 }
 
-// GetSlices provides a mock function with given fields: ctx
-func (_m *MockUseCase) GetSlices(ctx context.Context) ([]int, error) {
-	ret := _m.Called(ctx)
+// FindRecords provides a mock function with given fields: ctx, q
+func (_m *MockUseCase) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindRecords")
+	}
+
+	var r0 []domain.Record
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RecordQuery) ([]domain.Record, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RecordQuery) []domain.Record); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Record)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.RecordQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUseCase_FindRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindRecords'
+type MockUseCase_FindRecords_Call struct {
+	*mock.Call
+}
+
+// FindRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - q domain.RecordQuery
+func (_e *MockUseCase_Expecter) FindRecords(ctx interface{}, q interface{}) *MockUseCase_FindRecords_Call {
+	return &MockUseCase_FindRecords_Call{Call: _e.mock.On("FindRecords", ctx, q)}
+}
+
+func (_c *MockUseCase_FindRecords_Call) Run(run func(ctx context.Context, q domain.RecordQuery)) *MockUseCase_FindRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.RecordQuery))
+	})
+	return _c
+}
+
+func (_c *MockUseCase_FindRecords_Call) Return(_a0 []domain.Record, _a1 error) *MockUseCase_FindRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUseCase_FindRecords_Call) RunAndReturn(run func(context.Context, domain.RecordQuery) ([]domain.Record, error)) *MockUseCase_FindRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSlices provides a mock function with given fields: ctx, order
+func (_m *MockUseCase) GetSlices(ctx context.Context, order string) ([]domain.Number, error) {
+	ret := _m.Called(ctx, order)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetSlices")
 	}
 
-	var r0 []int
+	var r0 []domain.Number
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]int, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.Number, error)); ok {
+		return rf(ctx, order)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []int); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.Number); ok {
+		r0 = rf(ctx, order)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]int)
+			r0 = ret.Get(0).([]domain.Number)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, order)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,38 +120,39 @@ type MockUseCase_GetSlices_Call struct {
 
 // GetSlices is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockUseCase_Expecter) GetSlices(ctx interface{}) *MockUseCase_GetSlices_Call {
-	return &MockUseCase_GetSlices_Call{Call: _e.mock.On("GetSlices", ctx)}
+//   - order string
+func (_e *MockUseCase_Expecter) GetSlices(ctx interface{}, order interface{}) *MockUseCase_GetSlices_Call {
+	return &MockUseCase_GetSlices_Call{Call: _e.mock.On("GetSlices", ctx, order)}
 }
 
-func (_c *MockUseCase_GetSlices_Call) Run(run func(ctx context.Context)) *MockUseCase_GetSlices_Call {
+func (_c *MockUseCase_GetSlices_Call) Run(run func(ctx context.Context, order string)) *MockUseCase_GetSlices_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockUseCase_GetSlices_Call) Return(_a0 []int, _a1 error) *MockUseCase_GetSlices_Call {
+func (_c *MockUseCase_GetSlices_Call) Return(_a0 []domain.Number, _a1 error) *MockUseCase_GetSlices_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockUseCase_GetSlices_Call) RunAndReturn(run func(context.Context) ([]int, error)) *MockUseCase_GetSlices_Call {
+func (_c *MockUseCase_GetSlices_Call) RunAndReturn(run func(context.Context, string) ([]domain.Number, error)) *MockUseCase_GetSlices_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PutNumber provides a mock function with given fields: ctx, num
-func (_m *MockUseCase) PutNumber(ctx context.Context, num int) error {
-	ret := _m.Called(ctx, num)
+// PutNumber provides a mock function with given fields: ctx, number
+func (_m *MockUseCase) PutNumber(ctx context.Context, number domain.Number) error {
+	ret := _m.Called(ctx, number)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PutNumber")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, num)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Number) error); ok {
+		r0 = rf(ctx, number)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -104,14 +167,14 @@ type MockUseCase_PutNumber_Call struct {
 
 // PutNumber is a helper method to define mock.On call
 //   - ctx context.Context
-//   - num int
-func (_e *MockUseCase_Expecter) PutNumber(ctx interface{}, num interface{}) *MockUseCase_PutNumber_Call {
-	return &MockUseCase_PutNumber_Call{Call: _e.mock.On("PutNumber", ctx, num)}
+//   - number domain.Number
+func (_e *MockUseCase_Expecter) PutNumber(ctx interface{}, number interface{}) *MockUseCase_PutNumber_Call {
+	return &MockUseCase_PutNumber_Call{Call: _e.mock.On("PutNumber", ctx, number)}
 }
 
-func (_c *MockUseCase_PutNumber_Call) Run(run func(ctx context.Context, num int)) *MockUseCase_PutNumber_Call {
+func (_c *MockUseCase_PutNumber_Call) Run(run func(ctx context.Context, number domain.Number)) *MockUseCase_PutNumber_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int))
+		run(args[0].(context.Context), args[1].(domain.Number))
 	})
 	return _c
 }
@@ -121,7 +184,245 @@ func (_c *MockUseCase_PutNumber_Call) Return(_a0 error) *MockUseCase_PutNumber_C
 	return _c
 }
 
-func (_c *MockUseCase_PutNumber_Call) RunAndReturn(run func(context.Context, int) error) *MockUseCase_PutNumber_Call {
+func (_c *MockUseCase_PutNumber_Call) RunAndReturn(run func(context.Context, domain.Number) error) *MockUseCase_PutNumber_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Range provides a mock function with given fields: ctx, q
+func (_m *MockUseCase) Range(ctx context.Context, q usecase.RangeQuery) ([]domain.Number, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Range")
+	}
+
+	var r0 []domain.Number
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.RangeQuery) ([]domain.Number, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.RangeQuery) []domain.Number); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Number)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, usecase.RangeQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUseCase_Range_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Range'
+type MockUseCase_Range_Call struct {
+	*mock.Call
+}
+
+// Range is a helper method to define mock.On call
+//   - ctx context.Context
+//   - q usecase.RangeQuery
+func (_e *MockUseCase_Expecter) Range(ctx interface{}, q interface{}) *MockUseCase_Range_Call {
+	return &MockUseCase_Range_Call{Call: _e.mock.On("Range", ctx, q)}
+}
+
+func (_c *MockUseCase_Range_Call) Run(run func(ctx context.Context, q usecase.RangeQuery)) *MockUseCase_Range_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(usecase.RangeQuery))
+	})
+	return _c
+}
+
+func (_c *MockUseCase_Range_Call) Return(_a0 []domain.Number, _a1 error) *MockUseCase_Range_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUseCase_Range_Call) RunAndReturn(run func(context.Context, usecase.RangeQuery) ([]domain.Number, error)) *MockUseCase_Range_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Rank provides a mock function with given fields: ctx, num
+func (_m *MockUseCase) Rank(ctx context.Context, num domain.Number) (int, int, error) {
+	ret := _m.Called(ctx, num)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rank")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Number) (int, int, error)); ok {
+		return rf(ctx, num)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Number) int); ok {
+		r0 = rf(ctx, num)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Number) int); ok {
+		r1 = rf(ctx, num)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.Number) error); ok {
+		r2 = rf(ctx, num)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockUseCase_Rank_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rank'
+type MockUseCase_Rank_Call struct {
+	*mock.Call
+}
+
+// Rank is a helper method to define mock.On call
+//   - ctx context.Context
+//   - num domain.Number
+func (_e *MockUseCase_Expecter) Rank(ctx interface{}, num interface{}) *MockUseCase_Rank_Call {
+	return &MockUseCase_Rank_Call{Call: _e.mock.On("Rank", ctx, num)}
+}
+
+func (_c *MockUseCase_Rank_Call) Run(run func(ctx context.Context, num domain.Number)) *MockUseCase_Rank_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Number))
+	})
+	return _c
+}
+
+func (_c *MockUseCase_Rank_Call) Return(rank int, total int, err error) *MockUseCase_Rank_Call {
+	_c.Call.Return(rank, total, err)
+	return _c
+}
+
+func (_c *MockUseCase_Rank_Call) RunAndReturn(run func(context.Context, domain.Number) (int, int, error)) *MockUseCase_Rank_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function with given fields: ctx
+func (_m *MockUseCase) Stats(ctx context.Context) (domain.Stats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 domain.Stats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (domain.Stats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) domain.Stats); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(domain.Stats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUseCase_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type MockUseCase_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockUseCase_Expecter) Stats(ctx interface{}) *MockUseCase_Stats_Call {
+	return &MockUseCase_Stats_Call{Call: _e.mock.On("Stats", ctx)}
+}
+
+func (_c *MockUseCase_Stats_Call) Run(run func(ctx context.Context)) *MockUseCase_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockUseCase_Stats_Call) Return(_a0 domain.Stats, _a1 error) *MockUseCase_Stats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUseCase_Stats_Call) RunAndReturn(run func(context.Context) (domain.Stats, error)) *MockUseCase_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Subscribe provides a mock function with no fields
+func (_m *MockUseCase) Subscribe() (<-chan domain.Number, func()) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 <-chan domain.Number
+	var r1 func()
+	if rf, ok := ret.Get(0).(func() (<-chan domain.Number, func())); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() <-chan domain.Number); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan domain.Number)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() func()); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	return r0, r1
+}
+
+// MockUseCase_Subscribe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Subscribe'
+type MockUseCase_Subscribe_Call struct {
+	*mock.Call
+}
+
+// Subscribe is a helper method to define mock.On call
+func (_e *MockUseCase_Expecter) Subscribe() *MockUseCase_Subscribe_Call {
+	return &MockUseCase_Subscribe_Call{Call: _e.mock.On("Subscribe")}
+}
+
+func (_c *MockUseCase_Subscribe_Call) Run(run func()) *MockUseCase_Subscribe_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockUseCase_Subscribe_Call) Return(_a0 <-chan domain.Number, _a1 func()) *MockUseCase_Subscribe_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUseCase_Subscribe_Call) RunAndReturn(run func() (<-chan domain.Number, func())) *MockUseCase_Subscribe_Call {
 	_c.Call.Return(run)
 	return _c
 }
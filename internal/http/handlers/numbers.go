@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testovoe/internal/domain"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+func (h *HTTPHandler) ListNumbers(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.ListNumbers"
+
+		w.Header().Set("Content-Type", "application/json")
+
+		quoted, ok := quoteNumbers(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		limit := 0
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			var err error
+			limit, err = strconv.Atoi(rawLimit)
+			if err != nil || limit < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		q, withMeta, filtered, err := recordQuery(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if withMeta || filtered {
+			q.Limit = limit
+			h.listRecords(readContext(ctx, r), w, q, withMeta, quoted)
+			return
+		}
+
+		numbers, err := h.useCase.GetSlices(readContext(ctx, r), r.URL.Query().Get("order"))
+		if errors.Is(err, usecase.ErrUnknownOrder) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			if writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not get numbers", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if limit > 0 && limit < len(numbers) {
+			numbers = numbers[:limit]
+		}
+		err = json.NewEncoder(w).Encode(numbersResponse(numbers, quoted))
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+// listRecords answers ListNumbers requests that filter by metadata or ask
+// for it with meta=true.
+func (h *HTTPHandler) listRecords(ctx context.Context, w http.ResponseWriter, q domain.RecordQuery, withMeta, quoted bool) {
+	const op = "handlers.ListNumbers"
+
+	records, err := h.useCase.FindRecords(ctx, q)
+	if errors.Is(err, usecase.ErrUnknownOrder) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		if writeUnavailable(w, err) {
+			return
+		}
+		h.log.ErrorContext(ctx, "could not find records", "op", op, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var resp any
+	if withMeta {
+		resp = recordsResponse(records, quoted)
+	} else {
+		numbers := make([]domain.Number, len(records))
+		for i, rec := range records {
+			numbers[i] = rec.Num
+		}
+		resp = numbersResponse(numbers, quoted)
+	}
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		h.log.ErrorContext(ctx, "could not write response", "op", op, "error", err)
+	}
+}
+
+// recordQuery reads the metadata filters of a ListNumbers request:
+// since and until as RFC 3339 times, client, source and any number of
+// label.<key>=<value>. It also reports whether meta=true asked for metadata
+// in the response and whether any filter was given.
+func recordQuery(r *http.Request) (q domain.RecordQuery, withMeta, filtered bool, err error) {
+	query := r.URL.Query()
+	q.Order = query.Get("order")
+
+	if raw := query.Get("meta"); raw != "" {
+		withMeta, err = strconv.ParseBool(raw)
+		if err != nil {
+			return q, false, false, fmt.Errorf("meta: %w", err)
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		*dst, err = time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return q, false, false, fmt.Errorf("%s: must be an RFC 3339 time", name)
+		}
+		filtered = true
+	}
+
+	q.Client, q.Source = query.Get("client"), query.Get("source")
+	filtered = filtered || q.Client != "" || q.Source != ""
+
+	for name, values := range query {
+		key, ok := strings.CutPrefix(name, "label.")
+		if !ok {
+			continue
+		}
+		if !usecase.ValidLabelKey(key) {
+			return q, false, false, fmt.Errorf("%s: invalid label key", name)
+		}
+		if q.Labels == nil {
+			q.Labels = make(map[string]string)
+		}
+		q.Labels[key] = values[0]
+		filtered = true
+	}
+
+	return q, withMeta, filtered, nil
+}
+
+func (h *HTTPHandler) Stats(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Stats"
+
+		w.Header().Set("Content-Type", "application/json")
+
+		quoted, ok := quoteNumbers(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		stats, err := h.useCase.Stats(readContext(ctx, r))
+		if err != nil {
+			if writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not get stats", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(newStatsResponse(stats, quoted))
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Flusher drops every cached read.
+type Flusher interface {
+	Flush()
+}
+
+// ReadOnlySwitch turns writes off and back on.
+type ReadOnlySwitch interface {
+	ReadOnly() bool
+	SetReadOnly(on bool)
+}
+
+// Job is a background task an operator may run at once. Its result is
+// returned to the caller.
+type Job func(ctx context.Context) (any, error)
+
+// SetLogLevel lets the admin API change level at runtime.
+func (h *AdminHandler) SetLogLevel(level *slog.LevelVar) {
+	h.level = level
+}
+
+func (h *AdminHandler) SetCache(cache Flusher) {
+	h.cache = cache
+}
+
+func (h *AdminHandler) SetReadOnlySwitch(readOnly ReadOnlySwitch) {
+	h.readOnly = readOnly
+}
+
+// AddStats reports the result of fn under name in the stats dump. Like the
+// rest of the setters, it must be called before serving requests.
+func (h *AdminHandler) AddStats(name string, fn func() any) {
+	h.stats[name] = fn
+}
+
+func (h *AdminHandler) AddJob(name string, job Job) {
+	h.jobs[name] = job
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+func (h *AdminHandler) LogLevel(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.level == nil {
+			http.NotFound(w, r)
+			return
+		}
+		h.writeJSON(w, r, logLevel{Level: h.level.Level().String()})
+	}
+}
+
+// UpdateLogLevel sets the level in the body until the next restart or a
+// config reload that changes log.level.
+func (h *AdminHandler) UpdateLogLevel(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.UpdateLogLevel"
+
+		if h.level == nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer r.Body.Close()
+
+		var body logLevel
+		var level slog.Level
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err == nil {
+			err = level.UnmarshalText([]byte(body.Level))
+		}
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h.level.Set(level)
+		h.log.InfoContext(r.Context(), "log level changed", "op", op, "level", level)
+		h.writeJSON(w, r, logLevel{Level: level.String()})
+	}
+}
+
+// ConfigDump returns the running config with secrets redacted, as YAML that
+// could be used as a config file.
+func (h *AdminHandler) ConfigDump(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.ConfigDump"
+
+		if h.config == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		err := enc.Encode(h.config.Current().Redacted())
+		if err == nil {
+			err = enc.Close()
+		}
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+// Stats returns every registered stat, or only the one named by the name
+// URL parameter.
+func (h *AdminHandler) Stats(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if name := chi.URLParam(r, "name"); name != "" {
+			fn, ok := h.stats[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			h.writeJSON(w, r, fn())
+			return
+		}
+
+		out := make(map[string]any, len(h.stats))
+		for name, fn := range h.stats {
+			out[name] = fn()
+		}
+		h.writeJSON(w, r, out)
+	}
+}
+
+func (h *AdminHandler) FlushCache(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.FlushCache"
+
+		if h.cache == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		h.cache.Flush()
+		h.log.InfoContext(r.Context(), "cache flushed", "op", op)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Jobs lists the jobs RunJob accepts.
+func (h *AdminHandler) Jobs(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.writeJSON(w, r, map[string][]string{"jobs": slices.Sorted(maps.Keys(h.jobs))})
+	}
+}
+
+// RunJob runs the job named by the name URL parameter and waits for it.
+func (h *AdminHandler) RunJob(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.RunJob"
+
+		name := chi.URLParam(r, "name")
+		job, ok := h.jobs[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		h.log.InfoContext(r.Context(), "running job", "op", op, "job", name)
+		result, err := job(ctx)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "job failed", "op", op, "job", name, "error", err)
+			writeAdminError(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.writeJSON(w, r, map[string]any{"job": name, "result": result})
+	}
+}
+
+var errEnabledRequired = errors.New("enabled is required")
+
+type readOnlyMode struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (h *AdminHandler) ReadOnly(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.readOnly == nil {
+			http.NotFound(w, r)
+			return
+		}
+		on := h.readOnly.ReadOnly()
+		h.writeJSON(w, r, readOnlyMode{Enabled: &on})
+	}
+}
+
+// UpdateReadOnly switches writes off or on, as {"enabled": true} asks. The
+// mode is not persisted; a restart serves writes again.
+func (h *AdminHandler) UpdateReadOnly(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.UpdateReadOnly"
+
+		if h.readOnly == nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer r.Body.Close()
+
+		var body readOnlyMode
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&body)
+		if err == nil && body.Enabled == nil {
+			err = errEnabledRequired
+		}
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h.readOnly.SetReadOnly(*body.Enabled)
+		h.log.WarnContext(r.Context(), "read-only mode changed", "op", op, "enabled", *body.Enabled)
+		h.writeJSON(w, r, body)
+	}
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "could not write response", "error", err)
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testovoe/internal/domain"
+	"testovoe/internal/usecase"
+)
+
+type rankResponse struct {
+	Num   jsonNumber `json:"num"`
+	Rank  int        `json:"rank"`
+	Total int        `json:"total"`
+}
+
+// Rank answers GET /nums/rank?num=X with how many stored numbers are below X.
+func (h *HTTPHandler) Rank(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Rank"
+
+		w.Header().Set("Content-Type", "application/json")
+
+		quoted, ok := quoteNumbers(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		num, err := domain.ParseNumber(r.URL.Query().Get("num"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		rank, total, err := h.useCase.Rank(readContext(ctx, r), num)
+		if err != nil {
+			if writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not rank number", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(rankResponse{Num: jsonNumber{num, quoted}, Rank: rank, Total: total})
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
+
+// Range answers GET /nums/range?min=&max=&offset=&limit= with the stored
+// numbers between min and max inclusive, ascending.
+func (h *HTTPHandler) Range(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Range"
+
+		w.Header().Set("Content-Type", "application/json")
+
+		quoted, ok := quoteNumbers(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var q usecase.RangeQuery
+		for _, p := range []struct {
+			name string
+			dst  **domain.Number
+		}{{"min", &q.Min}, {"max", &q.Max}} {
+			raw := r.URL.Query().Get(p.name)
+			if raw == "" {
+				continue
+			}
+			num, err := domain.ParseNumber(raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			*p.dst = &num
+		}
+		for _, p := range []struct {
+			name string
+			dst  *int
+		}{{"offset", &q.Offset}, {"limit", &q.Limit}} {
+			raw := r.URL.Query().Get(p.name)
+			if raw == "" {
+				continue
+			}
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			*p.dst = v
+		}
+
+		numbers, err := h.useCase.Range(readContext(ctx, r), q)
+		if err != nil {
+			if writeUnavailable(w, err) {
+				return
+			}
+			h.log.ErrorContext(r.Context(), "could not get range", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(numbersResponse(numbers, quoted))
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "could not write response", "op", op, "error", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Watch streams every newly stored number as a server-sent event.
+func (h *HTTPHandler) Watch(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Watch"
+
+		// The stream outlives the server write timeout, so lift it.
+		rc := http.NewResponseController(w)
+		err := rc.SetWriteDeadline(time.Time{})
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			h.log.ErrorContext(r.Context(), "could not reset write deadline", "op", op, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		quoted, ok := quoteNumbers(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		numbers, unsubscribe := h.useCase.Subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.Context().Done():
+				return
+			case <-h.streams:
+				return
+			case num, ok := <-numbers:
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(userNumResponse{Num: jsonNumber{num: num, quoted: quoted}})
+				if err != nil {
+					h.log.ErrorContext(r.Context(), "could not marshal event", "op", op, "error", err)
+					return
+				}
+
+				_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+				if err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
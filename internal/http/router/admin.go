@@ -0,0 +1,43 @@
+package router
+
+import (
+	"context"
+	"expvar"
+	"net/http/pprof"
+	"testovoe/internal/auth"
+	"testovoe/internal/http/handlers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Admin serves the operator API, pprof and expvar for the admin server.
+// Every route requires the admin token.
+func Admin(ctx context.Context, router *chi.Mux, h *handlers.AdminHandler, token string) {
+	router.Group(func(r chi.Router) {
+		r.Use(auth.RequireToken(token))
+
+		r.Get("/log/level", h.LogLevel(ctx))
+		r.Put("/log/level", h.UpdateLogLevel(ctx))
+		r.Get("/config", h.ConfigDump(ctx))
+		r.Get("/config/version", h.ConfigVersion(ctx))
+		r.Get("/stats", h.Stats(ctx))
+		r.Get("/stats/{name}", h.Stats(ctx))
+		r.Post("/cache/flush", h.FlushCache(ctx))
+		r.Get("/jobs", h.Jobs(ctx))
+		r.Post("/jobs/{name}", h.RunJob(ctx))
+		r.Get("/read-only", h.ReadOnly(ctx))
+		r.Put("/read-only", h.UpdateReadOnly(ctx))
+		r.Get("/limits", h.RateLimits(ctx))
+		r.Put("/limits", h.UpdateRateLimits(ctx))
+		r.Get("/audit", h.Audit(ctx))
+		r.Get("/audit/verify", h.VerifyAudit(ctx))
+
+		r.Handle("/debug/vars", expvar.Handler())
+		r.HandleFunc("/debug/pprof/", pprof.Index)
+		r.HandleFunc("/debug/pprof/{profile}", pprof.Index)
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	})
+}
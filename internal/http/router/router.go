@@ -2,11 +2,63 @@ package router
 
 import (
 	"context"
+	"log/slog"
+	"net/http"
+	"testovoe/internal/auth"
+	"testovoe/internal/domain"
+	"testovoe/internal/features"
 	"testovoe/internal/http/handlers"
+	"testovoe/internal/ratelimit"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func Router(ctx context.Context, router *chi.Mux, http *handlers.HTTPHandler) {
-	router.Post("/put-num", http.HandleRequest(ctx))
+// Options holds the optional parts of the API; nil fields are left out.
+type Options struct {
+	Log *slog.Logger
+	// Auth makes every route but /healthz require credentials with the
+	// scope the route needs.
+	Auth *auth.Authenticator
+	// Limiter rate limits every authenticated route. With Quotas set it
+	// also enforces the daily write quota.
+	Limiter *ratelimit.Limiter
+	Quotas  ratelimit.QuotaStore
+	// Features switches off the routes of disabled flags.
+	Features *features.Flags
+}
+
+func Router(ctx context.Context, router *chi.Mux, h *handlers.HTTPHandler, opts Options) {
+	router.Get("/healthz", h.Health(ctx))
+
+	router.Group(func(r chi.Router) {
+		if opts.Auth != nil {
+			r.Use(opts.Auth.Middleware)
+		}
+		route := func(pattern, scope string) chi.Router {
+			var mw []func(http.Handler) http.Handler
+			if opts.Auth != nil {
+				mw = append(mw, auth.Require(scope, domain.Collection))
+			}
+			if opts.Limiter != nil {
+				mw = append(mw, opts.Limiter.Middleware(pattern))
+				if scope == domain.ScopeWrite && opts.Quotas != nil {
+					mw = append(mw, opts.Limiter.QuotaMiddleware(opts.Log, opts.Quotas))
+				}
+			}
+			return r.With(mw...)
+		}
+		flag := func(name string) func(http.Handler) http.Handler {
+			if opts.Features == nil {
+				return func(next http.Handler) http.Handler { return next }
+			}
+			return opts.Features.Middleware(name)
+		}
+
+		route("/put-num", domain.ScopeWrite).Post("/put-num", h.HandleRequest(ctx))
+		route("/nums", domain.ScopeRead).Get("/nums", h.ListNumbers(ctx))
+		route("/nums/watch", domain.ScopeRead).With(flag(features.Watch)).Get("/nums/watch", h.Watch(ctx))
+		route("/nums/rank", domain.ScopeRead).With(flag(features.Rank)).Get("/nums/rank", h.Rank(ctx))
+		route("/nums/range", domain.ScopeRead).With(flag(features.Range)).Get("/nums/range", h.Range(ctx))
+		route("/stats", domain.ScopeRead).With(flag(features.Stats)).Get("/stats", h.Stats(ctx))
+	})
 }
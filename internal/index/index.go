@@ -0,0 +1,241 @@
+package index
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+const (
+	maxLevel = 32
+	// levelP is the chance a node is promoted to the next level.
+	levelP = 0.25
+)
+
+// Index is an order-statistic skip list of records sorted by number and then
+// id. Every link stores how many nodes it skips, so rank and positional
+// lookups are O(log n) like inserts.
+type Index struct {
+	mu     sync.RWMutex
+	head   *node
+	level  int
+	length int
+}
+
+type node struct {
+	rec   domain.Record
+	next  []*node
+	width []int
+}
+
+func New() *Index {
+	return &Index{head: newNode(domain.Record{}, maxLevel), level: 1}
+}
+
+func newNode(rec domain.Record, level int) *node {
+	return &node{rec: rec, next: make([]*node, level), width: make([]int, level)}
+}
+
+func less(a, b domain.Record) bool {
+	if c := a.Num.Cmp(b.Num); c != 0 {
+		return c < 0
+	}
+	return a.ID < b.ID
+}
+
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.Float64() < levelP {
+		level++
+	}
+	return level
+}
+
+// Insert adds rec unless a record with the same id and number is already
+// present, and reports whether it was added.
+func (ix *Index) Insert(rec domain.Record) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	return ix.insert(rec)
+}
+
+func (ix *Index) insert(rec domain.Record) bool {
+	var (
+		update [maxLevel]*node
+		rank   [maxLevel]int
+	)
+
+	x := ix.head
+	for i := ix.level - 1; i >= 0; i-- {
+		if i < ix.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.next[i] != nil && less(x.next[i].rec, rec) {
+			rank[i] += x.width[i]
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	if n := x.next[0]; n != nil && n.rec.ID == rec.ID && n.rec.Num.Cmp(rec.Num) == 0 {
+		return false
+	}
+
+	level := randomLevel()
+	if level > ix.level {
+		for i := ix.level; i < level; i++ {
+			update[i] = ix.head
+			update[i].width[i] = ix.length
+		}
+		ix.level = level
+	}
+
+	n := newNode(rec, level)
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+
+		n.width[i] = update[i].width[i] - (rank[0] - rank[i])
+		update[i].width[i] = rank[0] - rank[i] + 1
+	}
+	for i := level; i < ix.level; i++ {
+		update[i].width[i]++
+	}
+
+	ix.length++
+	return true
+}
+
+// Delete removes rec and reports whether it was present.
+func (ix *Index) Delete(rec domain.Record) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	var update [maxLevel]*node
+	x := ix.head
+	for i := ix.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && less(x.next[i].rec, rec) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	n := x.next[0]
+	if n == nil || n.rec.ID != rec.ID || n.rec.Num.Cmp(rec.Num) != 0 {
+		return false
+	}
+
+	for i := 0; i < ix.level; i++ {
+		if update[i].next[i] == n {
+			update[i].width[i] += n.width[i] - 1
+			update[i].next[i] = n.next[i]
+		} else {
+			update[i].width[i]--
+		}
+	}
+	for ix.level > 1 && ix.head.next[ix.level-1] == nil {
+		ix.level--
+	}
+
+	ix.length--
+	return true
+}
+
+// Reset replaces the contents with records.
+func (ix *Index) Reset(records []domain.Record) {
+	ix.replace(build(records))
+}
+
+func build(records []domain.Record) *Index {
+	fresh := New()
+	for _, rec := range records {
+		fresh.insert(rec)
+	}
+	return fresh
+}
+
+func (ix *Index) replace(fresh *Index) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.head, ix.level, ix.length = fresh.head, fresh.level, fresh.length
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return ix.length
+}
+
+// Rank returns how many records hold a number less than num, and the total.
+func (ix *Index) Rank(num domain.Number) (rank, total int) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return ix.countBelow(num, false), ix.length
+}
+
+// countBelow counts numbers less than num, or less than or equal when
+// inclusive is set.
+func (ix *Index) countBelow(num domain.Number, inclusive bool) int {
+	rank := 0
+	x := ix.head
+	for i := ix.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			c := x.next[i].rec.Num.Cmp(num)
+			if c > 0 || c == 0 && !inclusive {
+				break
+			}
+			rank += x.width[i]
+			x = x.next[i]
+		}
+	}
+	return rank
+}
+
+// Range returns up to limit records with numbers in [lo, hi], skipping the
+// first offset of them. Nil bounds are open and a limit of 0 means no limit.
+func (ix *Index) Range(lo, hi *domain.Number, offset, limit int) []domain.Record {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	from, to := 0, ix.length
+	if lo != nil {
+		from = ix.countBelow(*lo, false)
+	}
+	if hi != nil {
+		to = ix.countBelow(*hi, true)
+	}
+
+	from += offset
+	if limit > 0 && from+limit < to {
+		to = from + limit
+	}
+	if from >= to {
+		return nil
+	}
+
+	records := make([]domain.Record, 0, to-from)
+	for x := ix.at(from); x != nil && len(records) < to-from; x = x.next[0] {
+		records = append(records, x.rec)
+	}
+	return records
+}
+
+// at returns the node at the zero-based position pos.
+func (ix *Index) at(pos int) *node {
+	traversed := 0
+	x := ix.head
+	for i := ix.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && traversed+x.width[i] <= pos+1 {
+			traversed += x.width[i]
+			x = x.next[i]
+		}
+		if traversed == pos+1 {
+			return x
+		}
+	}
+	return nil
+}
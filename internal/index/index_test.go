@@ -0,0 +1,221 @@
+package index
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/storage/memory"
+)
+
+func TestIndex_MatchesSortedSlice(t *testing.T) {
+	ix := New()
+	var all []domain.Record
+	for id := range int64(2000) {
+		rec := domain.Record{ID: id + 1, Num: domain.NewInt(rand.Int64N(200) - 100)}
+		all = append(all, rec)
+		require.True(t, ix.Insert(rec))
+	}
+	slices.SortFunc(all, func(a, b domain.Record) int {
+		if c := a.Num.Cmp(b.Num); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+
+	assert.Equal(t, len(all), ix.Len())
+	assert.Equal(t, all, ix.Range(nil, nil, 0, 0))
+
+	for _, v := range []int64{-101, -100, -3, 0, 42, 99, 100} {
+		num := domain.NewInt(v)
+		want, _ := slices.BinarySearchFunc(all, num, func(e domain.Record, n domain.Number) int {
+			if e.Num.Cmp(n) < 0 {
+				return -1
+			}
+			return 1
+		})
+		rank, total := ix.Rank(num)
+		assert.Equal(t, want, rank, "rank of %d", v)
+		assert.Equal(t, len(all), total)
+	}
+
+	lo, hi := domain.NewInt(-10), domain.NewInt(10)
+	var want []domain.Record
+	for _, rec := range all {
+		if rec.Num.Cmp(lo) >= 0 && rec.Num.Cmp(hi) <= 0 {
+			want = append(want, rec)
+		}
+	}
+	assert.Equal(t, want, ix.Range(&lo, &hi, 0, 0))
+	assert.Equal(t, want[5:15], ix.Range(&lo, &hi, 5, 10))
+	assert.Empty(t, ix.Range(&hi, &lo, 0, 0))
+}
+
+func TestIndex_InsertDuplicate(t *testing.T) {
+	ix := New()
+
+	assert.True(t, ix.Insert(domain.Record{ID: 1, Num: domain.NewInt(5)}))
+	assert.False(t, ix.Insert(domain.Record{ID: 1, Num: domain.NewInt(5)}))
+	assert.True(t, ix.Insert(domain.Record{ID: 2, Num: domain.NewInt(5)}))
+	assert.Equal(t, 2, ix.Len())
+}
+
+func TestStorage_ResyncAndConcurrentWrites(t *testing.T) {
+	backend := memory.New()
+	for i := range int64(100) {
+		_, err := backend.PutNumber(context.Background(), domain.NewInt(i))
+		require.NoError(t, err)
+	}
+
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), backend)
+	require.NoError(t, s.Resync(context.Background()))
+	assert.Equal(t, 100, s.index.Len())
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := range 50 {
+				_, err := s.PutNumber(context.Background(), domain.NewInt(int64(w*1000+i)))
+				assert.NoError(t, err)
+			}
+		})
+	}
+	for range 5 {
+		assert.NoError(t, s.Resync(context.Background()))
+	}
+	wg.Wait()
+
+	records, err := s.GetRecords(context.Background(), domain.OrderAsc)
+	require.NoError(t, err)
+	assert.Len(t, records, 300)
+
+	stored, err := backend.GetSlice(context.Background())
+	require.NoError(t, err)
+	rank, total := s.Rank(domain.NewInt(100))
+	assert.Equal(t, len(stored), total)
+	assert.Equal(t, 100+50, rank)
+}
+
+func TestIndex_DeleteKeepsOrderAndRanks(t *testing.T) {
+	ix := New()
+	var all []domain.Record
+	for id := range int64(1000) {
+		rec := domain.Record{ID: id + 1, Num: domain.NewInt(rand.Int64N(100))}
+		all = append(all, rec)
+		ix.Insert(rec)
+	}
+
+	var kept []domain.Record
+	for _, rec := range all {
+		if rec.ID%3 == 0 {
+			require.True(t, ix.Delete(rec))
+		} else {
+			kept = append(kept, rec)
+		}
+	}
+	assert.False(t, ix.Delete(domain.Record{ID: 3, Num: all[2].Num}))
+
+	slices.SortFunc(kept, func(a, b domain.Record) int {
+		if c := a.Num.Cmp(b.Num); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+	assert.Equal(t, len(kept), ix.Len())
+	assert.Equal(t, kept, ix.Range(nil, nil, 0, 0))
+	assert.Equal(t, kept[100:110], ix.Range(nil, nil, 100, 10))
+
+	fifty := domain.NewInt(50)
+	rank, _ := ix.Rank(fifty)
+	want := slices.IndexFunc(kept, func(r domain.Record) bool { return r.Num.Cmp(fifty) >= 0 })
+	assert.Equal(t, want, rank)
+}
+
+func TestStorage_LeavesOutRecordsPastMaxAge(t *testing.T) {
+	ctx := context.Background()
+	old := reqctx.WithMeta(ctx, domain.Meta{CreatedAt: time.Now().Add(-2 * time.Hour)})
+	backend := memory.New()
+	_, err := backend.PutNumber(old, domain.NewInt(1))
+	require.NoError(t, err)
+	_, err = backend.PutNumber(ctx, domain.NewInt(2))
+	require.NoError(t, err)
+
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), backend)
+	s.SetRetention(domain.RetentionPolicy{MaxAge: time.Hour})
+	require.NoError(t, s.Resync(ctx))
+
+	// A late spooled write keeps the time it was accepted.
+	_, err = s.PutNumber(old, domain.NewInt(0))
+	require.NoError(t, err)
+	_, err = s.PutNumber(ctx, domain.NewInt(3))
+	require.NoError(t, err)
+
+	nums, err := s.GetSlice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Number{domain.NewInt(2), domain.NewInt(3)}, nums)
+
+	rank, total := s.Rank(domain.NewInt(3))
+	assert.Equal(t, 1, rank)
+	assert.Equal(t, 2, total)
+	assert.Len(t, s.Range(nil, nil, 0, 0), 2)
+}
+
+func TestStorage_KeepsNewestMaxRows(t *testing.T) {
+	ctx := context.Background()
+	backend := memory.New()
+	for _, n := range []int64{5, 1, 4} {
+		_, err := backend.PutNumber(ctx, domain.NewInt(n))
+		require.NoError(t, err)
+	}
+
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), backend)
+	s.SetRetention(domain.RetentionPolicy{MaxRows: 2})
+	require.NoError(t, s.Resync(ctx))
+
+	nums, err := s.GetSlice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Number{domain.NewInt(1), domain.NewInt(4)}, nums)
+
+	_, err = s.PutNumber(ctx, domain.NewInt(0))
+	require.NoError(t, err)
+
+	rank, total := s.Rank(domain.NewInt(4))
+	assert.Equal(t, 1, rank)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []domain.Record{{ID: 3, Num: domain.NewInt(4)}, {ID: 4, Num: domain.NewInt(0)}},
+		sortByID(s.Range(nil, nil, 0, 0)))
+}
+
+func sortByID(records []domain.Record) []domain.Record {
+	slices.SortFunc(records, func(a, b domain.Record) int { return int(a.ID - b.ID) })
+	return records
+}
+
+// scanStorage records whether reads were marked as full scans.
+type scanStorage struct {
+	*memory.Storage
+	fullScan bool
+}
+
+func (s *scanStorage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	s.fullScan = reqctx.FullScan(ctx)
+	return s.Storage.GetRecords(ctx, order)
+}
+
+func TestStorage_ResyncIsFullScan(t *testing.T) {
+	backend := &scanStorage{Storage: memory.New()}
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), backend)
+
+	require.NoError(t, s.Resync(context.Background()))
+	assert.True(t, backend.fullScan)
+}
@@ -0,0 +1,254 @@
+package index
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+// Storage keeps an Index in step with the wrapped storage. It serves
+// ascending reads, ranks and ranges from memory and forwards everything else.
+// Writes made by other replicas show up after the next Resync.
+type Storage struct {
+	usecase.Storage
+
+	log     *slog.Logger
+	index   *Index
+	maxAge  time.Duration
+	maxRows int64
+
+	resyncMu sync.Mutex
+
+	mu      sync.Mutex
+	syncing bool
+	pending []aged
+	// byAge lists the indexed records oldest first while a retention
+	// bound is set.
+	byAge []aged
+}
+
+// aged is a record with the time it was created.
+type aged struct {
+	rec domain.Record
+	at  time.Time
+}
+
+func NewStorage(log *slog.Logger, storage usecase.Storage) *Storage {
+	return &Storage{Storage: storage, log: log, index: New()}
+}
+
+// SetRetention makes reads leave out records older than policy.MaxAge and
+// all but the newest policy.MaxRows, like retention does in storage, so the
+// index agrees with it between retention runs. It must be called before the
+// first Resync.
+func (s *Storage) SetRetention(policy domain.RetentionPolicy) {
+	s.maxAge, s.maxRows = policy.MaxAge, policy.MaxRows
+}
+
+func (s *Storage) bounded() bool {
+	return s.maxAge > 0 || s.maxRows > 0
+}
+
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	id, err := s.Storage.PutNumber(ctx, num)
+	if err != nil {
+		return 0, err
+	}
+
+	at := time.Now()
+	if meta, ok := reqctx.Meta(ctx); ok && !meta.CreatedAt.IsZero() {
+		at = meta.CreatedAt
+	}
+	s.add(aged{rec: domain.Record{ID: id, Num: num}, at: at})
+	return id, nil
+}
+
+func (s *Storage) add(a aged) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.syncing {
+		s.pending = append(s.pending, a)
+	}
+	if s.index.Insert(a.rec) {
+		s.track(a)
+	}
+}
+
+// track queues a for expiry. Callers hold s.mu.
+func (s *Storage) track(a aged) {
+	if !s.bounded() {
+		return
+	}
+	// Spooled writes arrive late with their original time, so this is not
+	// always an append.
+	i := len(s.byAge)
+	for i > 0 && s.byAge[i-1].at.After(a.at) {
+		i--
+	}
+	s.byAge = slices.Insert(s.byAge, i, a)
+}
+
+// expire drops the records past maxAge, then the oldest beyond maxRows.
+func (s *Storage) expire() {
+	if !s.bounded() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	if s.maxAge > 0 {
+		cutoff := time.Now().Add(-s.maxAge)
+		for n < len(s.byAge) && s.byAge[n].at.Before(cutoff) {
+			s.index.Delete(s.byAge[n].rec)
+			n++
+		}
+	}
+	if s.maxRows > 0 {
+		for n < len(s.byAge) && int64(s.index.Len()) > s.maxRows {
+			s.index.Delete(s.byAge[n].rec)
+			n++
+		}
+	}
+	s.byAge = slices.Delete(s.byAge, 0, n)
+}
+
+// Resync rebuilds the index from storage. Writes that land while the
+// snapshot is read are replayed on top of it; ids make that idempotent.
+func (s *Storage) Resync(ctx context.Context) error {
+	const op = "index.Resync"
+
+	s.resyncMu.Lock()
+	defer s.resyncMu.Unlock()
+
+	s.mu.Lock()
+	s.syncing, s.pending = true, nil
+	s.mu.Unlock()
+
+	records, err := s.snapshot(reqctx.WithFullScan(reqctx.WithFreshRead(ctx)))
+	var fresh *Index
+	if err == nil {
+		fresh = New()
+		for _, a := range records {
+			fresh.insert(a.rec)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := s.pending
+	s.syncing, s.pending = false, nil
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.byAge = nil
+	if s.bounded() {
+		s.byAge = records
+	}
+	for _, a := range pending {
+		if fresh.insert(a.rec) {
+			s.track(a)
+		}
+	}
+	s.index.replace(fresh)
+	return nil
+}
+
+// snapshot reads every record, with creation times oldest first when a
+// retention bound is set.
+func (s *Storage) snapshot(ctx context.Context) ([]aged, error) {
+	if !s.bounded() {
+		records, err := s.Storage.GetRecords(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		out := make([]aged, len(records))
+		for i, rec := range records {
+			out[i] = aged{rec: rec}
+		}
+		return out, nil
+	}
+
+	var q domain.RecordQuery
+	if s.maxAge > 0 {
+		q.Since = time.Now().Add(-s.maxAge)
+	}
+	records, err := s.Storage.FindRecords(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]aged, len(records))
+	for i, rec := range records {
+		out[i] = aged{rec: domain.Record{ID: rec.ID, Num: rec.Num}, at: time.Now()}
+		if rec.Meta != nil && !rec.Meta.CreatedAt.IsZero() {
+			out[i].at = rec.Meta.CreatedAt
+		}
+	}
+	slices.SortStableFunc(out, func(a, b aged) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.rec.ID, b.rec.ID)
+	})
+	return out, nil
+}
+
+// RunResync resyncs every interval until ctx is done.
+func (s *Storage) RunResync(ctx context.Context, interval time.Duration) {
+	const op = "index.RunResync"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Resync(ctx)
+			if err != nil {
+				s.log.Error("failed to resync index", "op", op, "error", err)
+			}
+		}
+	}
+}
+
+func (s *Storage) GetSlice(ctx context.Context) ([]domain.Number, error) {
+	s.expire()
+	records := s.index.Range(nil, nil, 0, 0)
+
+	numbers := make([]domain.Number, len(records))
+	for i, rec := range records {
+		numbers[i] = rec.Num
+	}
+	return numbers, nil
+}
+
+func (s *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	if order == "" || order == domain.OrderAsc {
+		s.expire()
+		return s.index.Range(nil, nil, 0, 0), nil
+	}
+	return s.Storage.GetRecords(ctx, order)
+}
+
+func (s *Storage) Rank(num domain.Number) (rank, total int) {
+	s.expire()
+	return s.index.Rank(num)
+}
+
+func (s *Storage) Range(lo, hi *domain.Number, offset, limit int) []domain.Record {
+	s.expire()
+	return s.index.Range(lo, hi, offset, limit)
+}
@@ -0,0 +1,51 @@
+package loadgen
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+const (
+	DistUniform    = "uniform"
+	DistSequential = "sequential"
+	DistDuplicates = "duplicates"
+)
+
+// Generator returns the next number to submit. It must be safe for
+// concurrent use.
+type Generator func() int64
+
+// NewGenerator builds a generator for the named distribution. Uniform draws
+// from [lo, hi], sequential counts up from lo and duplicates draws from
+// only distinct evenly spaced values in [lo, hi].
+func NewGenerator(dist string, lo, hi int64, distinct int) (Generator, error) {
+	if hi < lo {
+		return nil, fmt.Errorf("max %d is less than min %d", hi, lo)
+	}
+
+	span := hi - lo + 1
+
+	switch dist {
+	case DistUniform:
+		return func() int64 {
+			return lo + rand.Int64N(span)
+		}, nil
+	case DistSequential:
+		var next atomic.Int64
+		next.Store(lo - 1)
+		return func() int64 {
+			return next.Add(1)
+		}, nil
+	case DistDuplicates:
+		if distinct < 1 {
+			return nil, fmt.Errorf("distinct must be positive, got %d", distinct)
+		}
+		step := max(span/int64(distinct), 1)
+		return func() int64 {
+			return lo + rand.Int64N(int64(distinct))*step%span
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown distribution %q", dist)
+	}
+}
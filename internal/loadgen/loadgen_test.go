@@ -0,0 +1,96 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type funcTarget func(ctx context.Context, num int64) error
+
+func (f funcTarget) Put(ctx context.Context, num int64) error {
+	return f(ctx, num)
+}
+
+func TestNewGenerator_Sequential(t *testing.T) {
+	gen, err := NewGenerator(DistSequential, 5, 100, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{5, 6, 7}, []int64{gen(), gen(), gen()})
+}
+
+func TestNewGenerator_UniformStaysInRange(t *testing.T) {
+	gen, err := NewGenerator(DistUniform, -3, 3, 0)
+	require.NoError(t, err)
+
+	for range 1000 {
+		num := gen()
+		assert.GreaterOrEqual(t, num, int64(-3))
+		assert.LessOrEqual(t, num, int64(3))
+	}
+}
+
+func TestNewGenerator_DuplicatesLimitsDistinctValues(t *testing.T) {
+	gen, err := NewGenerator(DistDuplicates, 0, 1_000_000, 4)
+	require.NoError(t, err)
+
+	seen := map[int64]struct{}{}
+	for range 1000 {
+		seen[gen()] = struct{}{}
+	}
+
+	assert.LessOrEqual(t, len(seen), 4)
+}
+
+func TestNewGenerator_Invalid(t *testing.T) {
+	_, err := NewGenerator("zipf", 0, 10, 0)
+	assert.Error(t, err)
+
+	_, err = NewGenerator(DistUniform, 10, 0, 0)
+	assert.Error(t, err)
+
+	_, err = NewGenerator(DistDuplicates, 0, 10, 0)
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+
+	assert.Equal(t, time.Duration(50), percentile(sorted, 50))
+	assert.Equal(t, time.Duration(99), percentile(sorted, 99))
+	assert.Equal(t, time.Duration(100), percentile(sorted, 100))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
+
+func TestRun_RequestLimit(t *testing.T) {
+	var calls atomic.Int64
+	target := funcTarget(func(ctx context.Context, num int64) error {
+		if calls.Add(1)%10 == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	gen, err := NewGenerator(DistSequential, 0, 0, 0)
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), Config{Concurrency: 4, Requests: 100}, target, gen)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), calls.Load())
+	assert.Equal(t, int64(100), report.Requests)
+	assert.Equal(t, int64(10), report.Errors)
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	_, err := Run(context.Background(), Config{Concurrency: 1}, funcTarget(nil), nil)
+	assert.Error(t, err)
+}
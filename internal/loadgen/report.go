@@ -0,0 +1,71 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+type Report struct {
+	Requests   int64
+	Errors     int64
+	Elapsed    time.Duration
+	Throughput float64
+	Mean       time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+func newReport(latencies []time.Duration, errors int64, elapsed time.Duration) Report {
+	slices.Sort(latencies)
+
+	r := Report{
+		Requests: int64(len(latencies)),
+		Errors:   errors,
+		Elapsed:  elapsed,
+		P50:      percentile(latencies, 50),
+		P90:      percentile(latencies, 90),
+		P99:      percentile(latencies, 99),
+	}
+
+	if len(latencies) > 0 {
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		r.Mean = total / time.Duration(len(latencies))
+		r.Max = latencies[len(latencies)-1]
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(r.Requests) / elapsed.Seconds()
+	}
+
+	return r
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+
+	return sorted[rank]
+}
+
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	n, err := fmt.Fprintf(w,
+		"requests:   %d (%d errors)\n"+
+			"elapsed:    %s\n"+
+			"throughput: %.1f req/s\n"+
+			"latency:    mean %s, p50 %s, p90 %s, p99 %s, max %s\n",
+		r.Requests, r.Errors, r.Elapsed.Round(time.Millisecond), r.Throughput,
+		r.Mean, r.P50, r.P90, r.P99, r.Max)
+
+	return int64(n), err
+}
@@ -0,0 +1,130 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	// Concurrency is the number of workers issuing requests.
+	Concurrency int
+	// Rate caps requests per second across all workers. Zero runs each
+	// worker as fast as the target responds.
+	Rate float64
+	// Duration bounds the run. Zero means no time limit.
+	Duration time.Duration
+	// Requests bounds the number of requests. Zero means no count limit.
+	Requests int
+}
+
+func (c Config) validate() error {
+	if c.Concurrency < 1 {
+		return errors.New("concurrency must be positive")
+	}
+	if c.Rate < 0 {
+		return errors.New("rate must not be negative")
+	}
+	if c.Duration <= 0 && c.Requests <= 0 {
+		return errors.New("either duration or requests must be set")
+	}
+	return nil
+}
+
+// Run drives target with numbers from gen until the configured duration or
+// request count is reached, or ctx is cancelled.
+func Run(ctx context.Context, cfg Config, target Target, gen Generator) (Report, error) {
+	err := cfg.validate()
+	if err != nil {
+		return Report{}, err
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var (
+		issued   atomic.Int64
+		failures atomic.Int64
+		wg       sync.WaitGroup
+	)
+
+	// reserve claims the next request slot, honouring the request limit.
+	reserve := func() bool {
+		if cfg.Requests <= 0 {
+			return true
+		}
+		return issued.Add(1) <= int64(cfg.Requests)
+	}
+
+	// With a rate set, requests are scheduled on a fixed clock and latency is
+	// measured from the scheduled time, so a slow server cannot hide queueing
+	// delay by slowing the generator down.
+	var schedule <-chan time.Time
+	if cfg.Rate > 0 {
+		ticks := make(chan time.Time, cfg.Concurrency)
+		schedule = ticks
+		interval := time.Duration(float64(time.Second) / cfg.Rate)
+		go func() {
+			defer close(ticks)
+			next := time.Now()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(next)):
+				}
+				select {
+				case ticks <- next:
+				case <-ctx.Done():
+					return
+				}
+				next = next.Add(interval)
+			}
+		}()
+	}
+
+	latencies := make([][]time.Duration, cfg.Concurrency)
+	start := time.Now()
+
+	for i := range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil && reserve() {
+				sent := time.Now()
+				if schedule != nil {
+					var ok bool
+					sent, ok = <-schedule
+					if !ok {
+						return
+					}
+				}
+
+				err := target.Put(ctx, gen())
+				if ctx.Err() != nil {
+					// Requests cut short by the end of the run are not counted.
+					return
+				}
+				if err != nil {
+					failures.Add(1)
+				}
+				latencies[i] = append(latencies[i], time.Since(sent))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+
+	return newReport(all, failures.Load(), time.Since(start)), nil
+}
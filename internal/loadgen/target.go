@@ -0,0 +1,91 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testovoe/internal/domain"
+)
+
+// Target submits a single number to the service under test.
+type Target interface {
+	Put(ctx context.Context, num int64) error
+}
+
+// HandlerTarget calls an http.Handler directly, without a network hop.
+type HandlerTarget struct {
+	handler http.Handler
+}
+
+func NewHandlerTarget(handler http.Handler) *HandlerTarget {
+	return &HandlerTarget{handler: handler}
+}
+
+func (t *HandlerTarget) Put(ctx context.Context, num int64) error {
+	body, err := json.Marshal(domain.UserNum{Num: domain.NewInt(num)})
+	if err != nil {
+		return err
+	}
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/put-num", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	t.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK && w.Code != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %d", w.Code)
+	}
+
+	return nil
+}
+
+// HTTPTarget sends requests to a running server.
+type HTTPTarget struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPTarget(baseURL string, client *http.Client) *HTTPTarget {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPTarget{
+		url:    strings.TrimRight(baseURL, "/") + "/put-num",
+		client: client,
+	}
+}
+
+func (t *HTTPTarget) Put(ctx context.Context, num int64) error {
+	body, err := json.Marshal(domain.UserNum{Num: domain.NewInt(num)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// 202 means the server spooled the write while its database is down.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type fieldsKey struct{}
+
+// fields holds the attributes of one request. It is shared by every context
+// derived for the request, so fields added deep inside the middleware chain
+// show up in the access log as well.
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func (f *fields) add(attrs []slog.Attr) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attrs = append(f.attrs, attrs...)
+}
+
+func (f *fields) list() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attrs[:len(f.attrs):len(f.attrs)]
+}
+
+// WithFields returns ctx with a new set of fields holding attrs. Records
+// logged with ctx or a context derived from it carry the fields.
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	f := &fields{}
+	f.add(attrs)
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// AddFields adds attrs to the fields of ctx. Without fields it does nothing.
+func AddFields(ctx context.Context, attrs ...slog.Attr) {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.add(attrs)
+	}
+}
+
+// InheritFields carries the fields of from over to ctx.
+func InheritFields(ctx, from context.Context) context.Context {
+	if f, ok := from.Value(fieldsKey{}).(*fields); ok {
+		return context.WithValue(ctx, fieldsKey{}, f)
+	}
+	return ctx
+}
+
+// contextHandler adds the fields of the record's context to the record.
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		if attrs := f.list(); len(attrs) > 0 {
+			r = r.Clone()
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Options configures the logger New builds.
+type Options struct {
+	// Format is "text" for slog's text output; anything else means JSON.
+	Format string
+	Level  slog.Leveler
+	// Redact names attribute keys to hide on top of DefaultRedactKeys.
+	Redact   []string
+	Sampling Sampling
+}
+
+// Sampling thins out records below warn level. Per message and level, the
+// first First records of every Tick are kept, then every Thereafter-th; with
+// Thereafter zero the rest are dropped. A zero First keeps everything.
+type Sampling struct {
+	First      int
+	Thereafter int
+	Tick       time.Duration
+}
+
+// New returns a logger writing to w that adds the fields carried by the
+// context of each record, redacts secrets and samples as configured.
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: newRedactor(opts.Redact).replace,
+	}
+
+	var h slog.Handler
+	if opts.Format == "text" {
+		h = slog.NewTextHandler(w, handlerOpts)
+	} else {
+		h = slog.NewJSONHandler(w, handlerOpts)
+	}
+	if opts.Sampling.First > 0 {
+		h = newSampler(h, opts.Sampling)
+	}
+	return slog.New(&contextHandler{next: h})
+}
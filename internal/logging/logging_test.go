@@ -0,0 +1,154 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestNew_RedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Options{Redact: []string{"dsn"}})
+
+	log.Info("login",
+		"Authorization", "Bearer abc.def",
+		"X-Api-Key", "tt_k1_s3cret",
+		"dsn", "postgres://u:p@db/x",
+		"header", "got Bearer abc.def from client",
+		slog.Group("req", "password", "hunter2"),
+		"error", errors.New("connect host=db password=hunter2 failed"),
+		"url", "postgres://user:hunter2@db/x",
+		"key", "tt_k1_s3cret",
+		"user", "alice",
+	)
+
+	rec := records(t, &buf)[0]
+	assert.Equal(t, "REDACTED", rec["Authorization"])
+	assert.Equal(t, "REDACTED", rec["X-Api-Key"])
+	assert.Equal(t, "REDACTED", rec["dsn"])
+	assert.Equal(t, "got Bearer REDACTED from client", rec["header"])
+	assert.Equal(t, map[string]any{"password": "REDACTED"}, rec["req"])
+	assert.Equal(t, "connect host=db password=REDACTED failed", rec["error"])
+	assert.Equal(t, "postgres://user:REDACTED@db/x", rec["url"])
+	assert.Equal(t, "tt_k1_REDACTED", rec["key"])
+	assert.Equal(t, "alice", rec["user"])
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.NotContains(t, buf.String(), "s3cret")
+}
+
+func TestNew_SamplesBelowWarn(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Options{Sampling: Sampling{First: 2, Thereafter: 3, Tick: time.Hour}})
+
+	for range 8 {
+		log.Info("hit")
+		log.Warn("slow")
+	}
+	log.Info("other")
+
+	var hits, slow, other int
+	for _, rec := range records(t, &buf) {
+		switch rec["msg"] {
+		case "hit":
+			hits++
+		case "slow":
+			slow++
+		case "other":
+			other++
+		}
+	}
+	// Records 1, 2, 5 and 8.
+	assert.Equal(t, 4, hits)
+	assert.Equal(t, 8, slow)
+	assert.Equal(t, 1, other)
+}
+
+func TestNew_AddsContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Options{})
+
+	ctx := WithFields(context.Background(), slog.String("request_id", "r1"))
+	AddFields(ctx, slog.String("principal", "alice"))
+	derived := InheritFields(context.Background(), ctx)
+
+	log.InfoContext(derived, "stored")
+	log.Info("no context")
+	AddFields(context.Background(), slog.String("ignored", "x"))
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "r1", recs[0]["request_id"])
+	assert.Equal(t, "alice", recs[0]["principal"])
+	assert.NotContains(t, recs[1], "request_id")
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Options{})
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, RequestFields, AccessLog(log))
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddFields(r.Context(), slog.String("principal", "key:k1"))
+			next.ServeHTTP(w, r)
+		})
+	}).Get("/nums/{id}", func(w http.ResponseWriter, r *http.Request) {
+		log.InfoContext(r.Context(), "inside")
+		_, _ = w.Write([]byte("hello"))
+	})
+	r.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nums/42", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 3)
+
+	inside, access := recs[0], recs[1]
+	assert.Equal(t, "inside", inside["msg"])
+	assert.NotEmpty(t, inside["request_id"])
+	assert.Equal(t, inside["request_id"], access["request_id"])
+
+	assert.Equal(t, "request", access["msg"])
+	assert.Equal(t, "INFO", access["level"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/nums/{id}", access["route"])
+	assert.Equal(t, float64(http.StatusOK), access["status"])
+	assert.Equal(t, float64(5), access["bytes"])
+	assert.Equal(t, "key:k1", access["principal"])
+	assert.Contains(t, access, "duration")
+
+	failed := recs[2]
+	assert.Equal(t, "ERROR", failed["level"])
+	assert.Equal(t, float64(http.StatusServiceUnavailable), failed["status"])
+	assert.NotContains(t, failed, "principal")
+}
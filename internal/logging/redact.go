@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// DefaultRedactKeys are the attribute keys whose values are never logged.
+// Keys match case-insensitively, with '-' and '_' alike.
+var DefaultRedactKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"set_cookie",
+	"api_key",
+	"x_api_key",
+}
+
+// secretPatterns find credentials inside string values, such as a header
+// echoed in an error. Only the text between the first and the optional
+// second group is masked.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)\S+`),
+	regexp.MustCompile(`\b(tt_[A-Za-z0-9]+_)[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`(?i)(password=)[^\s&]+`),
+	regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+(@)`),
+}
+
+type redactor struct {
+	keys map[string]bool
+}
+
+func newRedactor(extra []string) *redactor {
+	r := &redactor{keys: map[string]bool{}}
+	for _, key := range append(DefaultRedactKeys, extra...) {
+		r.keys[normalizeKey(key)] = true
+	}
+	return r
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+func (r *redactor) replace(_ []string, a slog.Attr) slog.Attr {
+	if r.keys[normalizeKey(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s, ok := redactString(a.Value.String()); ok {
+			return slog.String(a.Key, s)
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			if s, ok := redactString(err.Error()); ok {
+				return slog.String(a.Key, s)
+			}
+		}
+	}
+	return a
+}
+
+// redactString masks the credentials s contains and reports whether it
+// found any.
+func redactString(s string) (string, bool) {
+	out := s
+	for _, re := range secretPatterns {
+		out = re.ReplaceAllString(out, "${1}"+redacted+"${2}")
+	}
+	return out, out != s
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+// samplerState is shared by a sampler and the handlers derived from it.
+type samplerState struct {
+	mu     sync.Mutex
+	start  time.Time
+	counts map[sampleKey]int
+}
+
+var dropped atomic.Int64
+
+// Dropped returns how many records sampling has dropped since start.
+func Dropped() int64 {
+	return dropped.Load()
+}
+
+// sampler drops records below warn level past the configured rate.
+type sampler struct {
+	next  slog.Handler
+	cfg   Sampling
+	state *samplerState
+}
+
+func newSampler(next slog.Handler, cfg Sampling) *sampler {
+	return &sampler{next: next, cfg: cfg, state: &samplerState{counts: map[sampleKey]int{}}}
+}
+
+func (s *sampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.next.Enabled(ctx, level)
+}
+
+func (s *sampler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn || s.keep(r) {
+		return s.next.Handle(ctx, r)
+	}
+	dropped.Add(1)
+	return nil
+}
+
+func (s *sampler) keep(r slog.Record) bool {
+	st := s.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if r.Time.Sub(st.start) >= s.cfg.Tick || r.Time.Before(st.start) {
+		st.start = r.Time
+		clear(st.counts)
+	}
+
+	key := sampleKey{level: r.Level, msg: r.Message}
+	st.counts[key]++
+	n := st.counts[key]
+	if n <= s.cfg.First {
+		return true
+	}
+	return s.cfg.Thereafter > 0 && (n-s.cfg.First)%s.cfg.Thereafter == 0
+}
+
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{next: s.next.WithAttrs(attrs), cfg: s.cfg, state: s.state}
+}
+
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{next: s.next.WithGroup(name), cfg: s.cfg, state: s.state}
+}
@@ -0,0 +1,136 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testovoe/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+type Migrator struct {
+	log         *slog.Logger
+	db          *sql.DB
+	provider    *goose.Provider
+	getVersions func(ctx context.Context) (current, target int64, err error)
+}
+
+func New(log *slog.Logger, storagePath string) (*Migrator, error) {
+	const op = "migrator.New"
+
+	db, err := sql.Open("pgx", storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not open database: %w", op, err)
+	}
+
+	// Session-level advisory lock, so replicas starting together apply
+	// migrations one at a time instead of racing each other.
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: could not create locker: %w", op, err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: could not create provider: %w", op, err)
+	}
+
+	return &Migrator{
+		log:         log,
+		db:          db,
+		provider:    provider,
+		getVersions: provider.GetVersions,
+	}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.provider.Close()
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	const op = "migrator.Up"
+
+	results, err := m.provider.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, res := range results {
+		m.log.Info("migration applied", "version", res.Source.Version, "duration", res.Duration)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Down(ctx context.Context) error {
+	const op = "migrator.Down"
+
+	res, err := m.provider.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.log.Info("migration rolled back", "version", res.Source.Version, "duration", res.Duration)
+
+	return nil
+}
+
+func (m *Migrator) Redo(ctx context.Context) error {
+	const op = "migrator.Redo"
+
+	err := m.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.log.Info("migration applied", "version", res.Source.Version, "duration", res.Duration)
+
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	const op = "migrator.Status"
+
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return statuses, nil
+}
+
+// CheckVersion returns ErrSchemaBehind when the database has not yet been
+// migrated to the latest embedded version. A database migrated further by a
+// newer binary is accepted, so that replicas still on the old one keep
+// starting during a rollout.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	const op = "migrator.CheckVersion"
+
+	current, target, err := m.getVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current < target {
+		return fmt.Errorf("%s: %w: at version %d, expected %d", op, ErrSchemaBehind, current, target)
+	}
+	if current > target {
+		m.log.WarnContext(ctx, "database schema is ahead of this binary", "op", op, "version", current, "expected", target)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMigrator(current, target int64, err error) (*Migrator, *bytes.Buffer) {
+	var logs bytes.Buffer
+	return &Migrator{
+		log: slog.New(slog.NewTextHandler(&logs, nil)),
+		getVersions: func(context.Context) (int64, int64, error) {
+			return current, target, err
+		},
+	}, &logs
+}
+
+func TestMigrator_CheckVersion(t *testing.T) {
+	m, logs := newTestMigrator(10, 10, nil)
+	assert.NoError(t, m.CheckVersion(context.Background()))
+	assert.Empty(t, logs.String())
+
+	// Without auto_migrate nothing applies pending migrations, so startup
+	// has to stop here.
+	m, _ = newTestMigrator(8, 10, nil)
+	err := m.CheckVersion(context.Background())
+	require.ErrorIs(t, err, ErrSchemaBehind)
+	assert.ErrorContains(t, err, "at version 8, expected 10")
+
+	m, _ = newTestMigrator(0, 10, nil)
+	assert.ErrorIs(t, m.CheckVersion(context.Background()), ErrSchemaBehind)
+}
+
+func TestMigrator_CheckVersion_DatabaseAhead(t *testing.T) {
+	m, logs := newTestMigrator(11, 10, nil)
+
+	assert.NoError(t, m.CheckVersion(context.Background()))
+	assert.Contains(t, logs.String(), "database schema is ahead of this binary")
+	assert.Contains(t, logs.String(), "version=11 expected=10")
+}
+
+func TestMigrator_CheckVersion_Unreachable(t *testing.T) {
+	down := errors.New("connection refused")
+	m, _ := newTestMigrator(0, 0, down)
+
+	err := m.CheckVersion(context.Background())
+	assert.ErrorIs(t, err, down)
+	assert.NotErrorIs(t, err, ErrSchemaBehind)
+}
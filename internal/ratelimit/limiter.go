@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limit is a token bucket: Rate tokens per second refill it up to Burst. A
+// zero Rate means no limit.
+type Limit struct {
+	Rate  float64 `json:"rate" yaml:"rate"`
+	Burst int     `json:"burst" yaml:"burst"`
+}
+
+// Limits holds the bucket for every route without its own entry in Routes,
+// which is keyed by route pattern such as "/put-num", and the number of
+// writes each client may make per UTC day, 0 for no quota.
+type Limits struct {
+	Default     Limit            `json:"default"`
+	Routes      map[string]Limit `json:"routes,omitempty"`
+	DailyWrites int64            `json:"daily_writes"`
+}
+
+func (l Limits) Validate() error {
+	var errs []error
+	check := func(name string, limit Limit) {
+		if limit.Rate < 0 || math.IsNaN(limit.Rate) || math.IsInf(limit.Rate, 0) {
+			errs = append(errs, fmt.Errorf("%s: rate must be a non-negative number", name))
+		}
+		if limit.Rate > 0 && limit.Burst < 1 {
+			errs = append(errs, fmt.Errorf("%s: burst must be at least 1", name))
+		}
+	}
+	check("default", l.Default)
+	for route, limit := range l.Routes {
+		check("routes."+route, limit)
+	}
+	if l.DailyWrites < 0 {
+		errs = append(errs, errors.New("daily_writes: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (l Limits) route(route string) Limit {
+	if limit, ok := l.Routes[route]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Decision is the outcome of taking a token.
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining the tokens left in it.
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again, RetryAfter how long
+	// until a refused request would be allowed.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter keeps a token bucket per route and client. Limits can be replaced
+// while it is in use; buckets pick up the new ones on their next request.
+type Limiter struct {
+	limits atomic.Pointer[Limits]
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+
+	limited atomic.Int64
+}
+
+type bucketKey struct {
+	route  string
+	client string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type Metrics struct {
+	Buckets int   `json:"buckets"`
+	Limited int64 `json:"limited"`
+}
+
+func New(limits Limits) *Limiter {
+	l := &Limiter{now: time.Now, buckets: make(map[bucketKey]*bucket)}
+	l.limits.Store(&limits)
+	return l
+}
+
+func (l *Limiter) Limits() Limits {
+	return *l.limits.Load()
+}
+
+func (l *Limiter) SetLimits(limits Limits) error {
+	err := limits.Validate()
+	if err != nil {
+		return err
+	}
+	l.limits.Store(&limits)
+	return nil
+}
+
+// Allow takes a token from client's bucket for route.
+func (l *Limiter) Allow(route, client string) Decision {
+	limit := l.Limits().route(route)
+	if limit.Rate <= 0 {
+		return Decision{Allowed: true}
+	}
+	burst := float64(limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := bucketKey{route: route, client: client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	d := Decision{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+		l.limited.Add(1)
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = seconds((burst - b.tokens) / limit.Rate)
+	return d
+}
+
+func (l *Limiter) Metrics() Metrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return Metrics{Buckets: len(l.buckets), Limited: l.limited.Load()}
+}
+
+// sweep drops, at most once a minute, buckets that have refilled: they are
+// indistinguishable from new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	limits := l.Limits()
+	for key, b := range l.buckets {
+		limit := limits.route(key.route)
+		if limit.Rate <= 0 || b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"testovoe/internal/reqctx"
+	"time"
+)
+
+// QuotaStore counts writes per client and UTC day where every instance sees
+// them. ConsumeQuota records one write unless client already made limit of
+// them today, and reports whether it did.
+type QuotaStore interface {
+	ConsumeQuota(ctx context.Context, client string, limit int64) (used int64, ok bool, err error)
+}
+
+// Middleware refuses requests beyond the client's bucket for route with 429
+// and tells every client its budget in RateLimit-* headers.
+func (l *Limiter) Middleware(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := l.Allow(route, ClientKey(r))
+			if d.Limit > 0 {
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+				w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+			}
+			if !d.Allowed {
+				tooManyRequests(w, d.RetryAfter, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// QuotaMiddleware refuses writes beyond the daily quota with 429 until the
+// next UTC day. A write is counted when it is attempted, whether or not it
+// succeeds. If the count cannot be reached the write is let through.
+func (l *Limiter) QuotaMiddleware(log *slog.Logger, store QuotaStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "ratelimit.QuotaMiddleware"
+
+			limit := l.Limits().DailyWrites
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			used, ok, err := store.ConsumeQuota(r.Context(), ClientKey(r), limit)
+			if err != nil {
+				log.Error("could not check write quota", "op", op, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				l.limited.Add(1)
+				now := l.now().UTC()
+				midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+				tooManyRequests(w, midnight.Sub(now), "daily write quota exceeded")
+				return
+			}
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(limit-used, 10))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientKey identifies the client a limit applies to: its authenticated
+// principal, or else its address.
+func ClientKey(r *http.Request) string {
+	if p, ok := reqctx.Principal(r.Context()); ok {
+		return "principal:" + p.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(retryAfter), 1)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+)
+
+func newTestLimiter(limits Limits) (*Limiter, *time.Time) {
+	now := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+	l := New(limits)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_RefillsAtRate(t *testing.T) {
+	l, now := newTestLimiter(Limits{Default: Limit{Rate: 2, Burst: 3}})
+
+	for range 3 {
+		assert.True(t, l.Allow("/nums", "a").Allowed)
+	}
+	d := l.Allow("/nums", "a")
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+	assert.True(t, l.Allow("/nums", "b").Allowed, "buckets are per client")
+
+	*now = now.Add(500 * time.Millisecond)
+	d = l.Allow("/nums", "a")
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 0, d.Remaining)
+	assert.Equal(t, 1500*time.Millisecond, d.Reset)
+}
+
+func TestLimiter_PerRouteAndRuntimeChanges(t *testing.T) {
+	l, _ := newTestLimiter(Limits{
+		Default: Limit{Rate: 100, Burst: 100},
+		Routes:  map[string]Limit{"/put-num": {Rate: 1, Burst: 1}},
+	})
+
+	assert.True(t, l.Allow("/put-num", "a").Allowed)
+	assert.False(t, l.Allow("/put-num", "a").Allowed)
+	assert.True(t, l.Allow("/nums", "a").Allowed)
+
+	require.NoError(t, l.SetLimits(Limits{}))
+	assert.True(t, l.Allow("/put-num", "a").Allowed, "rate 0 is unlimited")
+
+	assert.Error(t, l.SetLimits(Limits{Default: Limit{Rate: 1}}))
+	assert.Equal(t, Limits{}, l.Limits(), "invalid limits are not applied")
+}
+
+func TestMiddleware_Answers429WithHeaders(t *testing.T) {
+	l, _ := newTestLimiter(Limits{Default: Limit{Rate: 0.5, Burst: 1}})
+	h := l.Middleware("/nums")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/nums", nil)
+		req = req.WithContext(reqctx.WithPrincipal(req.Context(), domain.Principal{ID: "k1"}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+
+	w = serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+type quotaFunc func(ctx context.Context, client string, limit int64) (int64, bool, error)
+
+func (f quotaFunc) ConsumeQuota(ctx context.Context, client string, limit int64) (int64, bool, error) {
+	return f(ctx, client, limit)
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(Limits{DailyWrites: 2})
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	used := map[string]int64{}
+	var failing bool
+	store := quotaFunc(func(_ context.Context, client string, limit int64) (int64, bool, error) {
+		if failing {
+			return 0, false, errors.New("connection refused")
+		}
+		if used[client] >= limit {
+			return limit, false, nil
+		}
+		used[client]++
+		return used[client], true, nil
+	})
+	h := l.QuotaMiddleware(log, store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/put-num", nil)
+		req.RemoteAddr = "192.0.2.7:4000"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, "1", serve().Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, http.StatusOK, serve().Code)
+	w := serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"), "until UTC midnight")
+	assert.Equal(t, int64(2), used["ip:192.0.2.7"])
+
+	failing = true
+	assert.Equal(t, http.StatusOK, serve().Code, "fails open")
+}
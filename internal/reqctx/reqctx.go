@@ -0,0 +1,146 @@
+// Package reqctx carries per-request hints from the HTTP layer down through
+// the storage decorators.
+package reqctx
+
+import (
+	"context"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+type (
+	freshReadKey      struct{}
+	idempotencyKeyKey struct{}
+	writeTokenKey     struct{}
+	readAfterKey      struct{}
+	metaKey           struct{}
+	principalKey      struct{}
+	requestIDKey      struct{}
+	auditKey          struct{}
+	fullScanKey       struct{}
+)
+
+// WithFreshRead marks ctx so that caching storage layers go to the database
+// instead of answering from cache.
+func WithFreshRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshReadKey{}, true)
+}
+
+func FreshRead(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshReadKey{}).(bool)
+	return fresh
+}
+
+// WithFullScan marks reads carrying ctx as maintenance scans of the whole
+// table, which storage runs without its statement timeout.
+func WithFullScan(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullScanKey{}, true)
+}
+
+func FullScan(ctx context.Context) bool {
+	full, _ := ctx.Value(fullScanKey{}).(bool)
+	return full
+}
+
+// WithIdempotencyKey makes a PutNumber carrying ctx store the number at most
+// once for key and the client in its Meta, returning the original id on
+// repeats. Reusing the key for a different number fails with
+// domain.ErrIdempotencyMismatch.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// WriteToken receives the position of writes made through a context from
+// WithWriteToken. Storage that serves reads from replicas records one; the
+// value is opaque above it.
+type WriteToken struct {
+	mu  sync.Mutex
+	lsn string
+}
+
+func (t *WriteToken) LSN() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.lsn
+}
+
+func WithWriteToken(ctx context.Context) (context.Context, *WriteToken) {
+	token := &WriteToken{}
+	return context.WithValue(ctx, writeTokenKey{}, token), token
+}
+
+// WantsWriteToken reports whether ctx has a WriteToken to record into.
+func WantsWriteToken(ctx context.Context) bool {
+	_, ok := ctx.Value(writeTokenKey{}).(*WriteToken)
+	return ok
+}
+
+// RecordWrite notes that a write through ctx committed at lsn. It does
+// nothing if ctx has no WriteToken.
+func RecordWrite(ctx context.Context, lsn string) {
+	token, ok := ctx.Value(writeTokenKey{}).(*WriteToken)
+	if !ok {
+		return
+	}
+
+	token.mu.Lock()
+	token.lsn = lsn
+	token.mu.Unlock()
+}
+
+// WithReadAfter makes reads carrying ctx observe every write up to lsn, as
+// returned by a WriteToken.
+func WithReadAfter(ctx context.Context, lsn string) context.Context {
+	return context.WithValue(ctx, readAfterKey{}, lsn)
+}
+
+func ReadAfter(ctx context.Context) (string, bool) {
+	lsn, ok := ctx.Value(readAfterKey{}).(string)
+	return lsn, ok && lsn != ""
+}
+
+// WithMeta attaches the provenance stored with a number written through ctx.
+func WithMeta(ctx context.Context, meta domain.Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+func Meta(ctx context.Context) (domain.Meta, bool) {
+	meta, ok := ctx.Value(metaKey{}).(domain.Meta)
+	return meta, ok
+}
+
+// WithPrincipal attaches the authenticated caller of a request.
+func WithPrincipal(ctx context.Context, p domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func Principal(ctx context.Context) (domain.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return p, ok
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithAudit asks storage to record entry in the same transaction as the
+// write made through ctx.
+func WithAudit(ctx context.Context, entry domain.AuditEntry) context.Context {
+	return context.WithValue(ctx, auditKey{}, entry)
+}
+
+func Audit(ctx context.Context) (domain.AuditEntry, bool) {
+	entry, ok := ctx.Value(auditKey{}).(domain.AuditEntry)
+	return entry, ok
+}
@@ -0,0 +1,120 @@
+package resilience
+
+import (
+	"fmt"
+	"sync"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+type state int
+
+const (
+	stateClosed state = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s state) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitOpenError is returned without calling the database while the
+// breaker is open.
+type CircuitOpenError struct {
+	retryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open, retry in %s", e.retryAfter.Round(time.Millisecond))
+}
+
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return usecase.ErrUnavailable
+}
+
+// Breaker opens after threshold consecutive failures and lets a single probe
+// through once cooldown has passed. A successful probe closes it again.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may proceed, or the error to fail fast with.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		wait := b.cooldown - b.now().Sub(b.openedAt)
+		if wait > 0 {
+			return &CircuitOpenError{retryAfter: wait}
+		}
+		b.state, b.probing = stateHalfOpen, true
+		return nil
+	case stateHalfOpen:
+		if b.probing {
+			return &CircuitOpenError{retryAfter: b.cooldown}
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of an allowed call.
+func (b *Breaker) Record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if ok {
+		b.state, b.failures = stateClosed, 0
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt = stateOpen, b.now()
+	}
+}
+
+// Release ends an allowed call that says nothing about the database, such
+// as one its caller gave up on. A probe slot it held goes to the next call.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state.String()
+}
@@ -0,0 +1,72 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// rolledBack lists SQLSTATEs after which the statement is known not to have
+// taken effect.
+var rolledBack = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+	"53300": true, // too_many_connections
+}
+
+// IsTransient reports whether err is likely to go away on its own, such as a
+// refused connection or a serialization failure. A connection attempt that
+// timed out is transient too, even though it wraps
+// context.DeadlineExceeded.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return rolledBack[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		pgconn.SafeToRetry(err)
+}
+
+// Abandoned reports whether err means the caller gave up on a call rather
+// than the database failing it.
+func Abandoned(err error) bool {
+	return (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) && !IsTransient(err)
+}
+
+// SafeToRepeat reports whether a failed write can be sent again without
+// risking a duplicate: either it never reached the server or the server
+// rolled it back.
+func SafeToRepeat(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return rolledBack[pgErr.Code]
+	}
+
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		pgconn.SafeToRetry(err)
+}
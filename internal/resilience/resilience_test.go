@@ -0,0 +1,202 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/usecase"
+	"testovoe/internal/usecase/mocks"
+)
+
+func newTestStorage(t *testing.T, cfg Config) (*Storage, *mocks.Storage) {
+	mockStorage := mocks.NewStorage(t)
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), mockStorage, cfg)
+	s.sleep = func(context.Context, time.Duration) error { return nil }
+	return s, mockStorage
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(fmt.Errorf("dial: %w", syscall.ECONNREFUSED)))
+	assert.True(t, IsTransient(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, IsTransient(&pgconn.PgError{Code: "57P01"}))
+	assert.True(t, IsTransient(&pgconn.PgError{Code: "08006"}))
+
+	assert.False(t, IsTransient(nil))
+	assert.False(t, IsTransient(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.False(t, IsTransient(context.DeadlineExceeded))
+	assert.False(t, IsTransient(errors.New("boom")))
+}
+
+// blackholedConnect returns the error of a connection attempt whose dialer
+// never answers.
+func blackholedConnect(t *testing.T) error {
+	cfg, err := pgconn.ParseConfig("host=192.0.2.1 port=5432 user=app dbname=app sslmode=disable")
+	require.NoError(t, err)
+	cfg.ConnectTimeout = 20 * time.Millisecond
+	cfg.DialFunc = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	conn, err := pgconn.ConnectConfig(context.Background(), cfg)
+	if conn != nil {
+		_ = conn.Close(context.Background())
+	}
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	return err
+}
+
+func TestStorage_ConnectTimeoutTripsBreaker(t *testing.T) {
+	s, mockStorage := newTestStorage(t, Config{Retries: 0, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	timeout := blackholedConnect(t)
+	assert.True(t, IsTransient(timeout))
+	assert.False(t, Abandoned(timeout))
+
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{}, timeout).Twice()
+	for range 2 {
+		_, err := s.GetStats(context.Background())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	_, err := s.GetStats(context.Background())
+	assert.ErrorIs(t, err, usecase.ErrUnavailable)
+	assert.Equal(t, "open", s.breaker.State())
+}
+
+func TestStorage_AbandonedCallReleasesProbe(t *testing.T) {
+	s, mockStorage := newTestStorage(t, Config{Retries: 0, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	now := time.Unix(0, 0)
+	s.breaker.now = func() time.Time { return now }
+
+	s.breaker.Record(false)
+	now = now.Add(time.Minute)
+
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{}, context.Canceled).Once()
+	_, err := s.GetStats(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "half-open", s.breaker.State())
+
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 1}, nil).Once()
+	_, err = s.GetStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", s.breaker.State())
+}
+
+func TestStorage_RetriesTransientReads(t *testing.T) {
+	s, mockStorage := newTestStorage(t, Config{Retries: 3, BreakerThreshold: 10})
+
+	mockStorage.EXPECT().GetSlice(mock.Anything).Return(nil, &pgconn.PgError{Code: "40001"}).Twice()
+	mockStorage.EXPECT().GetSlice(mock.Anything).Return([]domain.Number{domain.NewInt(1)}, nil).Once()
+
+	numbers, err := s.GetSlice(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, numbers, 1)
+}
+
+func TestStorage_DoesNotRepeatAmbiguousWrites(t *testing.T) {
+	s, mockStorage := newTestStorage(t, Config{Retries: 3, BreakerThreshold: 10})
+
+	lost := fmt.Errorf("read: %w", syscall.ECONNRESET)
+	mockStorage.EXPECT().PutNumber(mock.Anything, domain.NewInt(1)).Return(int64(0), lost).Once()
+
+	_, err := s.PutNumber(context.Background(), domain.NewInt(1))
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+
+	mockStorage.EXPECT().PutNumber(mock.Anything, domain.NewInt(2)).Return(int64(0), &pgconn.PgError{Code: "40001"}).Once()
+	mockStorage.EXPECT().PutNumber(mock.Anything, domain.NewInt(2)).Return(int64(7), nil).Once()
+
+	id, err := s.PutNumber(context.Background(), domain.NewInt(2))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+}
+
+func TestStorage_BreakerFailsFast(t *testing.T) {
+	s, mockStorage := newTestStorage(t, Config{Retries: 0, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	now := time.Unix(0, 0)
+	s.breaker.now = func() time.Time { return now }
+
+	down := fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{}, down).Twice()
+
+	for range 2 {
+		_, err := s.GetStats(context.Background())
+		assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	}
+
+	_, err := s.GetStats(context.Background())
+	assert.ErrorIs(t, err, usecase.ErrUnavailable)
+	var open *CircuitOpenError
+	require.ErrorAs(t, err, &open)
+	assert.Equal(t, time.Minute, open.RetryAfter())
+	assert.Equal(t, "open", s.breaker.State())
+
+	now = now.Add(time.Minute)
+	mockStorage.EXPECT().GetStats(mock.Anything).Return(domain.Stats{Count: 1}, nil).Once()
+
+	stats, err := s.GetStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Count)
+	assert.Equal(t, "closed", s.breaker.State())
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	b := NewBreaker(1, time.Second)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	b.Record(false)
+	assert.Error(t, b.Allow())
+
+	now = now.Add(time.Second)
+	assert.NoError(t, b.Allow())
+	assert.Error(t, b.Allow(), "only one probe while half-open")
+
+	b.Record(false)
+	assert.Equal(t, "open", b.State())
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 10 {
+		d := Backoff(attempt, 10*time.Millisecond, 200*time.Millisecond)
+		ceiling := min(10*time.Millisecond<<attempt, 200*time.Millisecond)
+		assert.GreaterOrEqual(t, d, ceiling/2)
+		assert.Less(t, d, ceiling+1)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	calls := 0
+	err := WaitFor(context.Background(), log, time.Second, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return syscall.ECONNREFUSED
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	err = WaitFor(context.Background(), log, 50*time.Millisecond, func(context.Context) error {
+		return syscall.ECONNREFUSED
+	})
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,176 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+type Config struct {
+	// Retries is how many times a transient failure is retried.
+	Retries   int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Storage retries transient storage failures with jittered exponential
+// backoff and fails fast through a circuit breaker while the database is
+// down. Writes are only repeated when they cannot have been applied.
+type Storage struct {
+	usecase.Storage
+
+	log     *slog.Logger
+	cfg     Config
+	breaker *Breaker
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func NewStorage(log *slog.Logger, storage usecase.Storage, cfg Config) *Storage {
+	return &Storage{
+		Storage: storage,
+		log:     log,
+		cfg:     cfg,
+		breaker: NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		sleep:   sleep,
+	}
+}
+
+func (s *Storage) Breaker() *Breaker {
+	return s.breaker
+}
+
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	// A keyed write is idempotent, so even an ambiguous failure can be
+	// retried.
+	retryable := SafeToRepeat
+	if _, keyed := reqctx.IdempotencyKey(ctx); keyed {
+		retryable = IsTransient
+	}
+
+	var id int64
+	err := s.do(ctx, "storage.PutNumber", retryable, func(ctx context.Context) (err error) {
+		id, err = s.Storage.PutNumber(ctx, num)
+		return err
+	})
+	return id, err
+}
+
+func (s *Storage) GetSlice(ctx context.Context) ([]domain.Number, error) {
+	var numbers []domain.Number
+	err := s.do(ctx, "storage.GetSlice", IsTransient, func(ctx context.Context) (err error) {
+		numbers, err = s.Storage.GetSlice(ctx)
+		return err
+	})
+	return numbers, err
+}
+
+func (s *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	var records []domain.Record
+	err := s.do(ctx, "storage.GetRecords", IsTransient, func(ctx context.Context) (err error) {
+		records, err = s.Storage.GetRecords(ctx, order)
+		return err
+	})
+	return records, err
+}
+
+func (s *Storage) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	var records []domain.Record
+	err := s.do(ctx, "storage.FindRecords", IsTransient, func(ctx context.Context) (err error) {
+		records, err = s.Storage.FindRecords(ctx, q)
+		return err
+	})
+	return records, err
+}
+
+func (s *Storage) GetStats(ctx context.Context) (domain.Stats, error) {
+	var stats domain.Stats
+	err := s.do(ctx, "storage.GetStats", IsTransient, func(ctx context.Context) (err error) {
+		stats, err = s.Storage.GetStats(ctx)
+		return err
+	})
+	return stats, err
+}
+
+func (s *Storage) do(ctx context.Context, op string, retryable func(error) bool, call func(context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := s.breaker.Allow()
+		if err != nil {
+			return err
+		}
+
+		err = call(ctx)
+		// Only failures that point at the database being unhealthy trip
+		// the breaker; a rejected value says nothing about availability,
+		// and neither does a caller giving up.
+		if Abandoned(err) {
+			s.breaker.Release()
+		} else {
+			s.breaker.Record(!IsTransient(err))
+		}
+		if err == nil || attempt >= s.cfg.Retries || !retryable(err) {
+			return err
+		}
+
+		delay := Backoff(attempt, s.cfg.BaseDelay, s.cfg.MaxDelay)
+		s.log.WarnContext(ctx, "retrying storage call", "op", op, "attempt", attempt+1, "delay", delay, "error", err)
+		if err := s.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Backoff returns the delay before retry number attempt+1: exponential growth
+// capped at maxDelay, with the upper half randomized so that callers which
+// failed together do not retry together.
+func Backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	d := base << min(attempt, 30)
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+// WaitFor calls ping with backoff until it succeeds or timeout passes.
+func WaitFor(ctx context.Context, log *slog.Logger, timeout time.Duration, ping func(context.Context) error) error {
+	const op = "resilience.WaitFor"
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		delay := Backoff(attempt, 100*time.Millisecond, 2*time.Second)
+		log.Info("waiting for database", "op", op, "attempt", attempt+1, "error", err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("%s: database not reachable within %s: %w", op, timeout, err)
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
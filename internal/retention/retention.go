@@ -0,0 +1,119 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testovoe/internal/domain"
+	"time"
+)
+
+// Enforcer removes the numbers a policy no longer keeps. ahead is how many
+// days of partitions to create in advance when storage is partitioned.
+type Enforcer interface {
+	EnforceRetention(ctx context.Context, policy domain.RetentionPolicy, ahead int) (domain.RetentionReport, error)
+}
+
+// Job applies a retention policy and tells the layers that keep copies of
+// the data, such as the index and the cache, when numbers were removed.
+type Job struct {
+	log      *slog.Logger
+	enforcer Enforcer
+	policy   domain.RetentionPolicy
+	ahead    int
+
+	mu        sync.Mutex
+	onRemoved []func(context.Context)
+
+	runs     atomic.Int64
+	failures atomic.Int64
+	last     atomic.Pointer[Run]
+}
+
+// Run is the outcome of one enforcement.
+type Run struct {
+	At     time.Time              `json:"at"`
+	Report domain.RetentionReport `json:"report"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+type Metrics struct {
+	Runs     int64 `json:"runs"`
+	Failures int64 `json:"failures"`
+	Last     *Run  `json:"last,omitempty"`
+}
+
+func New(log *slog.Logger, enforcer Enforcer, policy domain.RetentionPolicy, ahead int) *Job {
+	return &Job{log: log, enforcer: enforcer, policy: policy, ahead: ahead}
+}
+
+// OnRemoved registers fn to be called after a run that removed numbers.
+func (j *Job) OnRemoved(fn func(context.Context)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.onRemoved = append(j.onRemoved, fn)
+}
+
+// Enforce applies the policy once and reports what it removed.
+func (j *Job) Enforce(ctx context.Context) (domain.RetentionReport, error) {
+	const op = "retention.Enforce"
+
+	j.runs.Add(1)
+	report, err := j.enforcer.EnforceRetention(ctx, j.policy, j.ahead)
+	run := &Run{At: time.Now(), Report: report}
+	if err != nil {
+		j.failures.Add(1)
+		run.Error = err.Error()
+		j.last.Store(run)
+		return domain.RetentionReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+	j.last.Store(run)
+
+	if report.Removed() > 0 || len(report.CreatedPartitions) > 0 {
+		j.log.Info("retention applied",
+			"op", op,
+			"deleted", report.Deleted,
+			"archived", report.Archived,
+			"dropped_partitions", report.DroppedPartitions,
+			"archived_partitions", report.ArchivedPartitions,
+			"created_partitions", report.CreatedPartitions,
+		)
+	}
+
+	if report.Removed() > 0 {
+		j.mu.Lock()
+		hooks := j.onRemoved
+		j.mu.Unlock()
+		for _, fn := range hooks {
+			fn(ctx)
+		}
+	}
+	return report, nil
+}
+
+// Run enforces the policy every interval until ctx is done.
+func (j *Job) Run(ctx context.Context, interval time.Duration) {
+	const op = "retention.Run"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := j.Enforce(ctx)
+			if err != nil {
+				j.log.Error("failed to apply retention", "op", op, "error", err)
+			}
+		}
+	}
+}
+
+func (j *Job) Metrics() Metrics {
+	return Metrics{Runs: j.runs.Load(), Failures: j.failures.Load(), Last: j.last.Load()}
+}
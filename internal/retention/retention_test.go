@@ -0,0 +1,72 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+)
+
+type enforcerFunc func(ctx context.Context, policy domain.RetentionPolicy, ahead int) (domain.RetentionReport, error)
+
+func (f enforcerFunc) EnforceRetention(ctx context.Context, policy domain.RetentionPolicy, ahead int) (domain.RetentionReport, error) {
+	return f(ctx, policy, ahead)
+}
+
+func newTestJob(f enforcerFunc) *Job {
+	policy := domain.RetentionPolicy{MaxAge: time.Hour, MaxRows: 100}
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), f, policy, 3)
+}
+
+func TestJob_NotifiesAfterRemoval(t *testing.T) {
+	reports := []domain.RetentionReport{
+		{Deleted: 2, DroppedPartitions: []string{"nums_p20261016"}},
+		{},
+	}
+	job := newTestJob(func(_ context.Context, policy domain.RetentionPolicy, ahead int) (domain.RetentionReport, error) {
+		assert.Equal(t, int64(100), policy.MaxRows)
+		assert.Equal(t, 3, ahead)
+		report := reports[0]
+		reports = reports[1:]
+		return report, nil
+	})
+
+	notified := 0
+	job.OnRemoved(func(context.Context) { notified++ })
+
+	report, err := job.Enforce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Removed())
+	assert.Equal(t, 1, notified)
+
+	_, err = job.Enforce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, notified, "nothing removed, nothing to refresh")
+
+	m := job.Metrics()
+	assert.Equal(t, int64(2), m.Runs)
+	assert.Zero(t, m.Failures)
+	require.NotNil(t, m.Last)
+	assert.Empty(t, m.Last.Error)
+}
+
+func TestJob_ReportsFailure(t *testing.T) {
+	job := newTestJob(func(context.Context, domain.RetentionPolicy, int) (domain.RetentionReport, error) {
+		return domain.RetentionReport{}, errors.New("lock timeout")
+	})
+	job.OnRemoved(func(context.Context) { t.Fatal("must not be called") })
+
+	_, err := job.Enforce(context.Background())
+
+	assert.ErrorContains(t, err, "lock timeout")
+	m := job.Metrics()
+	assert.Equal(t, int64(1), m.Failures)
+	assert.Equal(t, "lock timeout", m.Last.Error)
+}
@@ -0,0 +1,216 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+type Entry struct {
+	Key  string        `json:"key"`
+	Num  domain.Number `json:"num"`
+	Meta *domain.Meta  `json:"meta,omitempty"`
+	// Audit is recorded with the number once it is replayed.
+	Audit *domain.AuditEntry `json:"audit,omitempty"`
+}
+
+// Log is an append-only file of entries waiting to be stored. Every append
+// is fsynced before it returns. Acknowledged entries are skipped through an
+// offset kept in a sidecar file, and the log is truncated once it drains.
+type Log struct {
+	mu         sync.Mutex
+	file       *os.File
+	offsetPath string
+
+	entries []Entry
+	// ends holds the file offset just past each pending entry.
+	ends []int64
+}
+
+func Open(path string) (*Log, error) {
+	const op = "spool.Open"
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	l := &Log{file: file, offsetPath: path + ".offset"}
+	err = l.load()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return l, nil
+}
+
+// load reads the entries past the saved offset. A torn last line, left by a
+// crash during an append that was never acknowledged, is cut off.
+func (l *Log) load() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset, err := l.readOffset()
+	if err != nil {
+		return err
+	}
+	if offset > info.Size() {
+		// The log was truncated but the crash came before the offset reset.
+		offset = 0
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(l.file, offset, info.Size()-offset))
+	pos := offset
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return l.file.Truncate(pos)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var e Entry
+		err = json.Unmarshal(bytes.TrimSpace(line), &e)
+		if err != nil {
+			return fmt.Errorf("corrupt entry at offset %d: %w", pos, err)
+		}
+		pos += int64(len(line))
+		l.entries = append(l.entries, e)
+		l.ends = append(l.ends, pos)
+	}
+}
+
+func (l *Log) Append(e Entry) error {
+	const op = "spool.Append"
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = l.file.Write(line)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// Drop whatever part of the line made it, so the next append does
+		// not land after garbage.
+		_ = l.file.Truncate(info.Size())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.entries = append(l.entries, e)
+	l.ends = append(l.ends, info.Size()+int64(len(line)))
+	return nil
+}
+
+// Peek returns the oldest pending entry.
+func (l *Log) Peek() (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return Entry{}, false
+	}
+	return l.entries[0], true
+}
+
+// Ack marks the oldest pending entry as stored, if its key is key. An
+// entry acknowledged already is not acknowledged again, so a stale ack
+// cannot drop the entry behind it.
+func (l *Log) Ack(key string) error {
+	const op = "spool.Ack"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 || l.entries[0].Key != key {
+		return nil
+	}
+	end := l.ends[0]
+	l.entries, l.ends = l.entries[1:], l.ends[1:]
+
+	if len(l.entries) == 0 {
+		err := l.file.Truncate(0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		end = 0
+	}
+
+	err := l.writeOffset(end)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (l *Log) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.entries)
+}
+
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+func (l *Log) readOffset() (int64, error) {
+	data, err := os.ReadFile(l.offsetPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// writeOffset replaces the offset file atomically.
+func (l *Log) writeOffset(offset int64) error {
+	tmp := l.offsetPath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(strconv.FormatInt(offset, 10))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.offsetPath)
+}
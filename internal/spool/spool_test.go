@@ -0,0 +1,319 @@
+package spool
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/index"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/resilience"
+	"testovoe/internal/storage/memory"
+	"testovoe/internal/usecase"
+)
+
+func TestLog_ReopenKeepsPendingEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool", "numbers.log")
+
+	l, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Append(Entry{Key: "a", Num: domain.NewInt(1)}))
+	require.NoError(t, l.Append(Entry{Key: "b", Num: domain.NewInt(2)}))
+	require.NoError(t, l.Append(Entry{Key: "c", Num: domain.NewInt(3)}))
+	require.NoError(t, l.Ack("a"))
+	require.NoError(t, l.Close())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, 2, l.Len())
+	e, ok := l.Peek()
+	require.True(t, ok)
+	assert.Equal(t, Entry{Key: "b", Num: domain.NewInt(2)}, e)
+}
+
+func TestLog_TornLastLineIsDropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers.log")
+
+	l, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Append(Entry{Key: "a", Num: domain.NewInt(1)}))
+	require.NoError(t, l.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"key":"b","nu`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, l.Len())
+
+	require.NoError(t, l.Append(Entry{Key: "c", Num: domain.NewInt(3)}))
+	require.NoError(t, l.Close())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, 2, l.Len())
+}
+
+func TestLog_DrainedLogIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers.log")
+
+	l, err := Open(path)
+	require.NoError(t, err)
+	defer l.Close()
+	require.NoError(t, l.Append(Entry{Key: "a", Num: domain.NewInt(1)}))
+	require.NoError(t, l.Ack("a"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	_, ok := l.Peek()
+	assert.False(t, ok)
+}
+
+func TestLog_StaleAckKeepsNextEntry(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "numbers.log"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.NoError(t, l.Append(Entry{Key: "a", Num: domain.NewInt(1)}))
+	require.NoError(t, l.Append(Entry{Key: "b", Num: domain.NewInt(2)}))
+	require.NoError(t, l.Ack("a"))
+	require.NoError(t, l.Ack("a"))
+
+	e, ok := l.Peek()
+	require.True(t, ok)
+	assert.Equal(t, "b", e.Key)
+}
+
+// flakyStorage fails every write while down is set.
+type flakyStorage struct {
+	*memory.Storage
+	down atomic.Bool
+}
+
+func (s *flakyStorage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	if s.down.Load() {
+		return 0, &resilience.CircuitOpenError{}
+	}
+	return s.Storage.PutNumber(ctx, num)
+}
+
+func newTestSpool(t *testing.T) (*Storage, *flakyStorage) {
+	l, err := Open(filepath.Join(t.TempDir(), "numbers.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	inner := &flakyStorage{Storage: memory.New()}
+	return NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), inner, l), inner
+}
+
+func TestStorage_SpoolsWhileUnavailableAndReplaysInOrder(t *testing.T) {
+	s, inner := newTestSpool(t)
+	ctx := context.Background()
+
+	_, err := s.PutNumber(ctx, domain.NewInt(1))
+	require.NoError(t, err)
+
+	inner.down.Store(true)
+	_, err = s.PutNumber(ctx, domain.NewInt(2))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+
+	inner.down.Store(false)
+	// Queued behind the spooled write even though storage is back.
+	_, err = s.PutNumber(ctx, domain.NewInt(3))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+	assert.Equal(t, 2, s.Metrics().Depth)
+
+	require.NoError(t, s.Drain(ctx))
+
+	records, err := inner.GetRecords(ctx, domain.OrderInserted)
+	require.NoError(t, err)
+	nums := make([]domain.Number, len(records))
+	for i, rec := range records {
+		nums[i] = rec.Num
+	}
+	assert.Equal(t, []domain.Number{domain.NewInt(1), domain.NewInt(2), domain.NewInt(3)}, nums)
+	assert.Equal(t, Metrics{Depth: 0, Replayed: 2}, s.Metrics())
+}
+
+func TestStorage_DrainStopsWhileUnavailable(t *testing.T) {
+	s, inner := newTestSpool(t)
+	ctx := context.Background()
+
+	inner.down.Store(true)
+	_, err := s.PutNumber(ctx, domain.NewInt(1))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+
+	err = s.Drain(ctx)
+	assert.ErrorIs(t, err, usecase.ErrUnavailable)
+	assert.Equal(t, 1, s.Metrics().Depth)
+}
+
+func TestStorage_ReplayIsIdempotent(t *testing.T) {
+	s, inner := newTestSpool(t)
+	ctx := context.Background()
+
+	inner.down.Store(true)
+	_, err := s.PutNumber(reqctx.WithIdempotencyKey(ctx, "k1"), domain.NewInt(7))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+	inner.down.Store(false)
+
+	// The first attempt landed but the ack was lost.
+	_, err = inner.PutNumber(reqctx.WithIdempotencyKey(ctx, "k1"), domain.NewInt(7))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Drain(ctx))
+
+	nums, err := inner.GetSlice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Number{domain.NewInt(7)}, nums)
+}
+
+func TestStorage_ConcurrentDrainsLoseNothing(t *testing.T) {
+	s, inner := newTestSpool(t)
+	ctx := context.Background()
+
+	inner.down.Store(true)
+	for i := range int64(50) {
+		_, err := s.PutNumber(ctx, domain.NewInt(i))
+		require.ErrorIs(t, err, usecase.ErrPending)
+	}
+	inner.down.Store(false)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() { assert.NoError(t, s.Drain(ctx)) })
+	}
+	wg.Wait()
+
+	nums, err := inner.GetSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, nums, 50)
+	assert.Equal(t, 0, s.Metrics().Depth)
+}
+
+// gatedStorage holds every write until gate is closed.
+type gatedStorage struct {
+	*flakyStorage
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gatedStorage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.gate
+	return s.flakyStorage.PutNumber(ctx, num)
+}
+
+func TestStorage_WriteDuringReplayIsSpooled(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "numbers.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	require.NoError(t, l.Append(Entry{Key: "a", Num: domain.NewInt(1)}))
+
+	inner := &gatedStorage{
+		flakyStorage: &flakyStorage{Storage: memory.New()},
+		started:      make(chan struct{}, 1),
+		gate:         make(chan struct{}),
+	}
+	s := NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)), inner, l)
+	ctx := context.Background()
+
+	drained := make(chan error, 1)
+	go func() { drained <- s.Drain(ctx) }()
+	<-inner.started
+
+	// The spooled entry is being stored; a write now neither waits for it
+	// nor slips in ahead of it.
+	_, err = s.PutNumber(ctx, domain.NewInt(2))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+
+	close(inner.gate)
+	require.NoError(t, <-drained)
+
+	records, err := inner.GetRecords(ctx, domain.OrderInserted)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, domain.NewInt(1), records[0].Num)
+	assert.Equal(t, domain.NewInt(2), records[1].Num)
+	assert.Equal(t, 0, s.Metrics().Depth)
+}
+
+func TestStorage_ReplayKeepsMetadata(t *testing.T) {
+	s, inner := newTestSpool(t)
+	ctx := reqctx.WithMeta(context.Background(), domain.Meta{Source: "sensor", Labels: map[string]string{"env": "prod"}})
+
+	inner.down.Store(true)
+	before := time.Now()
+	_, err := s.PutNumber(ctx, domain.NewInt(1))
+	assert.ErrorIs(t, err, usecase.ErrPending)
+	inner.down.Store(false)
+
+	require.NoError(t, s.Drain(context.Background()))
+
+	records, err := inner.FindRecords(context.Background(), domain.RecordQuery{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	meta := records[0].Meta
+	assert.Equal(t, "sensor", meta.Source)
+	assert.Equal(t, map[string]string{"env": "prod"}, meta.Labels)
+	assert.False(t, meta.CreatedAt.Before(before), "stamped when accepted, not when replayed")
+}
+
+// scanlessStorage fails the test when the use case falls back to sorting a
+// full snapshot.
+type scanlessStorage struct {
+	*memory.Storage
+	t *testing.T
+}
+
+func (s *scanlessStorage) GetSlice(ctx context.Context) ([]domain.Number, error) {
+	s.t.Error("rank query fell back to a full scan")
+	return s.Storage.GetSlice(ctx)
+}
+
+func TestStorage_RankUsesIndexBeneathSpool(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "numbers.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	indexed := index.NewStorage(log, &scanlessStorage{Storage: memory.New(), t: t})
+	require.NoError(t, indexed.Resync(ctx))
+	useCase := usecase.NewUseCase(log, NewStorage(log, indexed, l))
+
+	for _, n := range []int64{5, 1, 3} {
+		require.NoError(t, useCase.PutNumber(ctx, domain.NewInt(n)))
+	}
+
+	rank, total, err := useCase.Rank(ctx, domain.NewInt(4))
+	require.NoError(t, err)
+	assert.Equal(t, 2, rank)
+	assert.Equal(t, 3, total)
+
+	lo := domain.NewInt(2)
+	nums, err := useCase.Range(ctx, usecase.RangeQuery{Min: &lo})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Number{domain.NewInt(3), domain.NewInt(5)}, nums)
+}
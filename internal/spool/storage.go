@@ -0,0 +1,185 @@
+package spool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/resilience"
+	"testovoe/internal/usecase"
+	"time"
+)
+
+// Storage spools writes to a local Log while the wrapped storage is
+// unavailable and replays them in order once it is back. Each spooled number
+// carries an idempotency key, so a replay interrupted after the insert
+// committed does not store it twice.
+type Storage struct {
+	usecase.Storage
+
+	log      *slog.Logger
+	spool    *Log
+	wake     chan struct{}
+	replayed atomic.Int64
+
+	// drainMu serializes Drain, which Run and the admin API may both call.
+	// While draining is set, writes go to the spool too, so none of them
+	// overtakes a replay in progress.
+	drainMu  sync.Mutex
+	draining atomic.Bool
+}
+
+type Metrics struct {
+	Depth    int   `json:"depth"`
+	Replayed int64 `json:"replayed"`
+}
+
+func NewStorage(log *slog.Logger, storage usecase.Storage, spool *Log) *Storage {
+	return &Storage{
+		Storage: storage,
+		log:     log,
+		spool:   spool,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// PutNumber stores num directly when nothing is spooled and no replay is
+// running. Otherwise, or when storage is unavailable, num is appended to the
+// spool and usecase.ErrPending is returned. Writes queue behind the spool so
+// that numbers are stored in the order they were accepted.
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	const op = "spool.PutNumber"
+
+	if s.spool.Len() == 0 && !s.draining.Load() {
+		id, err := s.Storage.PutNumber(ctx, num)
+		if err == nil || !canSpool(err) {
+			return id, err
+		}
+		s.log.WarnContext(ctx, "storage unavailable, spooling number", "op", op, "error", err)
+	}
+
+	key, _ := reqctx.IdempotencyKey(ctx)
+	if key == "" {
+		key = newKey()
+	}
+
+	// The number is stored later, but was accepted now.
+	meta, _ := reqctx.Meta(ctx)
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = time.Now()
+	}
+
+	e := Entry{Key: key, Num: num, Meta: &meta}
+	if entry, ok := reqctx.Audit(ctx); ok {
+		e.Audit = &entry
+	}
+
+	err := s.spool.Append(e)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	s.notify()
+
+	return 0, usecase.ErrPending
+}
+
+// Unwrap returns the wrapped storage, so that the use case finds an index
+// beneath the spool. Spooled numbers join it once they are replayed.
+func (s *Storage) Unwrap() usecase.Storage {
+	return s.Storage
+}
+
+func (s *Storage) Metrics() Metrics {
+	return Metrics{Depth: s.spool.Len(), Replayed: s.replayed.Load()}
+}
+
+func (s *Storage) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run replays the spool whenever a number is spooled and every interval
+// after a failed attempt, until ctx is done.
+func (s *Storage) Run(ctx context.Context, interval time.Duration) {
+	const op = "spool.Run"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.Drain(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Warn("spool replay paused", "op", op, "depth", s.spool.Len(), "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain stores spooled numbers oldest first and stops at the first one that
+// cannot be stored because storage is unavailable.
+func (s *Storage) Drain(ctx context.Context) error {
+	const op = "spool.Drain"
+
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	s.draining.Store(true)
+	defer s.draining.Store(false)
+
+	for {
+		e, ok := s.spool.Peek()
+		if !ok {
+			return nil
+		}
+
+		putCtx := reqctx.WithIdempotencyKey(ctx, e.Key)
+		if e.Meta != nil {
+			putCtx = reqctx.WithMeta(putCtx, *e.Meta)
+		}
+		if e.Audit != nil {
+			putCtx = reqctx.WithAudit(putCtx, *e.Audit)
+		}
+		_, err := s.Storage.PutNumber(putCtx, e.Num)
+		if err != nil && (errors.Is(err, usecase.ErrUnavailable) || resilience.IsTransient(err) || ctx.Err() != nil) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err != nil {
+			// Retrying will not help and would block every entry behind it.
+			s.log.Error("dropping spooled number", "op", op, "key", e.Key, "num", e.Num, "error", err)
+		} else {
+			s.replayed.Add(1)
+		}
+
+		err = s.spool.Ack(e.Key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+}
+
+// canSpool reports whether a failed write can be spooled: storage is down,
+// or the write is known not to have been applied, so storing it later cannot
+// duplicate it.
+func canSpool(err error) bool {
+	return errors.Is(err, usecase.ErrUnavailable) || resilience.SafeToRepeat(err)
+}
+
+func newKey() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testovoe/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	const op = "storage.CreateAPIKey"
+
+	if key.Collections == nil {
+		key.Collections = []string{}
+	}
+	err := s.db.QueryRow(ctx, `INSERT INTO api_keys (id, name, hash, scopes, collections)
+		VALUES ($1, $2, $3, $4, $5) RETURNING created_at`,
+		key.ID, key.Name, key.Hash, key.Scopes, key.Collections).Scan(&key.CreatedAt)
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("%s: could not store key: %w", op, err)
+	}
+	return key, nil
+}
+
+// APIKey returns the key with id, revoked or not. Keys are always read from
+// the primary so that a revocation applies at once.
+func (s *Storage) APIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	const op = "storage.APIKey"
+
+	rows, err := s.db.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("%s: could not fetch key: %w", op, err)
+	}
+	key, err := pgx.CollectExactlyOneRow(rows, scanAPIKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.APIKey{}, fmt.Errorf("%s: %q: %w", op, id, domain.ErrKeyNotFound)
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("%s: could not fetch key: %w", op, err)
+	}
+	return key, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	const op = "storage.ListAPIKeys"
+
+	rows, err := s.db.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not fetch keys: %w", op, err)
+	}
+	keys, err := pgx.CollectRows(rows, scanAPIKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not fetch keys: %w", op, err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key with id revoked. Revoking it again is a no-op.
+func (s *Storage) RevokeAPIKey(ctx context.Context, id string) error {
+	const op = "storage.RevokeAPIKey"
+
+	tag, err := s.db.Exec(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("%s: could not revoke key: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %q: %w", op, id, domain.ErrKeyNotFound)
+	}
+	return nil
+}
+
+const apiKeyColumns = "id, name, hash, scopes, collections, created_at, revoked_at"
+
+func scanAPIKey(row pgx.CollectableRow) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Hash, &key.Scopes, &key.Collections, &key.CreatedAt, &key.RevokedAt)
+	if len(key.Collections) == 0 {
+		key.Collections = nil
+	}
+	return key, err
+}
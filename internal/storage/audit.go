@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testovoe/internal/audit"
+	"testovoe/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// rowQuerier is the part of a pool or transaction that inserts use.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// appendAudit chains entry onto the audit log. Postgres computes the hash the
+// same way as audit.Hash.
+const appendAudit = `WITH head AS (
+	UPDATE audit_head SET seq = seq + 1,
+		hash = encode(sha256(decode(hash, 'hex') || convert_to($1, 'UTF8')), 'hex')
+	RETURNING seq, hash)
+	INSERT INTO audit_log (seq, at, principal, operation, request_id, outcome, num_id, entry, hash)
+	SELECT seq, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, 0), $1, hash FROM head
+	RETURNING seq`
+
+func recordAudit(ctx context.Context, q rowQuerier, entry domain.AuditEntry) error {
+	var seq int64
+	return q.QueryRow(ctx, appendAudit, string(entry.Canonical()), entry.At, entry.Principal,
+		entry.Operation, entry.RequestID, entry.Outcome, entry.NumID).Scan(&seq)
+}
+
+// RecordAudit appends entry to the audit log on its own.
+func (s *Storage) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	const op = "storage.RecordAudit"
+
+	err := recordAudit(ctx, s.db, entry)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) FindAudit(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	const op = "storage.FindAudit"
+
+	var (
+		conds = []string{"seq > $1"}
+		args  = []any{q.AfterSeq}
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if q.Principal != "" {
+		add("principal = $%d", q.Principal)
+	}
+	if q.Operation != "" {
+		add("operation = $%d", q.Operation)
+	}
+	if q.Outcome != "" {
+		add("outcome = $%d", q.Outcome)
+	}
+	if q.RequestID != "" {
+		add("request_id = $%d", q.RequestID)
+	}
+	if q.NumID != 0 {
+		add("num_id = $%d", q.NumID)
+	}
+	if !q.Since.IsZero() {
+		add("at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		add("at < $%d", q.Until)
+	}
+
+	query := "SELECT seq, entry, hash FROM audit_log WHERE " + strings.Join(conds, " AND ") + " ORDER BY seq"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AuditEntry, error) {
+		var (
+			entry     domain.AuditEntry
+			seq       int64
+			raw, hash string
+		)
+		err := row.Scan(&seq, &raw, &hash)
+		if err != nil {
+			return entry, err
+		}
+		err = json.Unmarshal([]byte(raw), &entry)
+		entry.Seq, entry.Hash = seq, hash
+		return entry, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// VerifyAudit recomputes the whole chain and checks it ends at the head. It
+// returns how many entries it checked; a broken link wraps
+// audit.ErrChainBroken.
+func (s *Storage) VerifyAudit(ctx context.Context) (int64, error) {
+	const op = "storage.VerifyAudit"
+
+	var checked int64
+	err := pgx.BeginTxFunc(ctx, s.db, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		var headSeq int64
+		var headHash string
+		err := tx.QueryRow(ctx, "SELECT seq, hash FROM audit_head").Scan(&headSeq, &headHash)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "SELECT seq, entry, hash FROM audit_log ORDER BY seq")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		prev := audit.Genesis
+		var (
+			seq       int64
+			raw, hash string
+		)
+		_, err = pgx.ForEachRow(rows, []any{&seq, &raw, &hash}, func() error {
+			if seq != checked+1 {
+				return fmt.Errorf("entry %d: %w: sequence jumps to %d", checked+1, audit.ErrChainBroken, seq)
+			}
+			if audit.Hash(prev, []byte(raw)) != hash {
+				return fmt.Errorf("entry %d: %w: hash mismatch", seq, audit.ErrChainBroken)
+			}
+			prev = hash
+			checked++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if checked != headSeq || prev != headHash {
+			return fmt.Errorf("%w: log ends at %d, head at %d", audit.ErrChainBroken, checked, headSeq)
+		}
+		return nil
+	})
+	if err != nil {
+		return checked, fmt.Errorf("%s: %w", op, err)
+	}
+	return checked, nil
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"time"
+)
+
+// Storage keeps numbers in process memory. It backs in-process load tests and
+// benchmarks where a database would dominate the measurement.
+type Storage struct {
+	mu    sync.RWMutex
+	nums  []domain.Number
+	metas []domain.Meta
+	keys  map[idempotencyKey]claim
+}
+
+// Idempotency keys are scoped by client, as in Postgres.
+type idempotencyKey struct{ client, key string }
+
+type claim struct {
+	id   int64
+	hash string
+}
+
+func New() *Storage {
+	return &Storage{keys: make(map[idempotencyKey]claim)}
+}
+
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, _ := reqctx.Meta(ctx)
+	key, keyed := reqctx.IdempotencyKey(ctx)
+	scoped := idempotencyKey{client: meta.Client, key: key}
+	hash := domain.RequestHash(num)
+	if c, ok := s.keys[scoped]; keyed && ok {
+		if c.hash != hash {
+			return 0, domain.ErrIdempotencyMismatch
+		}
+		return c.id, nil
+	}
+
+	id := s.put(num, meta)
+	if keyed {
+		s.keys[scoped] = claim{id: id, hash: hash}
+	}
+	return id, nil
+}
+
+func (s *Storage) PutNumbers(ctx context.Context, nums []domain.Number, metas []domain.Meta) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, len(nums))
+	for i, num := range nums {
+		var meta domain.Meta
+		if i < len(metas) {
+			meta = metas[i]
+		}
+		ids[i] = s.put(num, meta)
+	}
+	return ids, nil
+}
+
+func (s *Storage) put(num domain.Number, meta domain.Meta) int64 {
+	meta = meta.Clone()
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = time.Now()
+	}
+	s.nums = append(s.nums, num)
+	s.metas = append(s.metas, meta)
+	return int64(len(s.nums))
+}
+
+func (s *Storage) GetSlice(ctx context.Context) (numbers []domain.Number, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.nums), nil
+}
+
+// GetRecords numbers records by insertion, so only insertion order is
+// served directly.
+func (s *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	if order != "" && order != domain.OrderInserted {
+		return nil, domain.ErrUnsupportedOrder
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]domain.Record, len(s.nums))
+	for i, num := range s.nums {
+		records[i] = domain.Record{ID: int64(i + 1), Num: num}
+	}
+	return records, nil
+}
+
+// FindRecords serves the same orders as GetRecords.
+func (s *Storage) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	if q.Order != "" && q.Order != domain.OrderInserted {
+		return nil, domain.ErrUnsupportedOrder
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []domain.Record
+	for i, num := range s.nums {
+		if !q.Matches(s.metas[i]) {
+			continue
+		}
+		meta := s.metas[i].Clone()
+		records = append(records, domain.Record{ID: int64(i + 1), Num: num, Meta: &meta})
+		if q.Limit > 0 && len(records) == q.Limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+func (s *Storage) GetStats(ctx context.Context) (domain.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return domain.ComputeStats(s.nums), nil
+}
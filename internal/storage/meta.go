@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// metaParams are the insert parameters for a number's provenance. Unset
+// fields are NULL, so the column defaults apply.
+type metaParams struct {
+	createdAt *time.Time
+	client    *string
+	source    *string
+	labels    string
+}
+
+func metaArgs(ctx context.Context) metaParams {
+	meta, _ := reqctx.Meta(ctx)
+	return toMetaArgs(meta)
+}
+
+func toMetaArgs(meta domain.Meta) metaParams {
+	params := metaParams{labels: "{}"}
+	if !meta.CreatedAt.IsZero() {
+		params.createdAt = &meta.CreatedAt
+	}
+	if meta.Client != "" {
+		params.client = &meta.Client
+	}
+	if meta.Source != "" {
+		params.source = &meta.Source
+	}
+	if len(meta.Labels) > 0 {
+		labels, _ := json.Marshal(meta.Labels)
+		params.labels = string(labels)
+	}
+	return params
+}
+
+// FindRecords returns the records q selects together with their metadata,
+// sorted by q.Order. Orders Postgres cannot sort by yield
+// domain.ErrUnsupportedOrder, as for GetRecords.
+func (s *Storage) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	const op = "storage.FindRecords"
+
+	clause, ok := orderBy[q.Order]
+	if !ok {
+		return nil, fmt.Errorf("%s: %q: %w", op, q.Order, domain.ErrUnsupportedOrder)
+	}
+
+	column := "num"
+	if s.float {
+		column = "num_float"
+	}
+	where, args := recordFilter(q)
+	query := fmt.Sprintf("SELECT id, %[1]s, created_at, client, source, labels FROM nums WHERE %[1]s IS NOT NULL AND %[2]s%[3]s"+clause,
+		column, s.retained(), where)
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, release, err := s.readRows(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+	}
+	defer release()
+
+	var records []domain.Record
+	for rows.Next() {
+		var (
+			rec            domain.Record
+			meta           domain.Meta
+			num            pgtype.Numeric
+			f              float64
+			client, source *string
+		)
+		if s.float {
+			err = rows.Scan(&rec.ID, &f, &meta.CreatedAt, &client, &source, &meta.Labels)
+			rec.Num = domain.NewFloat(f)
+		} else {
+			err = rows.Scan(&rec.ID, &num, &meta.CreatedAt, &client, &source, &meta.Labels)
+			if err == nil {
+				rec.Num, err = fromNumeric(num)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+		}
+		if client != nil {
+			meta.Client = *client
+		}
+		if source != nil {
+			meta.Source = *source
+		}
+		if len(meta.Labels) == 0 {
+			meta.Labels = nil
+		}
+		rec.Meta = &meta
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// recordFilter turns the filters of q into SQL conditions, each prefixed
+// with AND, and their arguments.
+func recordFilter(q domain.RecordQuery) (string, []any) {
+	var (
+		b    strings.Builder
+		args []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		fmt.Fprintf(&b, " AND "+cond, len(args))
+	}
+
+	if !q.Since.IsZero() {
+		add("created_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		add("created_at < $%d", q.Until)
+	}
+	if q.Client != "" {
+		add("client = $%d", q.Client)
+	}
+	if q.Source != "" {
+		add("source = $%d", q.Source)
+	}
+	if len(q.Labels) > 0 {
+		encoded, _ := json.Marshal(q.Labels)
+		add("labels @> $%d::jsonb", string(encoded))
+	}
+	return b.String(), args
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"testovoe/internal/domain"
+)
+
+func TestRecordFilter(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := recordFilter(domain.RecordQuery{
+		Since:  since,
+		Source: "sensor",
+		Labels: map[string]string{"zone": "a", "env": "prod"},
+	})
+
+	assert.Equal(t, " AND created_at >= $1 AND source = $2 AND labels @> $3::jsonb", where)
+	assert.Equal(t, []any{since, "sensor", `{"env":"prod","zone":"a"}`}, args)
+
+	where, args = recordFilter(domain.RecordQuery{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+}
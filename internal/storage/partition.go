@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Partitions cover one UTC day of created_at each and are named after it.
+const (
+	partitionPrefix = "nums_p"
+	partitionLayout = "20060102"
+	partitionSpan   = 24 * time.Hour
+)
+
+var ErrPartitioned = errors.New("nums is already partitioned")
+
+// Partition converts nums into a table partitioned by day of created_at,
+// with partitions for every day that has rows and ahead days to come. Rows
+// are copied inside one transaction that holds nums exclusively, so writes
+// wait until it is done. The copy is exempt from statement_timeout.
+func (s *Storage) Partition(ctx context.Context, ahead int) ([]string, error) {
+	const op = "storage.Partition"
+
+	var created []string
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		partitioned, err := isPartitioned(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if partitioned {
+			return ErrPartitioned
+		}
+
+		for _, stmt := range []string{
+			"SET LOCAL statement_timeout = 0",
+			"LOCK TABLE nums IN ACCESS EXCLUSIVE MODE",
+		} {
+			_, err = tx.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+		var oldest *time.Time
+		err = tx.QueryRow(ctx, "SELECT min(created_at) FROM nums").Scan(&oldest)
+		if err != nil {
+			return err
+		}
+
+		// Every secondary index is created again on the new table under its
+		// old name, so later migrations can still drop it.
+		indexes, err := secondaryIndexes(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		stmts := []string{
+			"ALTER TABLE nums RENAME TO nums_unpartitioned",
+			"ALTER INDEX nums_pkey RENAME TO nums_unpartitioned_pkey",
+		}
+		for name := range indexes {
+			stmts = append(stmts, fmt.Sprintf("ALTER INDEX %s RENAME TO %s",
+				name, "nums_unpartitioned_"+strings.TrimPrefix(name, "nums_")))
+		}
+		stmts = append(stmts,
+			`CREATE TABLE nums (LIKE nums_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+				PARTITION BY RANGE (created_at)`,
+			"ALTER TABLE nums ADD CONSTRAINT nums_pkey PRIMARY KEY (id, created_at)",
+		)
+		for _, def := range indexes {
+			stmts = append(stmts, def)
+		}
+		stmts = append(stmts, "CREATE TABLE nums_default PARTITION OF nums DEFAULT")
+
+		for _, stmt := range stmts {
+			_, err = tx.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+		from := time.Now()
+		if oldest != nil {
+			from = *oldest
+		}
+		created, err = createPartitions(ctx, tx, from, time.Now().Add(time.Duration(ahead)*partitionSpan))
+		if err != nil {
+			return err
+		}
+
+		for _, stmt := range []string{
+			"INSERT INTO nums SELECT * FROM nums_unpartitioned",
+			// The sequence would otherwise be dropped with its old owner.
+			"ALTER SEQUENCE nums_id_seq OWNED BY nums.id",
+			"DROP TABLE nums_unpartitioned",
+		} {
+			_, err = tx.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// secondaryIndexes returns the definitions of the indexes on nums other than
+// its primary key, by name.
+func secondaryIndexes(ctx context.Context, tx pgx.Tx) (map[string]string, error) {
+	rows, err := tx.Query(ctx, `SELECT indexname, indexdef FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename = 'nums' AND indexname <> 'nums_pkey'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[string]string)
+	for rows.Next() {
+		var name, def string
+		err = rows.Scan(&name, &def)
+		if err != nil {
+			return nil, err
+		}
+		indexes[name] = def
+	}
+	return indexes, rows.Err()
+}
+
+func isPartitioned(ctx context.Context, tx pgx.Tx) (bool, error) {
+	var partitioned bool
+	err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'nums'::regclass)").
+		Scan(&partitioned)
+	return partitioned, err
+}
+
+// createPartitions makes sure a partition exists for every day from from to
+// to and returns the ones it created.
+func createPartitions(ctx context.Context, tx pgx.Tx, from, to time.Time) ([]string, error) {
+	existing, err := listPartitions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for day := from.UTC().Truncate(partitionSpan); !day.After(to); day = day.Add(partitionSpan) {
+		name := partitionPrefix + day.Format(partitionLayout)
+		if _, ok := existing[name]; ok {
+			continue
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf("CREATE TABLE %s PARTITION OF nums FOR VALUES FROM ('%s') TO ('%s')",
+			name, day.Format(time.RFC3339), day.Add(partitionSpan).Format(time.RFC3339)))
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+// listPartitions returns the day partitions of nums by the start of the day
+// they cover.
+func listPartitions(ctx context.Context, tx pgx.Tx) (map[string]time.Time, error) {
+	rows, err := tx.Query(ctx, `SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = 'nums'::regclass`)
+	if err != nil {
+		return nil, err
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		if day, ok := partitionDay(name); ok {
+			partitions[name] = day
+		}
+	}
+	return partitions, nil
+}
+
+func partitionDay(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	day, err := time.Parse(partitionLayout, suffix)
+	return day, err == nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ConsumeQuota counts one write by client today, in UTC, unless it already
+// made limit of them. The check and the count are one statement, so
+// instances sharing the database cannot together exceed the limit. Counts
+// from earlier days are removed as a new day starts.
+func (s *Storage) ConsumeQuota(ctx context.Context, client string, limit int64) (int64, bool, error) {
+	const op = "storage.ConsumeQuota"
+
+	var used int64
+	err := s.db.QueryRow(ctx, `INSERT INTO write_quotas (client, day, used)
+		VALUES ($1, (now() AT TIME ZONE 'UTC')::date, 1)
+		ON CONFLICT (client, day) DO UPDATE SET used = write_quotas.used + 1
+		WHERE write_quotas.used < $2
+		RETURNING used`, client, limit).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return limit, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if used == 1 {
+		_, err = s.db.Exec(ctx, "DELETE FROM write_quotas WHERE client = $1 AND day < (now() AT TIME ZONE 'UTC')::date", client)
+		if err != nil {
+			return 0, false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return used, true, nil
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testovoe/internal/reqctx"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// unknownLSN is recorded when the position of a committed write could not be
+// read. No replica ever reaches it, so reads pinned to it go to the primary.
+const unknownLSN = "FFFFFFFF/FFFFFFFF"
+
+type replica struct {
+	host  string
+	db    *pgxpool.Pool
+	state atomic.Pointer[replicaState]
+}
+
+type replicaState struct {
+	err      error
+	replayed uint64
+	lag      time.Duration
+}
+
+// ReplicaStatus describes a replica as of its last check.
+type ReplicaStatus struct {
+	Host      string `json:"host"`
+	Healthy   bool   `json:"healthy"`
+	ReplayLSN string `json:"replay_lsn,omitempty"`
+	Lag       string `json:"lag,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ConnectReplicas adds read replicas. Reads go to a replica that passed its
+// last check and lags the primary by at most maxLag, and to the primary when
+// there is none.
+func (s *Storage) ConnectReplicas(ctx context.Context, addrs []string, maxLag time.Duration) error {
+	const op = "storage.ConnectReplicas"
+
+	for _, addr := range addrs {
+		db, err := connect(ctx, addr, s.pool)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		connConfig := db.Config().ConnConfig
+		host := net.JoinHostPort(connConfig.Host, strconv.Itoa(int(connConfig.Port)))
+		r := &replica{host: host, db: db}
+		r.state.Store(&replicaState{err: errors.New("not checked yet")})
+		s.replicas = append(s.replicas, r)
+	}
+	s.maxLag = maxLag
+
+	s.CheckReplicas(ctx)
+	return nil
+}
+
+// CheckReplicas refreshes the replay position and lag of every replica.
+func (s *Storage) CheckReplicas(ctx context.Context) {
+	for _, r := range s.replicas {
+		r.state.Store(r.check(ctx))
+	}
+}
+
+// RunReplicaCheck checks replicas every interval until ctx is done.
+func (s *Storage) RunReplicaCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			s.CheckReplicas(checkCtx)
+			cancel()
+		}
+	}
+}
+
+// check measures lag as the age of the last replayed transaction, unless the
+// replica streams from the primary and has replayed everything received: an
+// idle primary sends nothing new, and the replica is then fully caught up
+// however old that transaction is. A replica whose WAL receiver is down
+// cannot know what it is missing, so it keeps the age as its lag. Seeing the
+// receiver's status takes pg_read_all_stats; without it replicas are treated
+// as disconnected.
+func (r *replica) check(ctx context.Context) *replicaState {
+	query := `SELECT pg_is_in_recovery(), COALESCE(pg_last_wal_replay_lsn()::text, ''),
+		COALESCE(pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn(), false),
+		EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+		COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)::float8`
+
+	var sample replicaSample
+	err := r.db.QueryRow(ctx, query).Scan(&sample.recovering, &sample.replayed, &sample.caughtUp,
+		&sample.streaming, &sample.age)
+	if err != nil {
+		return &replicaState{err: err}
+	}
+	return sample.state()
+}
+
+// replicaSample is what a check reads from a replica.
+type replicaSample struct {
+	recovering bool
+	replayed   string
+	caughtUp   bool
+	streaming  bool
+	// age is the number of seconds since the last replayed transaction
+	// committed.
+	age float64
+}
+
+func (smp replicaSample) state() *replicaState {
+	if !smp.recovering {
+		return &replicaState{err: errors.New("server is not a standby")}
+	}
+
+	lsn, err := parseLSN(smp.replayed)
+	if err != nil {
+		return &replicaState{err: err}
+	}
+
+	lag := time.Duration(smp.age * float64(time.Second))
+	if smp.caughtUp && smp.streaming {
+		lag = 0
+	}
+	return &replicaState{replayed: lsn, lag: lag}
+}
+
+func (r *replica) status() ReplicaStatus {
+	st := r.state.Load()
+	if st.err != nil {
+		return ReplicaStatus{Host: r.host, Error: st.err.Error()}
+	}
+	return ReplicaStatus{Host: r.host, Healthy: true, ReplayLSN: formatLSN(st.replayed), Lag: st.lag.String()}
+}
+
+func (s *Storage) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(s.replicas))
+	for i, r := range s.replicas {
+		statuses[i] = r.status()
+	}
+	return statuses
+}
+
+// reader returns the pool a read carrying ctx should use. Fresh reads and
+// reads pinned past every replica go to the primary.
+func (s *Storage) reader(ctx context.Context) *pgxpool.Pool {
+	if len(s.replicas) == 0 || reqctx.FreshRead(ctx) {
+		return s.db
+	}
+
+	var after uint64
+	if token, ok := reqctx.ReadAfter(ctx); ok {
+		lsn, err := parseLSN(token)
+		if err != nil {
+			return s.db
+		}
+		after = lsn
+	}
+
+	if r := s.pickReplica(after); r != nil {
+		return r.db
+	}
+	return s.db
+}
+
+// readRows runs query on the pool reader picks for ctx. Full scans run in a
+// transaction of their own with statement_timeout lifted. release closes
+// the rows and ends that transaction.
+func (s *Storage) readRows(ctx context.Context, query string, args ...any) (rows pgx.Rows, release func(), err error) {
+	db := s.reader(ctx)
+	if !reqctx.FullScan(ctx) {
+		rows, err = db.Query(ctx, query, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rows, rows.Close, nil
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = tx.Exec(ctx, "SET LOCAL statement_timeout = 0")
+	if err == nil {
+		rows, err = tx.Query(ctx, query, args...)
+	}
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, nil, err
+	}
+	return rows, func() {
+		rows.Close()
+		_ = tx.Rollback(ctx)
+	}, nil
+}
+
+// pickReplica round-robins over replicas that are healthy, within maxLag and
+// have replayed at least up to after.
+func (s *Storage) pickReplica(after uint64) *replica {
+	usable := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		st := r.state.Load()
+		if st.err == nil && st.lag <= s.maxLag && st.replayed >= after {
+			usable = append(usable, r)
+		}
+	}
+	if len(usable) == 0 {
+		return nil
+	}
+	return usable[s.next.Add(1)%uint64(len(usable))]
+}
+
+// recordWrite hands the primary's WAL position after a write to the caller,
+// so it can read its own write from a replica.
+func (s *Storage) recordWrite(ctx context.Context) {
+	if len(s.replicas) == 0 || !reqctx.WantsWriteToken(ctx) {
+		return
+	}
+
+	var lsn string
+	err := s.db.QueryRow(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn)
+	if err != nil {
+		lsn = unknownLSN
+	}
+	reqctx.RecordWrite(ctx, lsn)
+}
+
+// parseLSN parses a pg_lsn in its text form, two hex halves separated by a
+// slash.
+func parseLSN(s string) (uint64, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	return h<<32 | l, nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", lsn>>32, uint32(lsn))
+}
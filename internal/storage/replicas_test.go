@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/reqctx"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := parseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x16_B374D848), lsn)
+	assert.Equal(t, "16/B374D848", formatLSN(lsn))
+
+	_, err = parseLSN(unknownLSN)
+	assert.NoError(t, err)
+
+	for _, bad := range []string{"", "16", "x/1", "1/100000000"} {
+		_, err = parseLSN(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func newTestReplica(replayed uint64, lag time.Duration, err error) *replica {
+	r := &replica{db: &pgxpool.Pool{}}
+	r.state.Store(&replicaState{replayed: replayed, lag: lag, err: err})
+	return r
+}
+
+func TestStorage_ReaderRouting(t *testing.T) {
+	caughtUp := newTestReplica(0x200, 0, nil)
+	behind := newTestReplica(0x100, 0, nil)
+	lagging := newTestReplica(0x300, 5*time.Second, nil)
+	down := newTestReplica(0x300, 0, errors.New("connection refused"))
+
+	s := &Storage{
+		db:       &pgxpool.Pool{},
+		replicas: []*replica{caughtUp, behind, lagging, down},
+		maxLag:   time.Second,
+	}
+	ctx := context.Background()
+
+	seen := map[*pgxpool.Pool]int{}
+	for range 10 {
+		seen[s.reader(ctx)]++
+	}
+	assert.Equal(t, map[*pgxpool.Pool]int{caughtUp.db: 5, behind.db: 5}, seen, "reads are spread over usable replicas")
+
+	assert.Same(t, caughtUp.db, s.reader(reqctx.WithReadAfter(ctx, "0/180")))
+	assert.Same(t, s.db, s.reader(reqctx.WithReadAfter(ctx, "0/300")), "no replica has replayed the write")
+	assert.Same(t, s.db, s.reader(reqctx.WithReadAfter(ctx, "garbage")))
+	assert.Same(t, s.db, s.reader(reqctx.WithFreshRead(ctx)))
+}
+
+func TestReplicaSample_State(t *testing.T) {
+	streaming := replicaSample{recovering: true, replayed: "0/200", caughtUp: true, streaming: true, age: 3600}
+	st := streaming.state()
+	require.NoError(t, st.err)
+	assert.Equal(t, time.Duration(0), st.lag, "an idle primary leaves a streaming replica caught up")
+
+	// Without a WAL receiver, having replayed all that was received says
+	// nothing about what the primary has written since.
+	disconnected := streaming
+	disconnected.streaming = false
+	st = disconnected.state()
+	require.NoError(t, st.err)
+	assert.Equal(t, time.Hour, st.lag)
+
+	s := &Storage{db: &pgxpool.Pool{}, maxLag: time.Minute}
+	r := &replica{db: &pgxpool.Pool{}}
+	r.state.Store(st)
+	s.replicas = []*replica{r}
+	assert.Same(t, s.db, s.reader(context.Background()))
+
+	primary := replicaSample{replayed: "0/200"}
+	assert.Error(t, primary.state().err)
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testovoe/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SetRetention limits reads to the numbers policy keeps, so results agree
+// with what EnforceRetention leaves behind even before it next runs.
+func (s *Storage) SetRetention(policy domain.RetentionPolicy) {
+	s.retention.Store(&policy)
+}
+
+// retained returns the SQL condition selecting the rows the retention policy
+// keeps, or "TRUE".
+func (s *Storage) retained() string {
+	policy := s.retention.Load()
+	if policy == nil {
+		return "TRUE"
+	}
+	return retainedBy(*policy)
+}
+
+func retainedBy(policy domain.RetentionPolicy) string {
+	var conds []string
+	if policy.MaxAge > 0 {
+		conds = append(conds, fmt.Sprintf("created_at >= now() - interval '%d milliseconds'", policy.MaxAge.Milliseconds()))
+	}
+	if policy.MaxRows > 0 {
+		conds = append(conds, fmt.Sprintf("id IN (SELECT id FROM nums ORDER BY created_at DESC, id DESC LIMIT %d)", policy.MaxRows))
+	}
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// EnforceRetention removes the numbers policy no longer keeps: first whole
+// day partitions past MaxAge when nums is partitioned, then remaining rows
+// past MaxAge, then the oldest rows beyond MaxRows. With Archive set,
+// partitions are detached and kept as tables and rows move to nums_archive.
+// On a partitioned table it also creates partitions for the next ahead days.
+func (s *Storage) EnforceRetention(ctx context.Context, policy domain.RetentionPolicy, ahead int) (domain.RetentionReport, error) {
+	const op = "storage.EnforceRetention"
+
+	var report domain.RetentionReport
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		report = domain.RetentionReport{}
+
+		var now time.Time
+		err := tx.QueryRow(ctx, "SELECT now()").Scan(&now)
+		if err != nil {
+			return err
+		}
+		cutoff := now.Add(-policy.MaxAge)
+
+		partitioned, err := isPartitioned(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if partitioned {
+			report.CreatedPartitions, err = createPartitions(ctx, tx, now, now.Add(time.Duration(ahead)*partitionSpan))
+			if err != nil {
+				return err
+			}
+			if policy.MaxAge > 0 {
+				err = removePartitions(ctx, tx, policy.Archive, cutoff, &report)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if policy.MaxAge > 0 {
+			n, err := evict(ctx, tx, policy.Archive, "created_at < $1", cutoff)
+			if err != nil {
+				return err
+			}
+			countEvicted(&report, policy.Archive, n)
+
+			_, err = tx.Exec(ctx, "DELETE FROM nums_idempotency WHERE created_at < $1", cutoff)
+			if err != nil {
+				return err
+			}
+		}
+
+		if policy.MaxRows > 0 {
+			n, err := evict(ctx, tx, policy.Archive, `id NOT IN (
+				SELECT id FROM nums ORDER BY created_at DESC, id DESC LIMIT $1)`, policy.MaxRows)
+			if err != nil {
+				return err
+			}
+			countEvicted(&report, policy.Archive, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.RetentionReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+// evict deletes the rows matching where, moving them to nums_archive first
+// when archive is set, and returns how many there were.
+func evict(ctx context.Context, tx pgx.Tx, archive bool, where string, arg any) (int64, error) {
+	query := "DELETE FROM nums WHERE " + where
+	if archive {
+		query = fmt.Sprintf(`WITH moved AS (DELETE FROM nums WHERE %s RETURNING *)
+			INSERT INTO nums_archive SELECT * FROM moved`, where)
+	}
+
+	tag, err := tx.Exec(ctx, query, arg)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func countEvicted(report *domain.RetentionReport, archive bool, n int64) {
+	if archive {
+		report.Archived += n
+	} else {
+		report.Deleted += n
+	}
+}
+
+// removePartitions drops, or detaches and renames, every day partition that
+// ends at or before cutoff.
+func removePartitions(ctx context.Context, tx pgx.Tx, archive bool, cutoff time.Time, report *domain.RetentionReport) error {
+	partitions, err := listPartitions(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(partitions))
+	for name, day := range partitions {
+		if !day.Add(partitionSpan).After(cutoff) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		var rows int64
+		err = tx.QueryRow(ctx, "SELECT count(*) FROM "+name).Scan(&rows)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "ALTER TABLE nums DETACH PARTITION "+name)
+		if err != nil {
+			return err
+		}
+
+		if archive {
+			archived := "nums_archive_" + strings.TrimPrefix(name, "nums_")
+			_, err = tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", name, archived))
+			report.ArchivedPartitions = append(report.ArchivedPartitions, archived)
+			report.Archived += rows
+		} else {
+			_, err = tx.Exec(ctx, "DROP TABLE "+name)
+			report.DroppedPartitions = append(report.DroppedPartitions, name)
+			report.Deleted += rows
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"testovoe/internal/domain"
+)
+
+func TestRetainedBy(t *testing.T) {
+	assert.Equal(t, "TRUE", retainedBy(domain.RetentionPolicy{}))
+	assert.Equal(t, "created_at >= now() - interval '90000 milliseconds'",
+		retainedBy(domain.RetentionPolicy{MaxAge: 90 * time.Second}))
+	assert.Equal(t,
+		"created_at >= now() - interval '3600000 milliseconds' AND "+
+			"id IN (SELECT id FROM nums ORDER BY created_at DESC, id DESC LIMIT 10)",
+		retainedBy(domain.RetentionPolicy{MaxAge: time.Hour, MaxRows: 10}))
+
+	s := &Storage{}
+	assert.Equal(t, "TRUE", s.retained())
+	s.SetRetention(domain.RetentionPolicy{MaxRows: 5})
+	assert.Contains(t, s.retained(), "LIMIT 5")
+}
+
+func TestPartitionDay(t *testing.T) {
+	day, ok := partitionDay("nums_p20261018")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), day)
+
+	_, ok = partitionDay("nums_default")
+	assert.False(t, ok)
+}
@@ -2,62 +2,283 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync/atomic"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Storage keeps numbers in the nums table. Float64 mode uses the num_float
+// DOUBLE PRECISION column, every other mode the NUMERIC num column. Writes go
+// to the primary, reads to a replica when one is connected and caught up.
 type Storage struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	float bool
+
+	pool      Pool
+	retention atomic.Pointer[domain.RetentionPolicy]
+	replicas  []*replica
+	maxLag    time.Duration
+	next      atomic.Uint64
+}
+
+// Pool sizes and ages the connection pools of the primary and every replica.
+// Zero values keep the pgxpool defaults.
+type Pool struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
 }
 
-func New(ctx context.Context, storagePath string) (*Storage, error) {
-	poolConfig, err := pgxpool.ParseConfig(storagePath)
+func New(ctx context.Context, storagePath string, pool Pool, mode domain.NumberMode) (*Storage, error) {
+	db, err := connect(ctx, storagePath, pool)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	return &Storage{db: db, float: mode == domain.ModeFloat64, pool: pool}, nil
+}
+
+func connect(ctx context.Context, connString string, pool Pool) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Storage{db: db}, nil
+	if pool.MaxConns > 0 {
+		poolConfig.MaxConns = pool.MaxConns
+	}
+	poolConfig.MinConns = pool.MinConns
+	if pool.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = pool.MaxConnLifetime
+	}
+	if pool.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = pool.MaxConnIdleTime
+	}
+	if pool.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = pool.HealthCheckPeriod
+	}
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
 }
 
 func (s *Storage) Close() {
 	s.db.Close()
+	for _, r := range s.replicas {
+		r.db.Close()
+	}
+}
+
+// PoolStats describes a connection pool.
+type PoolStats struct {
+	MaxConns         int32  `json:"max_conns"`
+	TotalConns       int32  `json:"total_conns"`
+	IdleConns        int32  `json:"idle_conns"`
+	AcquiredConns    int32  `json:"acquired_conns"`
+	AcquireCount     int64  `json:"acquire_count"`
+	EmptyAcquires    int64  `json:"empty_acquires"`
+	CanceledAcquires int64  `json:"canceled_acquires"`
+	AcquireDuration  string `json:"acquire_duration"`
+}
+
+// PoolStats reports the primary's pool under "primary" and each replica's
+// under its host.
+func (s *Storage) PoolStats() map[string]PoolStats {
+	stats := map[string]PoolStats{"primary": poolStats(s.db)}
+	for _, r := range s.replicas {
+		stats[r.host] = poolStats(r.db)
+	}
+	return stats
+}
+
+func poolStats(db *pgxpool.Pool) PoolStats {
+	st := db.Stat()
+	return PoolStats{
+		MaxConns:         st.MaxConns(),
+		TotalConns:       st.TotalConns(),
+		IdleConns:        st.IdleConns(),
+		AcquiredConns:    st.AcquiredConns(),
+		AcquireCount:     st.AcquireCount(),
+		EmptyAcquires:    st.EmptyAcquireCount(),
+		CanceledAcquires: st.CanceledAcquireCount(),
+		AcquireDuration:  st.AcquireDuration().String(),
+	}
 }
 
-func (s *Storage) PutNumber(ctx context.Context, num int) error {
+func (s *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
 	const op = "storage.PutNumber"
 
-	query := "INSERT INTO nums (num) VALUES ($1)"
+	var id int64
+	entry, audited := reqctx.Audit(ctx)
+	if !audited {
+		var err error
+		id, err = s.insertNumber(ctx, s.db, num)
+		if err != nil {
+			return 0, fmt.Errorf("%s: could not store num: %w", op, err)
+		}
+		s.recordWrite(ctx)
+		return id, nil
+	}
+
+	// The audit entry commits or rolls back together with the number.
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		id, err = s.insertNumber(ctx, tx, num)
+		if err != nil {
+			return err
+		}
+		entry.Outcome, entry.NumID = domain.AuditSuccess, id
+		return recordAudit(ctx, tx, entry)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: could not store num: %w", op, err)
+	}
+	s.recordWrite(ctx)
+	return id, nil
+}
+
+func (s *Storage) insertNumber(ctx context.Context, q rowQuerier, num domain.Number) (int64, error) {
+	column := "num"
+	var arg any = toNumeric(num)
+	if s.float {
+		column = "num_float"
+		arg, _ = num.Float64()
+	}
+
+	meta := metaArgs(ctx)
+
+	var id int64
+	key, keyed := reqctx.IdempotencyKey(ctx)
+	if !keyed {
+		query := fmt.Sprintf(`INSERT INTO nums (%s, created_at, client, source, labels)
+			VALUES ($1, COALESCE($2, now()), $3, $4, $5) RETURNING id`, column)
+		err := q.QueryRow(ctx, query, arg, meta.createdAt, meta.client, meta.source, meta.labels).Scan(&id)
+		return id, err
+	}
+
+	// The key claims an id first and only a fresh claim inserts the number.
+	// A repeated key updates nothing but still returns the claimed id and
+	// request hash, also when the first claim is in a concurrent transaction,
+	// which the conflict waits for.
+	query := fmt.Sprintf(`WITH claimed AS (
+		INSERT INTO nums_idempotency (client, key, hash, id)
+		VALUES (COALESCE($4, ''), $2, $7, nextval('nums_id_seq'))
+		ON CONFLICT (client, key) DO UPDATE SET key = EXCLUDED.key
+		RETURNING id, hash, xmax = 0 AS fresh),
+		inserted AS (INSERT INTO nums (id, %s, created_at, client, source, labels)
+			SELECT id, $1, COALESCE($3, now()), $4, $5, $6 FROM claimed WHERE fresh)
+		SELECT id, hash FROM claimed`, column)
+	hash := domain.RequestHash(num)
+	var claimedHash string
+	err := q.QueryRow(ctx, query, arg, key, meta.createdAt, meta.client, meta.source, meta.labels, hash).Scan(&id, &claimedHash)
+	if err == nil && claimedHash != hash {
+		return 0, domain.ErrIdempotencyMismatch
+	}
+	return id, err
+}
+
+// PutNumbers inserts nums with a single statement and returns their ids in
+// the same order. metas holds the provenance of each number, or is nil.
+func (s *Storage) PutNumbers(ctx context.Context, nums []domain.Number, metas []domain.Meta) ([]int64, error) {
+	const op = "storage.PutNumbers"
+
+	query := `INSERT INTO nums (%s, created_at, client, source, labels)
+		SELECT n, COALESCE(c, now()), cl, src, l::jsonb
+		FROM unnest($1::%s[], $2::timestamptz[], $3::text[], $4::text[], $5::text[]) AS u(n, c, cl, src, l)
+		RETURNING id`
+	var arg any
+	if s.float {
+		query = fmt.Sprintf(query, "num_float", "float8")
+		floats := make([]float64, len(nums))
+		for i, num := range nums {
+			floats[i], _ = num.Float64()
+		}
+		arg = floats
+	} else {
+		query = fmt.Sprintf(query, "num", "numeric")
+		numerics := make([]pgtype.Numeric, len(nums))
+		for i, num := range nums {
+			numerics[i] = toNumeric(num)
+		}
+		arg = numerics
+	}
+
+	var (
+		createdAt = make([]*time.Time, len(nums))
+		clients   = make([]*string, len(nums))
+		sources   = make([]*string, len(nums))
+		labels    = make([]string, len(nums))
+	)
+	for i := range nums {
+		var meta domain.Meta
+		if i < len(metas) {
+			meta = metas[i]
+		}
+		args := toMetaArgs(meta)
+		createdAt[i], clients[i], sources[i] = args.createdAt, args.client, args.source
+		labels[i] = args.labels
+	}
 
-	_, err := s.db.Exec(ctx, query, num)
+	rows, err := s.db.Query(ctx, query, arg, createdAt, clients, sources, labels)
 	if err != nil {
-		return fmt.Errorf("%s: could not store num: %w", op, err)
+		return nil, fmt.Errorf("%s: could not store nums: %w", op, err)
 	}
-	return nil
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not store nums: %w", op, err)
+	}
+
+	// Rows are inserted, and draw ids from the sequence, in input order, but
+	// RETURNING does not promise to report them in that order.
+	slices.Sort(ids)
+	s.recordWrite(ctx)
+	return ids, nil
 }
 
-func (s *Storage) GetSlice(ctx context.Context) (numbers []int, err error) {
+func (s *Storage) GetSlice(ctx context.Context) (numbers []domain.Number, err error) {
 	const op = "storage.GetSlice"
 
-	query := "SELECT num FROM nums"
+	if s.float {
+		numbers, err = s.getFloats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: could not fetch nums: %w", op, err)
+		}
+		return numbers, nil
+	}
 
-	rows, err := s.db.Query(ctx, query)
+	query := "SELECT num FROM nums WHERE num IS NOT NULL AND " + s.retained()
+
+	rows, err := s.reader(ctx).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("%s: could not fetch nums: %w", op, err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var num int
+		var num pgtype.Numeric
 		err = rows.Scan(&num)
 		if err != nil {
 			return nil, fmt.Errorf("%s: could not fetch nums: %w", op, err)
 		}
-		numbers = append(numbers, num)
+
+		n, err := fromNumeric(num)
+		if err != nil {
+			return nil, fmt.Errorf("%s: could not fetch nums: %w", op, err)
+		}
+		numbers = append(numbers, n)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -66,3 +287,132 @@ func (s *Storage) GetSlice(ctx context.Context) (numbers []int, err error) {
 
 	return numbers, nil
 }
+
+// orderBy lists the orders Postgres sorts itself. Its float8 ordering puts NaN
+// last and treats -0 as equal to 0, which matches domain.Number.Cmp.
+var orderBy = map[string]string{
+	"":                       "",
+	domain.OrderAsc:          " ORDER BY %[1]s, id",
+	domain.OrderDesc:         " ORDER BY %[1]s DESC, id",
+	domain.OrderAbs:          " ORDER BY abs(%[1]s), id",
+	domain.OrderAbsDesc:      " ORDER BY abs(%[1]s) DESC, id",
+	domain.OrderInserted:     " ORDER BY id",
+	domain.OrderInsertedDesc: " ORDER BY id DESC",
+}
+
+func (s *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	const op = "storage.GetRecords"
+
+	clause, ok := orderBy[order]
+	if !ok {
+		return nil, fmt.Errorf("%s: %q: %w", op, order, domain.ErrUnsupportedOrder)
+	}
+
+	column := "num"
+	if s.float {
+		column = "num_float"
+	}
+	query := fmt.Sprintf("SELECT id, %[1]s FROM nums WHERE %[1]s IS NOT NULL AND %[2]s"+clause, column, s.retained())
+
+	rows, release, err := s.readRows(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+	}
+	defer release()
+
+	var records []domain.Record
+	for rows.Next() {
+		var (
+			rec domain.Record
+			num pgtype.Numeric
+			f   float64
+		)
+		if s.float {
+			err = rows.Scan(&rec.ID, &f)
+			rec.Num = domain.NewFloat(f)
+		} else {
+			err = rows.Scan(&rec.ID, &num)
+			if err == nil {
+				rec.Num, err = fromNumeric(num)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+		}
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: could not fetch records: %w", op, err)
+	}
+
+	return records, nil
+}
+
+func (s *Storage) GetStats(ctx context.Context) (domain.Stats, error) {
+	const op = "storage.GetStats"
+
+	// sum(double precision) is a naive running sum, so float stats are
+	// computed here with compensated summation instead.
+	if s.float {
+		numbers, err := s.getFloats(ctx)
+		if err != nil {
+			return domain.Stats{}, fmt.Errorf("%s: could not fetch stats: %w", op, err)
+		}
+		return domain.ComputeStats(numbers), nil
+	}
+
+	query := `SELECT count(num), COALESCE(min(num), 0), COALESCE(max(num), 0),
+		COALESCE(sum(num), 0), COALESCE(avg(num), 0) FROM nums WHERE ` + s.retained()
+
+	var (
+		stats               domain.Stats
+		minN, maxN, sumN, m pgtype.Numeric
+	)
+	err := s.reader(ctx).QueryRow(ctx, query).Scan(&stats.Count, &minN, &maxN, &sumN, &m)
+	if err != nil {
+		return domain.Stats{}, fmt.Errorf("%s: could not fetch stats: %w", op, err)
+	}
+
+	for _, f := range []struct {
+		src pgtype.Numeric
+		dst *domain.Number
+	}{{minN, &stats.Min}, {maxN, &stats.Max}, {sumN, &stats.Sum}, {m, &stats.Mean}} {
+		*f.dst, err = fromNumeric(f.src)
+		if err != nil {
+			return domain.Stats{}, fmt.Errorf("%s: could not fetch stats: %w", op, err)
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *Storage) getFloats(ctx context.Context) ([]domain.Number, error) {
+	rows, err := s.reader(ctx).Query(ctx, "SELECT num_float FROM nums WHERE num_float IS NOT NULL AND "+s.retained())
+	if err != nil {
+		return nil, err
+	}
+
+	floats, err := pgx.CollectRows(rows, pgx.RowTo[float64])
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make([]domain.Number, len(floats))
+	for i, f := range floats {
+		numbers[i] = domain.NewFloat(f)
+	}
+	return numbers, nil
+}
+
+func toNumeric(num domain.Number) pgtype.Numeric {
+	unscaled, scale := num.Unscaled()
+	return pgtype.Numeric{Int: unscaled, Exp: -scale, Valid: true}
+}
+
+func fromNumeric(num pgtype.Numeric) (domain.Number, error) {
+	if !num.Valid || num.NaN || num.InfinityModifier != pgtype.Finite {
+		return domain.Number{}, errors.New("numeric value is not a finite number")
+	}
+	return domain.NewDecimal(num.Int, -num.Exp), nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"time"
+)
+
+// Auditor records every attempted mutation. When InTx is true, storage
+// records successful writes in their own transaction, and Record is told so
+// through storedInTx.
+type Auditor interface {
+	InTx() bool
+	Record(ctx context.Context, entry domain.AuditEntry, storedInTx bool) error
+}
+
+// SetAuditor makes every mutation leave an audit entry. It must be called
+// before serving requests.
+func (u *UseCase) SetAuditor(a Auditor) {
+	u.auditor = a
+}
+
+type auditPayload struct {
+	Num    domain.Number     `json:"num"`
+	Source string            `json:"source,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func newAuditEntry(ctx context.Context, operation string, num domain.Number) domain.AuditEntry {
+	principal, _ := reqctx.Principal(ctx)
+	meta, _ := reqctx.Meta(ctx)
+	payload, _ := json.Marshal(auditPayload{Num: num, Source: meta.Source, Labels: meta.Labels})
+
+	return domain.AuditEntry{
+		At:        time.Now().UTC(),
+		Principal: principal.ID,
+		Operation: operation,
+		Payload:   payload,
+		RequestID: reqctx.RequestID(ctx),
+	}
+}
+
+func (u *UseCase) audit(ctx context.Context, entry domain.AuditEntry, outcome string, err error, storedInTx bool) {
+	const op = "useCase.audit"
+
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	err = u.auditor.Record(ctx, entry, storedInTx)
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to record audit entry", "op", op, "operation", entry.Operation, "outcome", outcome, "error", err)
+	}
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/usecase/mocks"
+)
+
+type recordedAudit struct {
+	entry      domain.AuditEntry
+	storedInTx bool
+}
+
+type fakeAuditor struct {
+	inTx    bool
+	entries []recordedAudit
+}
+
+func (a *fakeAuditor) InTx() bool { return a.inTx }
+
+func (a *fakeAuditor) Record(_ context.Context, entry domain.AuditEntry, storedInTx bool) error {
+	a.entries = append(a.entries, recordedAudit{entry, storedInTx})
+	return nil
+}
+
+func TestUseCase_PutNumber_Audit(t *testing.T) {
+	upper := domain.NewInt(100)
+	ctx := reqctx.WithPrincipal(context.Background(), domain.Principal{ID: "k1"})
+	ctx = reqctx.WithRequestID(ctx, "req-1")
+
+	testCases := []struct {
+		name       string
+		num        int64
+		storeErr   error
+		outcome    string
+		numID      int64
+		storedInTx bool
+	}{
+		{name: "success", num: 5, outcome: domain.AuditSuccess, numID: 7, storedInTx: true},
+		{name: "rejected", num: 500, outcome: domain.AuditRejected},
+		{name: "failure", num: 5, storeErr: errors.New("boom"), outcome: domain.AuditFailure},
+		{name: "pending", num: 5, storeErr: ErrPending, outcome: domain.AuditPending, storedInTx: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStorage := mocks.NewStorage(t)
+			auditor := &fakeAuditor{inTx: true}
+			useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), mockStorage)
+			useCase.SetValidationPolicy(ValidationPolicy{Max: &upper})
+			useCase.SetAuditor(auditor)
+
+			if tc.outcome != domain.AuditRejected {
+				mockStorage.EXPECT().
+					PutNumber(mock.MatchedBy(func(ctx context.Context) bool {
+						entry, ok := reqctx.Audit(ctx)
+						return ok && entry.Principal == "k1" && entry.RequestID == "req-1"
+					}), domain.NewInt(tc.num)).
+					Return(tc.numID, tc.storeErr).
+					Once()
+			}
+
+			_ = useCase.PutNumber(ctx, domain.NewInt(tc.num))
+
+			require.Len(t, auditor.entries, 1)
+			got := auditor.entries[0]
+			assert.Equal(t, tc.outcome, got.entry.Outcome)
+			assert.Equal(t, tc.numID, got.entry.NumID)
+			assert.Equal(t, tc.storedInTx, got.storedInTx)
+			assert.Equal(t, domain.AuditPut, got.entry.Operation)
+			assert.JSONEq(t, `{"num":`+domain.NewInt(tc.num).String()+`}`, string(got.entry.Payload))
+		})
+	}
+}
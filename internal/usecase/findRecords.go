@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testovoe/internal/domain"
+)
+
+// FindRecords returns the records matching q with their metadata. An empty
+// order sorts ascending; orders storage cannot express are sorted in memory,
+// after which the limit is applied.
+func (u *UseCase) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	const op = "useCase.FindRecords"
+
+	if q.Order == "" {
+		q.Order = domain.OrderAsc
+	}
+	orders := u.Orders()
+	compare, ok := orders.Lookup(q.Order)
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownOrder, q.Order, strings.Join(orders.Names(), ", "))
+	}
+
+	records, err := u.Storage.FindRecords(ctx, q)
+	if errors.Is(err, domain.ErrUnsupportedOrder) {
+		all := q
+		all.Order, all.Limit = "", 0
+		records, err = u.Storage.FindRecords(ctx, all)
+		if err == nil {
+			SortRecords(records, compare)
+			if q.Limit > 0 && len(records) > q.Limit {
+				records = records[:q.Limit]
+			}
+		}
+	}
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to find records", "op", op, "error", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+	"testovoe/internal/storage/memory"
+)
+
+func TestUseCase_FindRecords_SortsAndLimitsInMemory(t *testing.T) {
+	store := memory.New()
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), store)
+
+	prod := reqctx.WithMeta(context.Background(), domain.Meta{Labels: map[string]string{"env": "prod"}})
+	for _, v := range []int64{5, 1, 4, 2} {
+		_, err := store.PutNumber(prod, domain.NewInt(v))
+		require.NoError(t, err)
+	}
+	_, err := store.PutNumber(context.Background(), domain.NewInt(0))
+	require.NoError(t, err)
+
+	// Memory storage only serves insertion order, so ascending is sorted
+	// here and the limit applied afterwards.
+	records, err := useCase.FindRecords(context.Background(), domain.RecordQuery{
+		Labels: map[string]string{"env": "prod"},
+		Limit:  3,
+	})
+
+	require.NoError(t, err)
+	nums := make([]domain.Number, len(records))
+	for i, rec := range records {
+		nums[i] = rec.Num
+		require.NotNil(t, rec.Meta)
+		assert.Equal(t, "prod", rec.Meta.Labels["env"])
+	}
+	assert.Equal(t, ints(1, 2, 4), nums)
+}
+
+func TestUseCase_FindRecords_UnknownOrder(t *testing.T) {
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), memory.New())
+
+	_, err := useCase.FindRecords(context.Background(), domain.RecordQuery{Order: "sideways"})
+
+	assert.ErrorIs(t, err, ErrUnknownOrder)
+}
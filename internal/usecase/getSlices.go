@@ -2,22 +2,61 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testovoe/internal/domain"
 )
 
-func (u *UseCase) GetSlices(ctx context.Context) ([]int, error) {
+// GetSlices returns every stored number. An empty order sorts ascending in
+// memory; a named order is looked up in the registry and pushed down to
+// storage when it supports it.
+func (u *UseCase) GetSlices(ctx context.Context, order string) ([]domain.Number, error) {
 	const op = "useCase.GetSlices"
 
+	if order != "" {
+		return u.getOrdered(ctx, order)
+	}
+
 	numbers, err := u.Storage.GetSlice(ctx)
 	if err != nil {
-		u.log.Error("failed to get slices", op, err)
+		u.log.ErrorContext(ctx, "failed to get slices", "op", op, "error", err)
 		return nil, err
 	}
 
 	numbers, err = SortNums(numbers)
 	if err != nil {
-		u.log.Error("failed to sort numbers", op, err)
+		u.log.ErrorContext(ctx, "failed to sort numbers", "op", op, "error", err)
 		return nil, err
 	}
 
 	return numbers, nil
 }
+
+func (u *UseCase) getOrdered(ctx context.Context, order string) ([]domain.Number, error) {
+	const op = "useCase.GetSlices"
+
+	orders := u.Orders()
+	compare, ok := orders.Lookup(order)
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownOrder, order, strings.Join(orders.Names(), ", "))
+	}
+
+	records, err := u.Storage.GetRecords(ctx, order)
+	if errors.Is(err, domain.ErrUnsupportedOrder) {
+		records, err = u.Storage.GetRecords(ctx, "")
+		if err == nil {
+			SortRecords(records, compare)
+		}
+	}
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to get records", "op", op, "error", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	numbers := make([]domain.Number, len(records))
+	for i, rec := range records {
+		numbers[i] = rec.Num
+	}
+	return numbers, nil
+}
@@ -3,6 +3,8 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"testing"
@@ -10,6 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"testovoe/internal/domain"
+	"testovoe/internal/storage/memory"
 	"testovoe/internal/usecase/mocks"
 )
 
@@ -22,18 +26,18 @@ func TestUseCase_GetSlices_Success(t *testing.T) {
 		log:     logger,
 	}
 
-	unsortedNumbers := []int{5, 2, 8, 1, 9}
+	unsortedNumbers := ints(5, 2, 8, 1, 9)
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
 		Return(unsortedNumbers, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 
-	assert.Equal(t, []int{1, 2, 5, 8, 9}, result)
+	assert.Equal(t, ints(1, 2, 5, 8, 9), result)
 }
 
 func TestUseCase_GetSlices_StorageError(t *testing.T) {
@@ -51,7 +55,7 @@ func TestUseCase_GetSlices_StorageError(t *testing.T) {
 		Return(nil, expectedErr).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -69,14 +73,14 @@ func TestUseCase_GetSlices_EmptySlice(t *testing.T) {
 
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
-		Return([]int{}, nil).
+		Return(ints(), nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
-	assert.Equal(t, []int{}, result)
+	assert.Equal(t, ints(), result)
 }
 
 func TestUseCase_GetSlices_SingleElement(t *testing.T) {
@@ -90,13 +94,13 @@ func TestUseCase_GetSlices_SingleElement(t *testing.T) {
 
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
-		Return([]int{42}, nil).
+		Return(ints(42), nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
-	assert.Equal(t, []int{42}, result)
+	assert.Equal(t, ints(42), result)
 }
 
 func TestUseCase_GetSlices_NegativeNumbers(t *testing.T) {
@@ -108,16 +112,16 @@ func TestUseCase_GetSlices_NegativeNumbers(t *testing.T) {
 		log:     logger,
 	}
 
-	unsorted := []int{-5, 3, -1, 0, -10}
+	unsorted := ints(-5, 3, -1, 0, -10)
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
 		Return(unsorted, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
-	assert.Equal(t, []int{-10, -5, -1, 0, 3}, result)
+	assert.Equal(t, ints(-10, -5, -1, 0, 3), result)
 }
 
 func TestUseCase_GetSlices_Duplicates(t *testing.T) {
@@ -129,16 +133,16 @@ func TestUseCase_GetSlices_Duplicates(t *testing.T) {
 		log:     logger,
 	}
 
-	unsorted := []int{5, 2, 5, 1, 2, 9}
+	unsorted := ints(5, 2, 5, 1, 2, 9)
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
 		Return(unsorted, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
-	assert.Equal(t, []int{1, 2, 2, 5, 5, 9}, result)
+	assert.Equal(t, ints(1, 2, 2, 5, 5, 9), result)
 }
 
 func TestUseCase_GetSlices_AlreadySorted(t *testing.T) {
@@ -150,13 +154,13 @@ func TestUseCase_GetSlices_AlreadySorted(t *testing.T) {
 		log:     logger,
 	}
 
-	sorted := []int{1, 2, 3, 4, 5}
+	sorted := ints(1, 2, 3, 4, 5)
 	mockStorage.EXPECT().
 		GetSlice(mock.Anything).
 		Return(sorted, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
 	assert.Equal(t, sorted, result)
@@ -171,9 +175,9 @@ func TestUseCase_GetSlices_LargeSlice(t *testing.T) {
 		log:     logger,
 	}
 
-	large := make([]int, 1000)
+	large := make([]domain.Number, 1000)
 	for i := range large {
-		large[i] = 1000 - i
+		large[i] = domain.NewInt(int64(1000 - i))
 	}
 
 	mockStorage.EXPECT().
@@ -181,13 +185,13 @@ func TestUseCase_GetSlices_LargeSlice(t *testing.T) {
 		Return(large, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	assert.NoError(t, err)
 	assert.Len(t, result, 1000)
 
 	for i := 0; i < len(result)-1; i++ {
-		assert.LessOrEqual(t, result[i], result[i+1])
+		assert.LessOrEqual(t, result[i].Cmp(result[i+1]), 0)
 	}
 }
 
@@ -205,7 +209,7 @@ func TestUseCase_GetSlices_SortError(t *testing.T) {
 		Return(nil, nil).
 		Once()
 
-	result, err := useCase.GetSlices(context.Background())
+	result, err := useCase.GetSlices(context.Background(), "")
 
 	if err != nil {
 		assert.Error(t, err)
@@ -230,14 +234,35 @@ func TestUseCase_GetSlices_ContextPassed(t *testing.T) {
 		Run(func(ctx context.Context) {
 			capturedCtx = ctx
 		}).
-		Return([]int{1, 2, 3}, nil).
+		Return(ints(1, 2, 3), nil).
 		Once()
 
 	ctx := context.WithValue(context.Background(), "test-key", "test-value")
 
-	_, err := useCase.GetSlices(ctx)
+	_, err := useCase.GetSlices(ctx, "")
 	
 	assert.NoError(t, err)
 	assert.NotNil(t, capturedCtx)
 	assert.Equal(t, "test-value", capturedCtx.Value("test-key"))
 }
+
+func BenchmarkUseCase_GetSlices(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, size := range []int{100, 10_000, 100_000} {
+		storage := memory.New()
+		for i := range size {
+			_, _ = storage.PutNumber(context.Background(), domain.NewInt(int64((i*7919)%size)))
+		}
+		useCase := NewUseCase(logger, storage)
+
+		b.Run(fmt.Sprintf("n=%d", size), func(b *testing.B) {
+			for b.Loop() {
+				_, err := useCase.GetSlices(context.Background(), "")
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	domain "testovoe/internal/domain"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -21,24 +22,142 @@ func (_m *Storage) EXPECT() *Storage_Expecter {
 	return &Storage_Expecter{mock: &_m.Mock}
 }
 
+// FindRecords provides a mock function with given fields: ctx, q
+func (_m *Storage) FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindRecords")
+	}
+
+	var r0 []domain.Record
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RecordQuery) ([]domain.Record, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RecordQuery) []domain.Record); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Record)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.RecordQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_FindRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindRecords'
+type Storage_FindRecords_Call struct {
+	*mock.Call
+}
+
+// FindRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - q domain.RecordQuery
+func (_e *Storage_Expecter) FindRecords(ctx interface{}, q interface{}) *Storage_FindRecords_Call {
+	return &Storage_FindRecords_Call{Call: _e.mock.On("FindRecords", ctx, q)}
+}
+
+func (_c *Storage_FindRecords_Call) Run(run func(ctx context.Context, q domain.RecordQuery)) *Storage_FindRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.RecordQuery))
+	})
+	return _c
+}
+
+func (_c *Storage_FindRecords_Call) Return(_a0 []domain.Record, _a1 error) *Storage_FindRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_FindRecords_Call) RunAndReturn(run func(context.Context, domain.RecordQuery) ([]domain.Record, error)) *Storage_FindRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRecords provides a mock function with given fields: ctx, order
+func (_m *Storage) GetRecords(ctx context.Context, order string) ([]domain.Record, error) {
+	ret := _m.Called(ctx, order)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRecords")
+	}
+
+	var r0 []domain.Record
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.Record, error)); ok {
+		return rf(ctx, order)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.Record); ok {
+		r0 = rf(ctx, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Record)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRecords'
+type Storage_GetRecords_Call struct {
+	*mock.Call
+}
+
+// GetRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - order string
+func (_e *Storage_Expecter) GetRecords(ctx interface{}, order interface{}) *Storage_GetRecords_Call {
+	return &Storage_GetRecords_Call{Call: _e.mock.On("GetRecords", ctx, order)}
+}
+
+func (_c *Storage_GetRecords_Call) Run(run func(ctx context.Context, order string)) *Storage_GetRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_GetRecords_Call) Return(_a0 []domain.Record, _a1 error) *Storage_GetRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetRecords_Call) RunAndReturn(run func(context.Context, string) ([]domain.Record, error)) *Storage_GetRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetSlice provides a mock function with given fields: ctx
-func (_m *Storage) GetSlice(ctx context.Context) ([]int, error) {
+func (_m *Storage) GetSlice(ctx context.Context) ([]domain.Number, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetSlice")
 	}
 
-	var r0 []int
+	var r0 []domain.Number
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]int, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Number, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []int); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Number); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]int)
+			r0 = ret.Get(0).([]domain.Number)
 		}
 	}
 
@@ -69,32 +188,98 @@ func (_c *Storage_GetSlice_Call) Run(run func(ctx context.Context)) *Storage_Get
 	return _c
 }
 
-func (_c *Storage_GetSlice_Call) Return(numbers []int, err error) *Storage_GetSlice_Call {
+func (_c *Storage_GetSlice_Call) Return(numbers []domain.Number, err error) *Storage_GetSlice_Call {
 	_c.Call.Return(numbers, err)
 	return _c
 }
 
-func (_c *Storage_GetSlice_Call) RunAndReturn(run func(context.Context) ([]int, error)) *Storage_GetSlice_Call {
+func (_c *Storage_GetSlice_Call) RunAndReturn(run func(context.Context) ([]domain.Number, error)) *Storage_GetSlice_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStats provides a mock function with given fields: ctx
+func (_m *Storage) GetStats(ctx context.Context) (domain.Stats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 domain.Stats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (domain.Stats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) domain.Stats); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(domain.Stats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStats'
+type Storage_GetStats_Call struct {
+	*mock.Call
+}
+
+// GetStats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) GetStats(ctx interface{}) *Storage_GetStats_Call {
+	return &Storage_GetStats_Call{Call: _e.mock.On("GetStats", ctx)}
+}
+
+func (_c *Storage_GetStats_Call) Run(run func(ctx context.Context)) *Storage_GetStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_GetStats_Call) Return(_a0 domain.Stats, _a1 error) *Storage_GetStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetStats_Call) RunAndReturn(run func(context.Context) (domain.Stats, error)) *Storage_GetStats_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // PutNumber provides a mock function with given fields: ctx, num
-func (_m *Storage) PutNumber(ctx context.Context, num int) error {
+func (_m *Storage) PutNumber(ctx context.Context, num domain.Number) (int64, error) {
 	ret := _m.Called(ctx, num)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PutNumber")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Number) (int64, error)); ok {
+		return rf(ctx, num)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Number) int64); ok {
 		r0 = rf(ctx, num)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(int64)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Number) error); ok {
+		r1 = rf(ctx, num)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // Storage_PutNumber_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutNumber'
@@ -104,24 +289,24 @@ type Storage_PutNumber_Call struct {
 
 // PutNumber is a helper method to define mock.On call
 //   - ctx context.Context
-//   - num int
+//   - num domain.Number
 func (_e *Storage_Expecter) PutNumber(ctx interface{}, num interface{}) *Storage_PutNumber_Call {
 	return &Storage_PutNumber_Call{Call: _e.mock.On("PutNumber", ctx, num)}
 }
 
-func (_c *Storage_PutNumber_Call) Run(run func(ctx context.Context, num int)) *Storage_PutNumber_Call {
+func (_c *Storage_PutNumber_Call) Run(run func(ctx context.Context, num domain.Number)) *Storage_PutNumber_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int))
+		run(args[0].(context.Context), args[1].(domain.Number))
 	})
 	return _c
 }
 
-func (_c *Storage_PutNumber_Call) Return(_a0 error) *Storage_PutNumber_Call {
-	_c.Call.Return(_a0)
+func (_c *Storage_PutNumber_Call) Return(id int64, err error) *Storage_PutNumber_Call {
+	_c.Call.Return(id, err)
 	return _c
 }
 
-func (_c *Storage_PutNumber_Call) RunAndReturn(run func(context.Context, int) error) *Storage_PutNumber_Call {
+func (_c *Storage_PutNumber_Call) RunAndReturn(run func(context.Context, domain.Number) (int64, error)) *Storage_PutNumber_Call {
 	_c.Call.Return(run)
 	return _c
 }
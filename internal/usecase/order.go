@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testovoe/internal/domain"
+)
+
+var ErrUnknownOrder = errors.New("unknown order")
+
+// Comparator orders two records. Ties are broken by id, so a comparator only
+// needs to decide the cases it cares about.
+type Comparator func(a, b domain.Record) int
+
+// OrderRegistry maps order names accepted by GetSlices to comparators.
+type OrderRegistry struct {
+	mu     sync.RWMutex
+	orders map[string]Comparator
+}
+
+// NewOrderRegistry returns a registry holding the built-in domain orders.
+func NewOrderRegistry() *OrderRegistry {
+	return &OrderRegistry{orders: map[string]Comparator{
+		domain.OrderAsc: func(a, b domain.Record) int {
+			return a.Num.Cmp(b.Num)
+		},
+		domain.OrderDesc: func(a, b domain.Record) int {
+			return b.Num.Cmp(a.Num)
+		},
+		domain.OrderAbs: func(a, b domain.Record) int {
+			return a.Num.Abs().Cmp(b.Num.Abs())
+		},
+		domain.OrderAbsDesc: func(a, b domain.Record) int {
+			return b.Num.Abs().Cmp(a.Num.Abs())
+		},
+		domain.OrderInserted: func(a, b domain.Record) int {
+			return 0
+		},
+		domain.OrderInsertedDesc: func(a, b domain.Record) int {
+			return cmp.Compare(b.ID, a.ID)
+		},
+	}}
+}
+
+func (r *OrderRegistry) Register(name string, compare Comparator) error {
+	if name == "" || compare == nil {
+		return errors.New("order name and comparator are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orders[name]; ok {
+		return fmt.Errorf("order %q is already registered", name)
+	}
+	r.orders[name] = compare
+	return nil
+}
+
+func (r *OrderRegistry) Lookup(name string) (Comparator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.orders[name]
+	return c, ok
+}
+
+func (r *OrderRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.orders))
+	for name := range r.orders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// EvensFirst puts even integers before everything else, each group ascending.
+func EvensFirst(a, b domain.Record) int {
+	aEven, bEven := a.Num.IsEven(), b.Num.IsEven()
+	if aEven != bEven {
+		if aEven {
+			return -1
+		}
+		return 1
+	}
+	return a.Num.Cmp(b.Num)
+}
+
+// SortRecords sorts records with c, breaking ties by id.
+func SortRecords(records []domain.Record, c Comparator) {
+	slices.SortFunc(records, func(a, b domain.Record) int {
+		if r := c(a, b); r != 0 {
+			return r
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
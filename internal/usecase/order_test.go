@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/storage/memory"
+	"testovoe/internal/usecase/mocks"
+)
+
+func TestUseCase_GetSlices_Orders(t *testing.T) {
+	storage := memory.New()
+	for _, n := range []string{"3", "-4", "2", "-1", "4", "1.5"} {
+		_, err := storage.PutNumber(context.Background(), domain.MustParseNumber(n))
+		require.NoError(t, err)
+	}
+
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), storage)
+	require.NoError(t, useCase.Orders().Register("evens_first", EvensFirst))
+
+	testCases := []struct {
+		order    string
+		expected []string
+	}{
+		{"asc", []string{"-4", "-1", "1.5", "2", "3", "4"}},
+		{"desc", []string{"4", "3", "2", "1.5", "-1", "-4"}},
+		{"abs", []string{"-1", "1.5", "2", "3", "-4", "4"}},
+		{"abs_desc", []string{"-4", "4", "3", "2", "1.5", "-1"}},
+		{"inserted", []string{"3", "-4", "2", "-1", "4", "1.5"}},
+		{"inserted_desc", []string{"1.5", "4", "-1", "2", "-4", "3"}},
+		{"evens_first", []string{"-4", "2", "4", "-1", "1.5", "3"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.order, func(t *testing.T) {
+			numbers, err := useCase.GetSlices(context.Background(), tc.order)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, numberStrings(numbers))
+		})
+	}
+}
+
+func TestUseCase_GetSlices_UnknownOrder(t *testing.T) {
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewStorage(t))
+
+	_, err := useCase.GetSlices(context.Background(), "sideways")
+
+	assert.ErrorIs(t, err, ErrUnknownOrder)
+	assert.ErrorContains(t, err, "asc, desc")
+}
+
+func TestUseCase_GetSlices_PushedDown(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), mockStorage)
+
+	records := []domain.Record{{ID: 2, Num: domain.NewInt(9)}, {ID: 1, Num: domain.NewInt(1)}}
+	mockStorage.EXPECT().
+		GetRecords(mock.Anything, domain.OrderDesc).
+		Return(records, nil).
+		Once()
+
+	numbers, err := useCase.GetSlices(context.Background(), domain.OrderDesc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ints(9, 1), numbers)
+}
+
+func TestSortRecords_TieBreakOnID(t *testing.T) {
+	records := []domain.Record{
+		{ID: 3, Num: domain.NewInt(2)},
+		{ID: 1, Num: domain.MustParseNumber("2.0")},
+		{ID: 2, Num: domain.NewInt(-2)},
+	}
+
+	SortRecords(records, NewOrderRegistry().orders[domain.OrderAbs])
+
+	assert.Equal(t, []int64{1, 2, 3}, []int64{records[0].ID, records[1].ID, records[2].ID})
+}
+
+func TestOrderRegistry_RegisterDuplicate(t *testing.T) {
+	registry := NewOrderRegistry()
+
+	assert.Error(t, registry.Register(domain.OrderAsc, EvensFirst))
+	assert.Error(t, registry.Register("", EvensFirst))
+	assert.NoError(t, registry.Register("evens_first", EvensFirst))
+	assert.Contains(t, registry.Names(), "evens_first")
+}
@@ -1,15 +1,64 @@
 package usecase
 
-import "context"
+import (
+	"context"
+	"errors"
+	"testovoe/internal/domain"
+	"testovoe/internal/reqctx"
+)
 
-func (u *UseCase) PutNumber(ctx context.Context, number int) error {
+func (u *UseCase) PutNumber(ctx context.Context, number domain.Number) error {
 	const op = "useCase.PutNumber"
 
-	err := u.Storage.PutNumber(ctx, number)
+	var entry domain.AuditEntry
+	if u.auditor != nil {
+		entry = newAuditEntry(ctx, domain.AuditPut, number)
+	}
+
+	if u.readOnly.Load() {
+		if u.auditor != nil {
+			u.audit(ctx, entry, domain.AuditRejected, ErrReadOnly, false)
+		}
+		return ErrReadOnly
+	}
+
+	number, err := u.policy.Load().Validate(number)
 	if err != nil {
-		u.log.Error("failed to put number", "op", op, "error", err)
+		if u.auditor != nil {
+			u.audit(ctx, entry, domain.AuditRejected, err, false)
+		}
 		return err
 	}
 
+	storeCtx := ctx
+	inTx := u.auditor != nil && u.auditor.InTx()
+	if inTx {
+		storeCtx = reqctx.WithAudit(ctx, entry)
+	}
+
+	id, err := u.Storage.PutNumber(storeCtx, number)
+	if errors.Is(err, ErrPending) {
+		// A spooled write is recorded in storage when it is replayed.
+		if u.auditor != nil {
+			u.audit(ctx, entry, domain.AuditPending, nil, inTx)
+		}
+		return err
+	}
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to put number", "op", op, "error", err)
+		if u.auditor != nil {
+			u.audit(ctx, entry, domain.AuditFailure, err, false)
+		}
+		return err
+	}
+
+	if u.auditor != nil {
+		entry.NumID = id
+		u.audit(ctx, entry, domain.AuditSuccess, nil, inTx)
+	}
+
+	u.log.DebugContext(ctx, "number stored", "op", op)
+	u.publish(number)
+
 	return nil
 }
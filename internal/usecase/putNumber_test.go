@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"testovoe/internal/domain"
 	"testovoe/internal/usecase/mocks"
 )
 
@@ -23,11 +24,11 @@ func TestUseCase_PutNumber_Success(t *testing.T) {
 	}
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 42).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Return(int64(1), nil).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), 42)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(42))
 
 	assert.NoError(t, err)
 }
@@ -43,16 +44,39 @@ func TestUseCase_PutNumber_StorageError(t *testing.T) {
 
 	expectedErr := errors.New("database write failed")
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 42).
-		Return(expectedErr).
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Return(int64(0), expectedErr).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), 42)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(42))
 
 	assert.Error(t, err)
 	assert.Equal(t, expectedErr, err)
 }
 
+func TestUseCase_PutNumber_ReadOnly(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	useCase := &UseCase{
+		Storage: mockStorage,
+		log:     logger,
+	}
+	useCase.SetReadOnly(true)
+
+	err := useCase.PutNumber(context.Background(), domain.NewInt(42))
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	useCase.SetReadOnly(false)
+	mockStorage.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Return(int64(1), nil).
+		Once()
+
+	err = useCase.PutNumber(context.Background(), domain.NewInt(42))
+	assert.NoError(t, err)
+}
+
 func TestUseCase_PutNumber_PositiveNumber(t *testing.T) {
 	mockStorage := mocks.NewStorage(t)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -63,11 +87,11 @@ func TestUseCase_PutNumber_PositiveNumber(t *testing.T) {
 	}
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 100).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(100)).
+		Return(int64(1), nil).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), 100)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(100))
 
 	assert.NoError(t, err)
 }
@@ -82,11 +106,11 @@ func TestUseCase_PutNumber_Zero(t *testing.T) {
 	}
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 0).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(0)).
+		Return(int64(1), nil).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), 0)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(0))
 
 	assert.NoError(t, err)
 }
@@ -101,11 +125,11 @@ func TestUseCase_PutNumber_NegativeNumber(t *testing.T) {
 	}
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, -42).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(-42)).
+		Return(int64(1), nil).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), -42)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(-42))
 
 	assert.NoError(t, err)
 }
@@ -119,10 +143,10 @@ func TestUseCase_PutNumber_LargeNumber(t *testing.T) {
 		log:     logger,
 	}
 
-	largeNum := 2147483647
+	largeNum := domain.NewInt(2147483647)
 	mockStorage.EXPECT().
 		PutNumber(mock.Anything, largeNum).
-		Return(nil).
+		Return(int64(1), nil).
 		Once()
 
 	err := useCase.PutNumber(context.Background(), largeNum)
@@ -141,16 +165,16 @@ func TestUseCase_PutNumber_ContextPassed(t *testing.T) {
 
 	var capturedCtx context.Context
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 42).
-		Run(func(ctx context.Context, num int) {
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Run(func(ctx context.Context, num domain.Number) {
 			capturedCtx = ctx
 		}).
-		Return(nil).
+		Return(int64(1), nil).
 		Once()
 
 	ctx := context.WithValue(context.Background(), "request-id", "12345")
 
-	err := useCase.PutNumber(ctx, 42)
+	err := useCase.PutNumber(ctx, domain.NewInt(42))
 
 	assert.NoError(t, err)
 	assert.NotNil(t, capturedCtx)
@@ -160,7 +184,7 @@ func TestUseCase_PutNumber_ContextPassed(t *testing.T) {
 func TestUseCase_PutNumber_TableDriven(t *testing.T) {
 	testCases := []struct {
 		name   string
-		number int
+		number int64
 	}{
 		{"zero", 0},
 		{"positive small", 5},
@@ -182,11 +206,11 @@ func TestUseCase_PutNumber_TableDriven(t *testing.T) {
 			}
 
 			mockStorage.EXPECT().
-				PutNumber(mock.Anything, tc.number).
-				Return(nil).
+				PutNumber(mock.Anything, domain.NewInt(tc.number)).
+				Return(int64(1), nil).
 				Once()
 
-			err := useCase.PutNumber(context.Background(), tc.number)
+			err := useCase.PutNumber(context.Background(), domain.NewInt(tc.number))
 
 			assert.NoError(t, err)
 		})
@@ -203,23 +227,23 @@ func TestUseCase_PutNumber_MultipleCalls(t *testing.T) {
 	}
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 1).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(1)).
+		Return(int64(1), nil).
 		Once()
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 2).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(2)).
+		Return(int64(1), nil).
 		Once()
 
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 3).
-		Return(nil).
+		PutNumber(mock.Anything, domain.NewInt(3)).
+		Return(int64(1), nil).
 		Once()
 
-	assert.NoError(t, useCase.PutNumber(context.Background(), 1))
-	assert.NoError(t, useCase.PutNumber(context.Background(), 2))
-	assert.NoError(t, useCase.PutNumber(context.Background(), 3))
+	assert.NoError(t, useCase.PutNumber(context.Background(), domain.NewInt(1)))
+	assert.NoError(t, useCase.PutNumber(context.Background(), domain.NewInt(2)))
+	assert.NoError(t, useCase.PutNumber(context.Background(), domain.NewInt(3)))
 }
 
 func TestUseCase_PutNumber_LogsError(t *testing.T) {
@@ -236,11 +260,30 @@ func TestUseCase_PutNumber_LogsError(t *testing.T) {
 
 	expectedErr := errors.New("storage error")
 	mockStorage.EXPECT().
-		PutNumber(mock.Anything, 42).
-		Return(expectedErr).
+		PutNumber(mock.Anything, domain.NewInt(42)).
+		Return(int64(0), expectedErr).
 		Once()
 
-	err := useCase.PutNumber(context.Background(), 42)
+	err := useCase.PutNumber(context.Background(), domain.NewInt(42))
 
 	assert.Error(t, err)
 }
+
+func TestUseCase_PutNumber_RejectedByPolicy(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	useCase := &UseCase{
+		Storage: mockStorage,
+		log:     logger,
+	}
+
+	maxNum := domain.NewInt(100)
+	useCase.SetValidationPolicy(ValidationPolicy{Max: &maxNum})
+
+	err := useCase.PutNumber(context.Background(), domain.NewInt(101))
+
+	var verr *ValidationError
+	assert.ErrorAs(t, err, &verr)
+	mockStorage.AssertNotCalled(t, "PutNumber", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testovoe/internal/domain"
+)
+
+// RankIndex is implemented by storage that keeps numbers in an
+// order-statistic index and answers rank and range queries in log time.
+// Decorators stacked above such storage expose it with Unwrap.
+type RankIndex interface {
+	Rank(num domain.Number) (rank, total int)
+	Range(lo, hi *domain.Number, offset, limit int) []domain.Record
+}
+
+type RangeQuery struct {
+	Min    *domain.Number
+	Max    *domain.Number
+	Offset int
+	Limit  int
+}
+
+// Rank returns how many stored numbers are less than num, and how many are
+// stored in total.
+func (u *UseCase) Rank(ctx context.Context, num domain.Number) (rank, total int, err error) {
+	const op = "useCase.Rank"
+
+	ix, err := u.rankIndex(ctx)
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to load numbers", "op", op, "error", err)
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rank, total = ix.Rank(num)
+	return rank, total, nil
+}
+
+// Range returns stored numbers within [q.Min, q.Max] in ascending order.
+func (u *UseCase) Range(ctx context.Context, q RangeQuery) ([]domain.Number, error) {
+	const op = "useCase.Range"
+
+	ix, err := u.rankIndex(ctx)
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to load numbers", "op", op, "error", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records := ix.Range(q.Min, q.Max, q.Offset, q.Limit)
+	numbers := make([]domain.Number, len(records))
+	for i, rec := range records {
+		numbers[i] = rec.Num
+	}
+	return numbers, nil
+}
+
+// rankIndex returns the index of the storage or of a layer beneath it, or
+// sorts a snapshot when none keeps one.
+func (u *UseCase) rankIndex(ctx context.Context) (RankIndex, error) {
+	for s := u.Storage; s != nil; {
+		if ix, ok := s.(RankIndex); ok {
+			return ix, nil
+		}
+		wrapper, ok := s.(interface{ Unwrap() Storage })
+		if !ok {
+			break
+		}
+		s = wrapper.Unwrap()
+	}
+
+	numbers, err := u.Storage.GetSlice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	numbers, _ = SortNums(numbers)
+	return sortedNumbers(numbers), nil
+}
+
+type sortedNumbers []domain.Number
+
+func (s sortedNumbers) search(num domain.Number, inclusive bool) int {
+	i, _ := slices.BinarySearchFunc(s, num, func(e, target domain.Number) int {
+		c := e.Cmp(target)
+		if c == 0 && inclusive {
+			return -1
+		}
+		if c == 0 {
+			return 1
+		}
+		return c
+	})
+	return i
+}
+
+func (s sortedNumbers) Rank(num domain.Number) (rank, total int) {
+	return s.search(num, false), len(s)
+}
+
+func (s sortedNumbers) Range(lo, hi *domain.Number, offset, limit int) []domain.Record {
+	from, to := 0, len(s)
+	if lo != nil {
+		from = s.search(*lo, false)
+	}
+	if hi != nil {
+		to = s.search(*hi, true)
+	}
+
+	from += offset
+	if limit > 0 && from+limit < to {
+		to = from + limit
+	}
+	if from >= to {
+		return nil
+	}
+
+	records := make([]domain.Record, 0, to-from)
+	for _, num := range s[from:to] {
+		records = append(records, domain.Record{Num: num})
+	}
+	return records
+}
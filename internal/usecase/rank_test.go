@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/usecase/mocks"
+)
+
+func TestUseCase_RankAndRange_WithoutIndex(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	useCase := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), mockStorage)
+
+	mockStorage.EXPECT().
+		GetSlice(mock.Anything).
+		RunAndReturn(func(context.Context) ([]domain.Number, error) {
+			return ints(5, 1, 3, 3, 9, 7), nil
+		}).
+		Twice()
+
+	rank, total, err := useCase.Rank(context.Background(), domain.NewInt(3))
+	require.NoError(t, err)
+	assert.Equal(t, 1, rank)
+	assert.Equal(t, 6, total)
+
+	lo, hi := domain.NewInt(3), domain.NewInt(7)
+	numbers, err := useCase.Range(context.Background(), RangeQuery{Min: &lo, Max: &hi, Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, ints(3, 5), numbers)
+}
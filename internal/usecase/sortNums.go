@@ -2,15 +2,18 @@ package usecase
 
 import (
 	"slices"
+	"testovoe/internal/domain"
 )
 
-func SortNums(numbers []int) ([]int, error) {
+func SortNums(numbers []domain.Number) ([]domain.Number, error) {
 	numbersLen := len(numbers)
 	if numbersLen < 2 {
 		return numbers, nil
 	}
 
-	slices.Sort(numbers)
+	// Stable, so values that compare equal but differ, such as -0 and +0 or
+	// 2 and 2.0, stay in insertion order.
+	slices.SortStableFunc(numbers, domain.Number.Cmp)
 
 	return numbers, nil
 }
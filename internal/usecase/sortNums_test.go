@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"testovoe/internal/domain"
+)
+
+func BenchmarkSortNums(b *testing.B) {
+	for _, size := range []int{100, 10_000, 1_000_000} {
+		src := make([]domain.Number, size)
+		for i := range src {
+			src[i] = domain.NewInt(rand.Int64N(int64(size)))
+		}
+
+		b.Run(fmt.Sprintf("n=%d", size), func(b *testing.B) {
+			numbers := make([]domain.Number, size)
+			for b.Loop() {
+				copy(numbers, src)
+				_, _ = SortNums(numbers)
+			}
+		})
+	}
+}
+
+func TestSortNums_MixedScales(t *testing.T) {
+	numbers := []domain.Number{
+		domain.MustParseNumber("10"),
+		domain.MustParseNumber("9.99"),
+		domain.MustParseNumber("-0.5"),
+		domain.MustParseNumber("123456789012345678901234567890"),
+		domain.MustParseNumber("2"),
+	}
+
+	sorted, err := SortNums(numbers)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-0.5", "2", "9.99", "10", "123456789012345678901234567890"}, numberStrings(sorted))
+}
+
+func TestSortNums_Floats(t *testing.T) {
+	numbers := []domain.Number{
+		domain.NewFloat(math.NaN()),
+		domain.NewFloat(1.5),
+		domain.NewFloat(math.Inf(1)),
+		domain.NewFloat(0),
+		domain.NewFloat(math.Inf(-1)),
+		domain.NewFloat(math.Copysign(0, -1)),
+		domain.NewFloat(-2),
+	}
+
+	sorted, err := SortNums(numbers)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-Infinity", "-2", "0", "-0", "1.5", "Infinity", "NaN"}, numberStrings(sorted))
+}
+
+func ints(values ...int64) []domain.Number {
+	numbers := make([]domain.Number, len(values))
+	for i, v := range values {
+		numbers[i] = domain.NewInt(v)
+	}
+	return numbers
+}
+
+func numberStrings(numbers []domain.Number) []string {
+	strs := make([]string, len(numbers))
+	for i, num := range numbers {
+		strs[i] = num.String()
+	}
+	return strs
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"testovoe/internal/domain"
+)
+
+func (u *UseCase) Stats(ctx context.Context) (domain.Stats, error) {
+	const op = "useCase.Stats"
+
+	stats, err := u.Storage.GetStats(ctx)
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to get stats", "op", op, "error", err)
+		return domain.Stats{}, err
+	}
+
+	return stats, nil
+}
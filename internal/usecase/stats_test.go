@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"testovoe/internal/domain"
+	"testovoe/internal/usecase/mocks"
+)
+
+func TestUseCase_Stats_Success(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	useCase := &UseCase{
+		Storage: mockStorage,
+		log:     logger,
+	}
+
+	expected := domain.Stats{
+		Count: 2,
+		Min:   domain.NewInt(1),
+		Max:   domain.NewInt(3),
+		Sum:   domain.NewInt(4),
+		Mean:  domain.NewInt(2),
+	}
+	mockStorage.EXPECT().
+		GetStats(mock.Anything).
+		Return(expected, nil).
+		Once()
+
+	stats, err := useCase.Stats(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, stats)
+}
+
+func TestUseCase_Stats_StorageError(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	useCase := &UseCase{
+		Storage: mockStorage,
+		log:     logger,
+	}
+
+	expectedErr := errors.New("database connection failed")
+	mockStorage.EXPECT().
+		GetStats(mock.Anything).
+		Return(domain.Stats{}, expectedErr).
+		Once()
+
+	_, err := useCase.Stats(context.Background())
+
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestUseCase_Subscribe_ReceivesStoredNumbers(t *testing.T) {
+	mockStorage := mocks.NewStorage(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	useCase := NewUseCase(logger, mockStorage)
+
+	mockStorage.EXPECT().
+		PutNumber(mock.Anything, domain.NewInt(7)).
+		Return(int64(1), nil).
+		Once()
+
+	numbers, unsubscribe := useCase.Subscribe()
+	defer unsubscribe()
+
+	assert.NoError(t, useCase.PutNumber(context.Background(), domain.NewInt(7)))
+
+	select {
+	case num := <-numbers:
+		assert.Equal(t, domain.NewInt(7), num)
+	case <-time.After(time.Second):
+		t.Fatal("number was not published")
+	}
+}
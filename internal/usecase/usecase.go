@@ -2,23 +2,84 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testovoe/internal/domain"
 )
 
+// ErrUnavailable is wrapped by storage errors that mean the database cannot
+// be reached right now.
+var ErrUnavailable = errors.New("storage is unavailable")
+
+// ErrPending means a number was accepted durably but not stored yet; it will
+// be written once storage is back.
+var ErrPending = errors.New("accepted, pending storage")
+
+// ErrReadOnly means writes are switched off by an operator.
+var ErrReadOnly = errors.New("service is read-only")
+
 //go:generate mockery --name=UseCase --output=mocks/ --outpkg=mocks
 type Storage interface {
-	PutNumber(ctx context.Context, num int) error
-	GetSlice(ctx context.Context) (numbers []int, err error)
+	PutNumber(ctx context.Context, num domain.Number) (id int64, err error)
+	GetSlice(ctx context.Context) (numbers []domain.Number, err error)
+	// GetRecords returns records sorted by order, or in any order when it is
+	// empty. Orders the backend cannot express yield domain.ErrUnsupportedOrder.
+	GetRecords(ctx context.Context, order string) ([]domain.Record, error)
+	GetStats(ctx context.Context) (domain.Stats, error)
+	// FindRecords returns the records q selects with their metadata, sorted
+	// like GetRecords.
+	FindRecords(ctx context.Context, q domain.RecordQuery) ([]domain.Record, error)
 }
 
 type UseCase struct {
 	log     *slog.Logger
 	Storage Storage
+
+	policy   atomic.Pointer[ValidationPolicy]
+	orders   *OrderRegistry
+	readOnly atomic.Bool
+
+	auditor Auditor
+
+	mu          sync.Mutex
+	subscribers map[chan domain.Number]struct{}
 }
 
 func NewUseCase(log *slog.Logger, storage Storage) *UseCase {
 	return &UseCase{
-		log:     log,
-		Storage: storage,
+		log:         log,
+		Storage:     storage,
+		orders:      NewOrderRegistry(),
+		subscribers: make(map[chan domain.Number]struct{}),
+	}
+}
+
+// Orders returns the registry of sort orders accepted by GetSlices. Custom
+// comparators should be registered before serving requests.
+func (u *UseCase) Orders() *OrderRegistry {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.orders == nil {
+		u.orders = NewOrderRegistry()
 	}
+	return u.orders
+}
+
+// SetValidationPolicy replaces the policy applied to new numbers. It is safe
+// to call while requests are being served.
+func (u *UseCase) SetValidationPolicy(policy ValidationPolicy) {
+	u.policy.Store(&policy)
+}
+
+// SetReadOnly switches writes off or back on. While off, PutNumber returns
+// ErrReadOnly. It is safe to call while requests are being served.
+func (u *UseCase) SetReadOnly(on bool) {
+	u.readOnly.Store(on)
+}
+
+func (u *UseCase) ReadOnly() bool {
+	return u.readOnly.Load()
 }
@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"testovoe/internal/domain"
+)
+
+const (
+	fieldNum    = "num"
+	fieldSource = "source"
+	fieldLabels = "labels"
+)
+
+// Limits on the metadata a client may attach to a number.
+const (
+	maxSourceLen   = 64
+	maxLabels      = 32
+	maxLabelKeyLen = 63
+	maxLabelValLen = 256
+)
+
+var ErrMalformedRequest = errors.New("malformed request")
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ValidationPolicy restricts which numbers may be stored. The mode decides
+// which kinds of numbers are accepted at all; nil bounds, empty lists and
+// zero digit limits impose no further restriction. NaN is only accepted in
+// float64 mode with AllowNaN set, and then sorts after every other number.
+// The digit limits apply to numbers as sent, except in float64 mode, which
+// stores every number in the same size.
+type ValidationPolicy struct {
+	Mode             domain.NumberMode
+	AllowNaN         bool
+	Min              *domain.Number
+	Max              *domain.Number
+	Allow            []domain.Number
+	Deny             []domain.Number
+	MaxIntegerDigits int
+	MaxScale         int
+}
+
+// Validate returns num normalized for the policy's mode, or a
+// *ValidationError listing every rule it breaks. A nil policy accepts any
+// int64.
+func (p *ValidationPolicy) Validate(num domain.Number) (domain.Number, error) {
+	if p == nil {
+		p = &ValidationPolicy{}
+	}
+
+	verr := &ValidationError{}
+
+	if p.Mode != domain.ModeFloat64 && !num.IsFloat() {
+		if p.MaxIntegerDigits > 0 && num.IntegerDigits() > p.MaxIntegerDigits {
+			verr.add(fieldNum, "must have at most %d digits before the decimal point", p.MaxIntegerDigits)
+		}
+		if p.MaxScale > 0 && int(num.Scale()) > p.MaxScale {
+			verr.add(fieldNum, "must have at most %d decimal places", p.MaxScale)
+		}
+		if err := verr.orNil(); err != nil {
+			return domain.Number{}, err
+		}
+	}
+
+	num, err := p.Mode.Normalize(num)
+	if err != nil {
+		verr.add(fieldNum, "%s", err)
+		return domain.Number{}, verr
+	}
+
+	if num.IsNaN() {
+		if !p.AllowNaN {
+			verr.add(fieldNum, "must not be NaN")
+		}
+		return num, verr.orNil()
+	}
+
+	if p.Min != nil && num.Cmp(*p.Min) < 0 {
+		verr.add(fieldNum, "must be greater than or equal to %s", p.Min)
+	}
+	if p.Max != nil && num.Cmp(*p.Max) > 0 {
+		verr.add(fieldNum, "must be less than or equal to %s", p.Max)
+	}
+	if len(p.Allow) > 0 && !containsNumber(p.Allow, num) {
+		verr.add(fieldNum, "is not in the allowed list")
+	}
+	if containsNumber(p.Deny, num) {
+		verr.add(fieldNum, "is not allowed")
+	}
+
+	if err := verr.orNil(); err != nil {
+		return domain.Number{}, err
+	}
+
+	return num, nil
+}
+
+func containsNumber(list []domain.Number, num domain.Number) bool {
+	return slices.ContainsFunc(list, func(n domain.Number) bool {
+		return n.Cmp(num) == 0
+	})
+}
+
+// DecodeUserNum strictly decodes a request body. Unknown fields, a missing
+// num and values that are not numbers are reported as field errors; bodies
+// that are not a single JSON object wrap ErrMalformedRequest. Whether the
+// number suits the configured mode is checked later by the policy.
+func DecodeUserNum(r io.Reader) (domain.UserNum, error) {
+	dec := json.NewDecoder(r)
+
+	var fields map[string]json.RawMessage
+	err := dec.Decode(&fields)
+	if err != nil {
+		return domain.UserNum{}, fmt.Errorf("%w: %w", ErrMalformedRequest, err)
+	}
+	if dec.More() {
+		return domain.UserNum{}, fmt.Errorf("%w: unexpected data after object", ErrMalformedRequest)
+	}
+
+	verr := &ValidationError{}
+
+	unknown := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != fieldNum && name != fieldSource && name != fieldLabels {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		verr.add(name, "unknown field")
+	}
+
+	raw, ok := fields[fieldNum]
+	if !ok {
+		verr.add(fieldNum, "is required")
+		return domain.UserNum{}, verr
+	}
+
+	var userNum domain.UserNum
+	err = userNum.Num.UnmarshalJSON(raw)
+	if err != nil {
+		verr.add(fieldNum, "%s", err)
+	}
+
+	if raw, ok := fields[fieldSource]; ok {
+		userNum.Source = decodeSource(raw, verr)
+	}
+	if raw, ok := fields[fieldLabels]; ok {
+		userNum.Labels = decodeLabels(raw, verr)
+	}
+
+	if err := verr.orNil(); err != nil {
+		return domain.UserNum{}, err
+	}
+
+	return userNum, nil
+}
+
+func decodeSource(raw json.RawMessage, verr *ValidationError) string {
+	var source string
+	if json.Unmarshal(raw, &source) != nil {
+		verr.add(fieldSource, "must be a string")
+		return ""
+	}
+	if len(source) > maxSourceLen {
+		verr.add(fieldSource, "must be at most %d bytes", maxSourceLen)
+	}
+	return source
+}
+
+func decodeLabels(raw json.RawMessage, verr *ValidationError) map[string]string {
+	var labels map[string]string
+	if json.Unmarshal(raw, &labels) != nil {
+		verr.add(fieldLabels, "must be an object of strings")
+		return nil
+	}
+	if len(labels) > maxLabels {
+		verr.add(fieldLabels, "must have at most %d entries", maxLabels)
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !ValidLabelKey(k) {
+			verr.add(fieldLabels+"."+k, "key must be 1-%d letters, digits, '_', '-' or '.'", maxLabelKeyLen)
+		}
+		if len(labels[k]) > maxLabelValLen {
+			verr.add(fieldLabels+"."+k, "must be at most %d bytes", maxLabelValLen)
+		}
+	}
+	return labels
+}
+
+// ValidLabelKey reports whether k may name a label.
+func ValidLabelKey(k string) bool {
+	if k == "" || len(k) > maxLabelKeyLen {
+		return false
+	}
+	for _, c := range k {
+		ok := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testovoe/internal/domain"
+)
+
+func TestDecodeUserNum_Valid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"integer", `{"num": 42}`, "42"},
+		{"negative", `{"num": -7}`, "-7"},
+		{"decimal", `{"num": 3.70}`, "3.70"},
+		{"exponent", `{"num": 1e3}`, "1000"},
+		{"negative exponent", `{"num": 15e-3}`, "0.015"},
+		{"beyond int64", `{"num": 9223372036854775808}`, "9223372036854775808"},
+		{"quoted", `{"num": "-123456789012345678901234567890.25"}`, "-123456789012345678901234567890.25"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userNum, err := DecodeUserNum(strings.NewReader(tc.body))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, userNum.Num.String())
+		})
+	}
+}
+
+func TestDecodeUserNum_FieldErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected []FieldError
+	}{
+		{"quoted garbage", `{"num": "five"}`, []FieldError{{"num", "must be a number"}}},
+		{"bool", `{"num": true}`, []FieldError{{"num", "must be a number"}}},
+		{"null", `{"num": null}`, []FieldError{{"num", "must be a number"}}},
+		{"huge exponent", `{"num": 1e999999}`, []FieldError{{"num", "has too many digits"}}},
+		{"missing", `{}`, []FieldError{{"num", "is required"}}},
+		{
+			"unknown fields",
+			`{"num": 1, "z": 1, "a": 2}`,
+			[]FieldError{{"a", "unknown field"}, {"z", "unknown field"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeUserNum(strings.NewReader(tc.body))
+
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tc.expected, verr.Errors)
+		})
+	}
+}
+
+func TestDecodeUserNum_Metadata(t *testing.T) {
+	userNum, err := DecodeUserNum(strings.NewReader(`{"num": 1, "source": "sensor", "labels": {"env": "prod", "zone": "a"}}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "sensor", userNum.Source)
+	assert.Equal(t, map[string]string{"env": "prod", "zone": "a"}, userNum.Labels)
+
+	_, err = DecodeUserNum(strings.NewReader(`{"num": 1, "source": 5, "labels": {"bad key": "x", "env": 1}}`))
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{
+		{"source", "must be a string"},
+		{"labels", "must be an object of strings"},
+	}, verr.Errors)
+
+	_, err = DecodeUserNum(strings.NewReader(`{"num": 1, "labels": {"bad key": "x"}}`))
+
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "labels.bad key", verr.Errors[0].Field)
+}
+
+func TestDecodeUserNum_Malformed(t *testing.T) {
+	for _, body := range []string{``, `invalid json{`, `[1]`, `{"num": 1} {"num": 2}`} {
+		_, err := DecodeUserNum(strings.NewReader(body))
+
+		assert.ErrorIs(t, err, ErrMalformedRequest, body)
+	}
+}
+
+func TestValidationPolicy_Modes(t *testing.T) {
+	testCases := []struct {
+		mode     domain.NumberMode
+		input    string
+		expected string
+		errMsg   string
+	}{
+		{domain.ModeInt64, "5", "5", ""},
+		{domain.ModeInt64, "5.00", "5", ""},
+		{domain.ModeInt64, "3.7", "", "must be an integer"},
+		{domain.ModeInt64, "9223372036854775808", "", "is out of range for a 64-bit integer"},
+		{domain.ModeBigInt, "9223372036854775808", "9223372036854775808", ""},
+		{domain.ModeBigInt, "1e20", "100000000000000000000", ""},
+		{domain.ModeBigInt, "0.5", "", "must be an integer"},
+		{domain.ModeDecimal, "3.70", "3.70", ""},
+		{domain.ModeDecimal, "-0.000001", "-0.000001", ""},
+		{domain.ModeDecimal, "Infinity", "", "must be a finite number"},
+		{domain.ModeDecimal, "-0.0", "0", ""},
+		{domain.ModeFloat64, "-0.0", "-0", ""},
+		{domain.ModeFloat64, "0.1", "0.1", ""},
+		{domain.ModeFloat64, "5", "5", ""},
+		{domain.ModeFloat64, "1e400", "", "is out of range for a 64-bit float"},
+		{domain.ModeFloat64, "-Infinity", "-Infinity", ""},
+		{domain.ModeFloat64, "NaN", "", "must not be NaN"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.mode)+" "+tc.input, func(t *testing.T) {
+			policy := &ValidationPolicy{Mode: tc.mode}
+
+			num, err := policy.Validate(domain.MustParseNumber(tc.input))
+
+			if tc.errMsg != "" {
+				var verr *ValidationError
+				require.ErrorAs(t, err, &verr)
+				assert.Equal(t, []FieldError{{"num", tc.errMsg}}, verr.Errors)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, num.String())
+		})
+	}
+}
+
+func TestValidationPolicy_Validate(t *testing.T) {
+	lo, hi := domain.NewInt(-10), domain.MustParseNumber("10.5")
+	policy := &ValidationPolicy{
+		Mode:  domain.ModeDecimal,
+		Min:   &lo,
+		Max:   &hi,
+		Allow: []domain.Number{domain.NewInt(-20), domain.NewInt(1), domain.MustParseNumber("2.5"), domain.NewInt(3)},
+		Deny:  []domain.Number{domain.NewInt(3)},
+	}
+
+	_, err := policy.Validate(domain.MustParseNumber("2.50"))
+	assert.NoError(t, err)
+
+	var verr *ValidationError
+
+	_, err = policy.Validate(domain.NewInt(-20))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{{"num", "must be greater than or equal to -10"}}, verr.Errors)
+
+	_, err = policy.Validate(domain.NewInt(11))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{
+		{"num", "must be less than or equal to 10.5"},
+		{"num", "is not in the allowed list"},
+	}, verr.Errors)
+
+	_, err = policy.Validate(domain.NewInt(3))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{{"num", "is not allowed"}}, verr.Errors)
+}
+
+func TestValidationPolicy_DigitLimits(t *testing.T) {
+	policy := &ValidationPolicy{Mode: domain.ModeDecimal, MaxIntegerDigits: 3, MaxScale: 2}
+
+	_, err := policy.Validate(domain.MustParseNumber("-999.99"))
+	assert.NoError(t, err)
+	_, err = policy.Validate(domain.MustParseNumber("0.05"))
+	assert.NoError(t, err)
+
+	var verr *ValidationError
+	_, err = policy.Validate(domain.MustParseNumber("1e3"))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{{"num", "must have at most 3 digits before the decimal point"}}, verr.Errors)
+
+	_, err = policy.Validate(domain.MustParseNumber("1.125"))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []FieldError{{"num", "must have at most 2 decimal places"}}, verr.Errors)
+
+	// float64 stores every number in eight bytes.
+	policy.Mode = domain.ModeFloat64
+	_, err = policy.Validate(domain.MustParseNumber("1e300"))
+	assert.NoError(t, err)
+}
+
+func TestValidationPolicy_NilAcceptsInt64(t *testing.T) {
+	var policy *ValidationPolicy
+
+	_, err := policy.Validate(domain.NewInt(-9223372036854775808))
+	assert.NoError(t, err)
+
+	_, err = policy.Validate(domain.MustParseNumber("0.5"))
+	assert.Error(t, err)
+}
+
+func TestValidationPolicy_AllowNaN(t *testing.T) {
+	maxNum := domain.NewInt(10)
+	policy := &ValidationPolicy{Mode: domain.ModeFloat64, AllowNaN: true, Max: &maxNum}
+
+	num, err := policy.Validate(domain.MustParseNumber("NaN"))
+	require.NoError(t, err)
+	assert.True(t, num.IsNaN())
+
+	_, err = policy.Validate(domain.MustParseNumber("Infinity"))
+	assert.Error(t, err)
+}
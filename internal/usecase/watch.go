@@ -0,0 +1,41 @@
+package usecase
+
+import "testovoe/internal/domain"
+
+const subscriberBuffer = 64
+
+// Subscribe returns a channel receiving every number stored after the call.
+// Slow subscribers miss numbers instead of blocking writers. The returned
+// function must be called to release the subscription.
+func (u *UseCase) Subscribe() (<-chan domain.Number, func()) {
+	ch := make(chan domain.Number, subscriberBuffer)
+
+	u.mu.Lock()
+	if u.subscribers == nil {
+		u.subscribers = make(map[chan domain.Number]struct{})
+	}
+	u.subscribers[ch] = struct{}{}
+	u.mu.Unlock()
+
+	return ch, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		if _, ok := u.subscribers[ch]; ok {
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (u *UseCase) publish(number domain.Number) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for ch := range u.subscribers {
+		select {
+		case ch <- number:
+		default:
+		}
+	}
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE nums ALTER COLUMN num TYPE BIGINT;
+
+-- +goose Down
+ALTER TABLE nums ALTER COLUMN num TYPE INT;
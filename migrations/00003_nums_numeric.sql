@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE nums ALTER COLUMN num TYPE NUMERIC;
+
+-- +goose Down
+-- Fails if values outside the BIGINT range or with fractional digits were stored.
+ALTER TABLE nums ALTER COLUMN num TYPE BIGINT;
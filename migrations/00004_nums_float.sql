@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE nums ADD COLUMN num_float DOUBLE PRECISION;
+ALTER TABLE nums ALTER COLUMN num DROP NOT NULL;
+ALTER TABLE nums ADD CONSTRAINT nums_one_value CHECK ((num IS NULL) <> (num_float IS NULL));
+
+-- +goose Down
+-- Rows written in float64 mode have no NUMERIC value and are dropped.
+DELETE FROM nums WHERE num IS NULL;
+ALTER TABLE nums DROP CONSTRAINT nums_one_value;
+ALTER TABLE nums ALTER COLUMN num SET NOT NULL;
+ALTER TABLE nums DROP COLUMN num_float;
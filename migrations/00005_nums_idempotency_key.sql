@@ -0,0 +1,16 @@
+-- +goose Up
+-- Idempotency keys are scoped by client and remember which number they were
+-- first used with. They live apart from nums since a unique index on a
+-- partitioned table has to include the partition key.
+CREATE TABLE nums_idempotency (
+    client TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (client, key)
+);
+CREATE INDEX nums_idempotency_created_at ON nums_idempotency (created_at);
+
+-- +goose Down
+DROP TABLE nums_idempotency;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE nums ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX nums_created_at ON nums (created_at, id);
+
+-- Rows evicted by an archiving retention policy.
+CREATE TABLE nums_archive (LIKE nums);
+
+-- +goose Down
+-- Fails once nums has been converted to a partitioned table.
+DROP TABLE nums_archive;
+DROP INDEX nums_created_at;
+ALTER TABLE nums DROP COLUMN created_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE nums ADD COLUMN client TEXT;
+ALTER TABLE nums ADD COLUMN source TEXT;
+ALTER TABLE nums ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX nums_labels ON nums USING GIN (labels jsonb_path_ops);
+
+-- Archived rows are moved with SELECT *, so the columns must line up.
+ALTER TABLE nums_archive ADD COLUMN client TEXT;
+ALTER TABLE nums_archive ADD COLUMN source TEXT;
+ALTER TABLE nums_archive ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE nums_archive DROP COLUMN labels;
+ALTER TABLE nums_archive DROP COLUMN source;
+ALTER TABLE nums_archive DROP COLUMN client;
+DROP INDEX nums_labels;
+ALTER TABLE nums DROP COLUMN labels;
+ALTER TABLE nums DROP COLUMN source;
+ALTER TABLE nums DROP COLUMN client;
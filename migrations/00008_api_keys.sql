@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    hash        TEXT NOT NULL,
+    scopes      TEXT[] NOT NULL,
+    collections TEXT[] NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at  TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE api_keys;
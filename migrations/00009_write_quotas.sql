@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE write_quotas (
+    client TEXT NOT NULL,
+    day    DATE NOT NULL,
+    used   BIGINT NOT NULL,
+    PRIMARY KEY (client, day)
+);
+
+-- +goose Down
+DROP TABLE write_quotas;
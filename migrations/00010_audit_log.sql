@@ -0,0 +1,41 @@
+-- +goose Up
+-- The single head row holds the last sequence number and hash. Every append
+-- updates it, which orders appends and lets each one chain to the last.
+CREATE TABLE audit_head (
+    id   BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    seq  BIGINT NOT NULL,
+    hash TEXT NOT NULL
+);
+INSERT INTO audit_head (seq, hash) VALUES (0, repeat('0', 64));
+
+-- entry holds the exact JSON that was hashed; the other columns copy fields
+-- out of it for filtering.
+CREATE TABLE audit_log (
+    seq        BIGINT PRIMARY KEY,
+    at         TIMESTAMPTZ NOT NULL,
+    principal  TEXT,
+    operation  TEXT NOT NULL,
+    request_id TEXT,
+    outcome    TEXT NOT NULL,
+    num_id     BIGINT,
+    entry      TEXT NOT NULL,
+    hash       TEXT NOT NULL
+);
+CREATE INDEX audit_log_at ON audit_log (at);
+CREATE INDEX audit_log_principal ON audit_log (principal, seq);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+DROP TABLE audit_head;